@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"dojo-api/pkg/agreement"
+	"dojo-api/pkg/orm"
+	"dojo-api/utils"
+
+	"github.com/rs/zerolog/log"
+)
+
+/*
+Usage:
+This script is used to recompute the inter-annotator agreement of tasks over historical task results.
+
+go run cmd/agreement/main.go recompute
+  - Recomputes the agreement of every task that has results.
+
+go run cmd/agreement/main.go recompute 2024-01-01T00:00:00Z
+  - Recomputes the agreement of tasks created since the given RFC3339 timestamp.
+*/
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "recompute" {
+		log.Error().Msg("No action provided. Use 'recompute' with an optional RFC3339 start timestamp")
+		return
+	}
+
+	var since *time.Time
+	if len(os.Args) > 2 {
+		since = utils.ParseDate(os.Args[2])
+		if since == nil {
+			log.Error().Str("since", os.Args[2]).Msg("Invalid start timestamp, expected RFC3339")
+			return
+		}
+	}
+	defer orm.GetConnHandler().OnShutdown()
+
+	agreementService := agreement.NewAgreementService()
+	numTasks, err := agreementService.RecomputeAll(context.Background(), since)
+	if err != nil {
+		log.Error().Err(err).Int("numTasks", numTasks).Msg("Failed to recompute task agreement")
+		return
+	}
+	log.Info().Int("numTasks", numTasks).Msg("Recomputed task agreement")
+}
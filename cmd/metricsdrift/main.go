@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"dojo-api/pkg/metric"
+	"dojo-api/pkg/orm"
+
+	"github.com/rs/zerolog/log"
+)
+
+/*
+Usage:
+This script is used to recompute the public metrics from the source tables and compare them against the Metrics table
+and the Redis counters, which drift after a Redis flush or a failed metric update.
+
+go run cmd/metricsdrift/main.go check
+  - Prints the recomputed, stored and cached value of every metric.
+
+go run cmd/metricsdrift/main.go fix
+  - Same as check, then overwrites the Metrics rows and Redis counters that drifted with the recomputed values.
+*/
+
+func main() {
+	if len(os.Args) < 2 || (os.Args[1] != "check" && os.Args[1] != "fix") {
+		log.Error().Msg("No action provided. Use 'check' or 'fix'")
+		return
+	}
+	defer orm.GetConnHandler().OnShutdown()
+
+	report, err := metric.NewMetricService().CheckMetricDrift(context.Background(), os.Args[1] == "fix")
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to check metric drift")
+		return
+	}
+
+	fmt.Printf("%-30s %10s %10s %10s\n", "METRIC", "ACTUAL", "STORED", "CACHED")
+	for _, drift := range report.Metrics {
+		marker := ""
+		if drift.HasDrift() {
+			marker = " *"
+		}
+		fmt.Printf("%-30s %10d %10s %10s%s\n", drift.Type, drift.Actual, formatValue(drift.Stored), formatValue(drift.Cached), marker)
+	}
+
+	fmt.Printf("\n%-30s %10s %10s %14s %14s\n", "COMPLETION TIMES", "COUNT", "STORED", "SUM", "STORED")
+	for _, drift := range report.CompletionStats {
+		marker := ""
+		if drift.HasDrift() {
+			marker = " *"
+		}
+		storedSum := "-"
+		if drift.StoredSum != nil {
+			storedSum = fmt.Sprintf("%.0f", *drift.StoredSum)
+		}
+		fmt.Printf("%-30s %10d %10s %14.0f %14s%s\n", drift.TaskType, drift.ActualCount, formatValue(drift.StoredCount), drift.ActualSum, storedSum, marker)
+	}
+	if !report.HasDrift() {
+		fmt.Println("No drift")
+	} else if report.Fixed {
+		fmt.Println("Fixed the metrics marked with *")
+	}
+}
+
+func formatValue(value *int) string {
+	if value == nil {
+		return "-"
+	}
+	return fmt.Sprint(*value)
+}
@@ -97,8 +97,8 @@ func (o *FixtureService) CreateDefaultTask(ctx context.Context, title string, ex
 
 	clientWrapper := orm.GetPrismaClient()
 
-	clientWrapper.BeforeQuery()
-	defer clientWrapper.AfterQuery()
+	queryTimer := clientWrapper.BeforeQuery()
+	defer clientWrapper.AfterQuery(queryTimer)
 
 	expireAt := time.Now().Add(expireDuration)
 
@@ -11,7 +11,10 @@ import (
 
 	"dojo-api/pkg/api"
 	"dojo-api/pkg/cache"
+	"dojo-api/pkg/leaderboard"
+	"dojo-api/pkg/metric"
 	"dojo-api/pkg/orm"
+	"dojo-api/pkg/settlement"
 	"dojo-api/utils"
 
 	_ "dojo-api/docs"
@@ -19,6 +22,7 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog/log"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -30,8 +34,16 @@ import (
 
 func main() {
 	loadEnvVars()
+	// refuse to start without it, aliases keyed with an empty secret can be reversed by hashing candidate model names
+	utils.LoadDotEnv("MODEL_ALIAS_SECRET")
+	// same for the leaderboard pseudonyms, which would otherwise be traceable to worker IDs
+	utils.LoadDotEnv("LEADERBOARD_PSEUDONYM_SECRET")
 	go continuouslyReadEnv()
 	go orm.NewTaskORM().UpdateExpiredTasks(context.Background())
+	go settlement.NewSettlementService().SettleFinishedTasks(context.Background())
+	go leaderboard.NewLeaderboardService().RefreshLeaderboards(context.Background())
+	go metric.NewMetricService().SnapshotMetricsHourly(context.Background())
+	go metric.NewMetricService().CheckMetricDriftPeriodically(context.Background())
 
 	runtimeEnv := utils.LoadDotEnv("RUNTIME_ENV")
 	if runtimeEnv == "aws" {
@@ -60,11 +72,14 @@ func main() {
 	router := gin.New()                          // empty engine
 	router.Use(gin.Recovery())                   // add recovery middleware
 	router.Use(api.CustomGinLogger(&log.Logger)) // add our custom gin logger
+	router.Use(api.PrometheusMiddleware())       // add request metrics for /metrics
 
 	router.Use(cors.New(config))
 	router.ForwardedByClientIP = true
 	api.LoginRoutes(router)
 
+	router.GET(api.MetricsRoute, api.MetricsAuthMiddleware(), gin.WrapH(promhttp.Handler()))
+
 	if os.Getenv("RUNTIME_ENV") == "local" {
 		router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 	}
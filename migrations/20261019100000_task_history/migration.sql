@@ -0,0 +1,17 @@
+-- CreateTable
+CREATE TABLE "TaskHistory" (
+    "id" TEXT NOT NULL,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(3) NOT NULL,
+    "task_id" TEXT NOT NULL,
+    "miner_user_id" TEXT NOT NULL,
+    "changes" JSONB NOT NULL,
+
+    CONSTRAINT "TaskHistory_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE INDEX "TaskHistory_task_id_created_at_idx" ON "TaskHistory"("task_id", "created_at");
+
+-- AddForeignKey
+ALTER TABLE "TaskHistory" ADD CONSTRAINT "TaskHistory_task_id_fkey" FOREIGN KEY ("task_id") REFERENCES "Task"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
@@ -0,0 +1,18 @@
+-- CreateTable
+CREATE TABLE "TaskAgreement" (
+    "id" TEXT NOT NULL,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(3) NOT NULL,
+    "task_id" TEXT NOT NULL,
+    "num_results" INTEGER NOT NULL,
+    "krippendorff_alpha" DOUBLE PRECISION,
+    "kendall_w" DOUBLE PRECISION,
+
+    CONSTRAINT "TaskAgreement_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "TaskAgreement_task_id_key" ON "TaskAgreement"("task_id");
+
+-- AddForeignKey
+ALTER TABLE "TaskAgreement" ADD CONSTRAINT "TaskAgreement_task_id_fkey" FOREIGN KEY ("task_id") REFERENCES "Task"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
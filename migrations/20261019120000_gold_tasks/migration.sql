@@ -0,0 +1,24 @@
+-- AlterTable
+ALTER TABLE "Task" ADD COLUMN     "gold_answers" JSONB,
+ADD COLUMN     "is_gold" BOOLEAN NOT NULL DEFAULT false;
+
+-- AlterTable
+ALTER TABLE "TaskResult" ADD COLUMN     "gold_passed" BOOLEAN;
+
+-- CreateTable
+CREATE TABLE "WorkerGoldAccuracy" (
+    "id" TEXT NOT NULL,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(3) NOT NULL,
+    "worker_id" TEXT NOT NULL,
+    "num_graded" INTEGER NOT NULL DEFAULT 0,
+    "num_passed" INTEGER NOT NULL DEFAULT 0,
+
+    CONSTRAINT "WorkerGoldAccuracy_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "WorkerGoldAccuracy_worker_id_key" ON "WorkerGoldAccuracy"("worker_id");
+
+-- AddForeignKey
+ALTER TABLE "WorkerGoldAccuracy" ADD CONSTRAINT "WorkerGoldAccuracy_worker_id_fkey" FOREIGN KEY ("worker_id") REFERENCES "DojoWorker"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
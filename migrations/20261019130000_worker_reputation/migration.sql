@@ -0,0 +1,28 @@
+-- AlterTable
+ALTER TABLE "Task" ADD COLUMN     "min_reputation" DOUBLE PRECISION;
+
+-- CreateTable
+CREATE TABLE "WorkerReputation" (
+    "id" TEXT NOT NULL,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(3) NOT NULL,
+    "worker_id" TEXT NOT NULL,
+    "score" DOUBLE PRECISION NOT NULL,
+    "gold_accuracy" DOUBLE PRECISION NOT NULL,
+    "consensus_agreement" DOUBLE PRECISION NOT NULL,
+    "invalid_rate" DOUBLE PRECISION NOT NULL,
+    "account_age_days" INTEGER NOT NULL,
+    "consensus_sum" DOUBLE PRECISION NOT NULL DEFAULT 0,
+    "consensus_count" INTEGER NOT NULL DEFAULT 0,
+
+    CONSTRAINT "WorkerReputation_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "WorkerReputation_worker_id_key" ON "WorkerReputation"("worker_id");
+
+-- CreateIndex
+CREATE INDEX "WorkerReputation_score_idx" ON "WorkerReputation"("score");
+
+-- AddForeignKey
+ALTER TABLE "WorkerReputation" ADD CONSTRAINT "WorkerReputation_worker_id_fkey" FOREIGN KEY ("worker_id") REFERENCES "DojoWorker"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
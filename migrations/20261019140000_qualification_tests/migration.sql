@@ -0,0 +1,49 @@
+-- CreateEnum
+CREATE TYPE "QualificationStatus" AS ENUM ('PASSED', 'FAILED');
+
+-- CreateTable
+CREATE TABLE "QualificationTest" (
+    "id" TEXT NOT NULL,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(3) NOT NULL,
+    "miner_user_id" TEXT NOT NULL,
+    "task_type" "TaskType" NOT NULL,
+    "subscription_key" TEXT,
+    "title" TEXT NOT NULL,
+    "questions" JSONB NOT NULL,
+    "pass_threshold" DOUBLE PRECISION NOT NULL,
+    "cooldown_hours" INTEGER NOT NULL,
+    "is_active" BOOLEAN NOT NULL DEFAULT true,
+
+    CONSTRAINT "QualificationTest_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateTable
+CREATE TABLE "WorkerQualification" (
+    "id" TEXT NOT NULL,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(3) NOT NULL,
+    "test_id" TEXT NOT NULL,
+    "worker_id" TEXT NOT NULL,
+    "status" "QualificationStatus" NOT NULL,
+    "score" DOUBLE PRECISION NOT NULL,
+    "attempts" INTEGER NOT NULL,
+    "last_attempt_at" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "WorkerQualification_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE INDEX "QualificationTest_miner_user_id_task_type_idx" ON "QualificationTest"("miner_user_id", "task_type");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "WorkerQualification_test_id_worker_id_key" ON "WorkerQualification"("test_id", "worker_id");
+
+-- AddForeignKey
+ALTER TABLE "QualificationTest" ADD CONSTRAINT "QualificationTest_miner_user_id_fkey" FOREIGN KEY ("miner_user_id") REFERENCES "MinerUser"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "WorkerQualification" ADD CONSTRAINT "WorkerQualification_test_id_fkey" FOREIGN KEY ("test_id") REFERENCES "QualificationTest"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "WorkerQualification" ADD CONSTRAINT "WorkerQualification_worker_id_fkey" FOREIGN KEY ("worker_id") REFERENCES "DojoWorker"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
@@ -0,0 +1,20 @@
+-- CreateTable
+CREATE TABLE "WorkerScoreBucket" (
+    "id" TEXT NOT NULL,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(3) NOT NULL,
+    "worker_id" TEXT NOT NULL,
+    "criteria_type" TEXT NOT NULL,
+    "bucket" INTEGER NOT NULL,
+    "count" INTEGER NOT NULL,
+    "sum" DOUBLE PRECISION NOT NULL,
+    "sum_sq" DOUBLE PRECISION NOT NULL,
+
+    CONSTRAINT "WorkerScoreBucket_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "WorkerScoreBucket_worker_id_criteria_type_bucket_key" ON "WorkerScoreBucket"("worker_id", "criteria_type", "bucket");
+
+-- AddForeignKey
+ALTER TABLE "WorkerScoreBucket" ADD CONSTRAINT "WorkerScoreBucket_worker_id_fkey" FOREIGN KEY ("worker_id") REFERENCES "DojoWorker"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
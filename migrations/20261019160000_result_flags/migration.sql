@@ -0,0 +1,35 @@
+-- CreateEnum
+CREATE TYPE "ResultFlagStatus" AS ENUM ('PENDING', 'CONFIRMED', 'DISMISSED');
+
+-- AlterTable
+ALTER TABLE "TaskResult" ADD COLUMN     "client_ip" TEXT,
+ADD COLUMN     "fingerprint" TEXT;
+
+-- CreateTable
+CREATE TABLE "ResultFlag" (
+    "id" TEXT NOT NULL,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(3) NOT NULL,
+    "task_result_id" TEXT NOT NULL,
+    "cluster_id" TEXT NOT NULL,
+    "reasons" TEXT[],
+    "score" DOUBLE PRECISION NOT NULL,
+    "status" "ResultFlagStatus" NOT NULL DEFAULT 'PENDING',
+
+    CONSTRAINT "ResultFlag_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE INDEX "TaskResult_task_id_fingerprint_idx" ON "TaskResult"("task_id", "fingerprint");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "ResultFlag_task_result_id_key" ON "ResultFlag"("task_result_id");
+
+-- CreateIndex
+CREATE INDEX "ResultFlag_status_created_at_idx" ON "ResultFlag"("status", "created_at");
+
+-- CreateIndex
+CREATE INDEX "ResultFlag_cluster_id_idx" ON "ResultFlag"("cluster_id");
+
+-- AddForeignKey
+ALTER TABLE "ResultFlag" ADD CONSTRAINT "ResultFlag_task_result_id_fkey" FOREIGN KEY ("task_result_id") REFERENCES "TaskResult"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
@@ -0,0 +1,46 @@
+-- CreateEnum
+CREATE TYPE "DwellTimeAction" AS ENUM ('REJECT', 'FLAG');
+
+-- AlterTable
+ALTER TABLE "TaskResult" ADD COLUMN     "time_on_task" DOUBLE PRECISION;
+
+-- CreateTable
+CREATE TABLE "TaskFetch" (
+    "id" TEXT NOT NULL,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(3) NOT NULL,
+    "task_id" TEXT NOT NULL,
+    "worker_id" TEXT NOT NULL,
+    "first_fetched_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "last_fetched_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT "TaskFetch_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateTable
+CREATE TABLE "MinerDwellTime" (
+    "id" TEXT NOT NULL,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(3) NOT NULL,
+    "miner_user_id" TEXT NOT NULL,
+    "task_type" "TaskType" NOT NULL,
+    "min_seconds" INTEGER NOT NULL,
+    "action" "DwellTimeAction" NOT NULL DEFAULT 'REJECT',
+
+    CONSTRAINT "MinerDwellTime_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "TaskFetch_task_id_worker_id_key" ON "TaskFetch"("task_id", "worker_id");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "MinerDwellTime_miner_user_id_task_type_key" ON "MinerDwellTime"("miner_user_id", "task_type");
+
+-- AddForeignKey
+ALTER TABLE "TaskFetch" ADD CONSTRAINT "TaskFetch_task_id_fkey" FOREIGN KEY ("task_id") REFERENCES "Task"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "TaskFetch" ADD CONSTRAINT "TaskFetch_worker_id_fkey" FOREIGN KEY ("worker_id") REFERENCES "DojoWorker"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "MinerDwellTime" ADD CONSTRAINT "MinerDwellTime_miner_user_id_fkey" FOREIGN KEY ("miner_user_id") REFERENCES "MinerUser"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
@@ -0,0 +1,2 @@
+-- AlterTable
+ALTER TABLE "TaskResult" ADD COLUMN     "response_order" INTEGER[];
@@ -0,0 +1,23 @@
+-- CreateEnum
+CREATE TYPE "SettlementPolicy" AS ENUM ('EQUAL_SHARE', 'CONSENSUS_WEIGHTED');
+
+-- CreateTable
+CREATE TABLE "TaskSettlement" (
+    "id" TEXT NOT NULL,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(3) NOT NULL,
+    "task_id" TEXT NOT NULL,
+    "policy" "SettlementPolicy" NOT NULL,
+    "total_reward" DOUBLE PRECISION NOT NULL,
+    "total_paid" DOUBLE PRECISION NOT NULL,
+    "num_valid" INTEGER NOT NULL,
+    "shares" JSONB NOT NULL,
+
+    CONSTRAINT "TaskSettlement_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "TaskSettlement_task_id_key" ON "TaskSettlement"("task_id");
+
+-- AddForeignKey
+ALTER TABLE "TaskSettlement" ADD CONSTRAINT "TaskSettlement_task_id_fkey" FOREIGN KEY ("task_id") REFERENCES "Task"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
@@ -0,0 +1,25 @@
+-- CreateEnum
+CREATE TYPE "StakeEntryType" AS ENUM ('DEPOSIT', 'LOCK', 'RELEASE', 'SLASH');
+
+-- CreateTable
+CREATE TABLE "StakeLedgerEntry" (
+    "id" TEXT NOT NULL,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "worker_id" TEXT NOT NULL,
+    "type" "StakeEntryType" NOT NULL,
+    "amount" DOUBLE PRECISION NOT NULL,
+    "task_id" TEXT,
+    "task_result_id" TEXT,
+    "reference" TEXT,
+
+    CONSTRAINT "StakeLedgerEntry_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE INDEX "StakeLedgerEntry_worker_id_created_at_idx" ON "StakeLedgerEntry"("worker_id", "created_at");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "StakeLedgerEntry_task_result_id_type_key" ON "StakeLedgerEntry"("task_result_id", "type");
+
+-- AddForeignKey
+ALTER TABLE "StakeLedgerEntry" ADD CONSTRAINT "StakeLedgerEntry_worker_id_fkey" FOREIGN KEY ("worker_id") REFERENCES "DojoWorker"("id") ON DELETE RESTRICT ON UPDATE CASCADE;
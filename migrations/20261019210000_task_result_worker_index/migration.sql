@@ -0,0 +1,2 @@
+-- CreateIndex
+CREATE INDEX "TaskResult_worker_id_created_at_idx" ON "TaskResult"("worker_id", "created_at");
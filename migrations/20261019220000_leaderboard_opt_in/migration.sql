@@ -0,0 +1,2 @@
+-- AlterTable
+ALTER TABLE "DojoWorker" ADD COLUMN     "leaderboard_opt_in" BOOLEAN NOT NULL DEFAULT false;
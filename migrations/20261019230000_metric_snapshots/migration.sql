@@ -0,0 +1,18 @@
+-- CreateTable
+CREATE TABLE "MetricSnapshot" (
+    "id" TEXT NOT NULL,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(3) NOT NULL,
+    "type" "MetricsType" NOT NULL,
+    "bucket_start" TIMESTAMP(3) NOT NULL,
+    "metrics_data" JSONB NOT NULL,
+
+    CONSTRAINT "MetricSnapshot_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "MetricSnapshot_type_bucket_start_key" ON "MetricSnapshot"("type", "bucket_start");
+
+-- Seed the history with the current value of every metric
+INSERT INTO "MetricSnapshot" ("id", "updated_at", "type", "bucket_start", "metrics_data")
+SELECT gen_random_uuid()::text, now(), "type", date_trunc('hour', "updated_at"), "metrics_data" FROM "Metrics";
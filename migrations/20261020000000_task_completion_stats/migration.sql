@@ -0,0 +1,35 @@
+-- CreateTable
+CREATE TABLE "TaskCompletionStats" (
+    "id" TEXT NOT NULL,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(3) NOT NULL,
+    "task_type" "TaskType" NOT NULL,
+    "count" INTEGER NOT NULL,
+    "sum" DOUBLE PRECISION NOT NULL,
+    "min" DOUBLE PRECISION NOT NULL,
+    "max" DOUBLE PRECISION NOT NULL,
+    "buckets" JSONB NOT NULL,
+
+    CONSTRAINT "TaskCompletionStats_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "TaskCompletionStats_task_type_key" ON "TaskCompletionStats"("task_type");
+
+-- Seed the statistics from the existing completion events, buckets use the same gamma of 1.01 / 0.99 as pkg/metric
+WITH times AS (
+    SELECT t."type" AS task_type, ("e"."events_data"->>'task_completion_time')::float AS x
+    FROM "Events" e
+    JOIN "Task" t ON t."id" = "e"."events_data"->>'task_id'
+    WHERE "e"."type" = 'TASK_COMPLETION_TIME'
+), buckets AS (
+    SELECT task_type,
+        CASE WHEN x <= 0 THEN 'zero' ELSE ceil(ln(x) / ln(1.01 / 0.99))::int::text END AS bucket,
+        COUNT(*) AS n, SUM(x) AS total, MIN(x) AS min_x, MAX(x) AS max_x
+    FROM times
+    GROUP BY task_type, bucket
+)
+INSERT INTO "TaskCompletionStats" ("id", "updated_at", "task_type", "count", "sum", "min", "max", "buckets")
+SELECT gen_random_uuid()::text, now(), task_type, SUM(n), SUM(total), MIN(min_x), MAX(max_x), jsonb_object_agg(bucket, n)
+FROM buckets
+GROUP BY task_type;
@@ -0,0 +1,2 @@
+-- AlterEnum
+ALTER TYPE "StakeEntryType" ADD VALUE 'CLAWBACK';
@@ -0,0 +1,226 @@
+package agreement
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"dojo-api/db"
+	"dojo-api/pkg/orm"
+	"dojo-api/pkg/task"
+
+	"github.com/rs/zerolog/log"
+)
+
+// number of tasks recomputed at a time when backfilling historical data
+const recomputeBatchSize = 200
+
+type AgreementService struct {
+	taskORM          *orm.TaskORM
+	taskResultORM    *orm.TaskResultORM
+	taskAgreementORM *orm.TaskAgreementORM
+}
+
+func NewAgreementService() *AgreementService {
+	return &AgreementService{
+		taskORM:          orm.NewTaskORM(),
+		taskResultORM:    orm.NewTaskResultORM(),
+		taskAgreementORM: orm.NewTaskAgreementORM(),
+	}
+}
+
+// GetTaskAgreement returns the stored agreement of a task, computing it first if it was never stored
+func (s *AgreementService) GetTaskAgreement(ctx context.Context, taskId string) (*TaskAgreementResponse, error) {
+	taskAgreement, err := s.taskAgreementORM.GetByTaskId(ctx, taskId)
+	if err == nil {
+		return buildTaskAgreementResponse(taskAgreement), nil
+	}
+	if !errors.Is(err, db.ErrNotFound) {
+		log.Error().Err(err).Str("taskId", taskId).Msg("Error getting task agreement")
+		return nil, err
+	}
+
+	taskModel, err := s.taskORM.GetById(ctx, taskId)
+	if err != nil {
+		return nil, err
+	}
+	return s.RefreshTaskAgreement(ctx, taskModel)
+}
+
+// RefreshTaskAgreement recomputes the agreement of a task from its COMPLETED results and stores it
+func (s *AgreementService) RefreshTaskAgreement(ctx context.Context, taskModel *db.TaskModel) (*TaskAgreementResponse, error) {
+	taskResults, err := s.taskResultORM.GetTaskResultsByTaskId(ctx, taskModel.ID)
+	if err != nil {
+		log.Error().Err(err).Str("taskId", taskModel.ID).Msg("Error getting task results for agreement")
+		return nil, err
+	}
+
+	numResults, krippendorffAlpha, kendallW, err := ComputeTaskAgreement(taskModel, taskResults)
+	if err != nil {
+		log.Error().Err(err).Str("taskId", taskModel.ID).Msg("Error computing task agreement")
+		return nil, err
+	}
+
+	taskAgreement, err := s.taskAgreementORM.UpsertTaskAgreement(ctx, taskModel.ID, numResults, krippendorffAlpha, kendallW)
+	if err != nil {
+		log.Error().Err(err).Str("taskId", taskModel.ID).Msg("Error storing task agreement")
+		return nil, err
+	}
+	return buildTaskAgreementResponse(taskAgreement), nil
+}
+
+// GetMinerAgreementRollup averages the agreement of the miner's tasks per time window
+func (s *AgreementService) GetMinerAgreementRollup(ctx context.Context, minerUserId string, params RollupParams) (*MinerAgreementResponse, error) {
+	rows, err := s.taskAgreementORM.GetRollupByMinerUser(ctx, minerUserId, string(params.Interval), params.From, params.To)
+	if err != nil {
+		log.Error().Err(err).Str("minerUserId", minerUserId).Msg("Error getting agreement rollup")
+		return nil, err
+	}
+
+	windows := make([]AgreementWindow, 0, len(rows))
+	for _, row := range rows {
+		numTasks, err := strconv.Atoi(string(row.NumTasks))
+		if err != nil {
+			return nil, err
+		}
+		numResults, err := strconv.Atoi(string(row.NumResults))
+		if err != nil {
+			return nil, err
+		}
+
+		window := AgreementWindow{
+			WindowStart: row.WindowStart.Time,
+			NumTasks:    numTasks,
+			NumResults:  numResults,
+		}
+		if row.MeanKrippendorffAlpha != nil {
+			value := float64(*row.MeanKrippendorffAlpha)
+			window.MeanKrippendorffAlpha = &value
+		}
+		if row.MeanKendallW != nil {
+			value := float64(*row.MeanKendallW)
+			window.MeanKendallW = &value
+		}
+		windows = append(windows, window)
+	}
+
+	return &MinerAgreementResponse{
+		Interval: params.Interval,
+		From:     params.From,
+		To:       params.To,
+		Windows:  windows,
+	}, nil
+}
+
+// RecomputeAll recomputes the agreement of every task that has results, optionally only tasks created since the given time
+func (s *AgreementService) RecomputeAll(ctx context.Context, since *time.Time) (int, error) {
+	numTasks := 0
+	cursor := ""
+	for {
+		tasks, err := s.taskORM.GetTasksWithResults(ctx, since, cursor, recomputeBatchSize)
+		if err != nil {
+			return numTasks, err
+		}
+
+		for i := range tasks {
+			if _, err := s.RefreshTaskAgreement(ctx, &tasks[i]); err != nil {
+				return numTasks, fmt.Errorf("failed to recompute agreement for task %s: %w", tasks[i].ID, err)
+			}
+			numTasks++
+		}
+
+		log.Info().Int("numTasks", numTasks).Msg("Recomputed task agreement batch")
+		if len(tasks) < recomputeBatchSize {
+			break
+		}
+		cursor = tasks[len(tasks)-1].ID
+	}
+	return numTasks, nil
+}
+
+// ComputeTaskAgreement returns the number of COMPLETED results, Krippendorff's alpha over the scores given to each
+// model response and Kendall's W over the rankings implied by each worker's scores, nil when undefined
+func ComputeTaskAgreement(taskModel *db.TaskModel, taskResults []db.TaskResultModel) (int, *float64, *float64, error) {
+	var taskData task.TaskData
+	if err := json.Unmarshal(taskModel.TaskData, &taskData); err != nil {
+		return 0, nil, nil, err
+	}
+
+	// one model to score map per worker
+	votes := make([]map[string]float64, 0, len(taskResults))
+	for _, taskResult := range taskResults {
+		if taskResult.Status != db.TaskResultStatusCompleted {
+			continue
+		}
+
+		var resultData []task.Result
+		if err := json.Unmarshal(taskResult.ResultData, &resultData); err != nil {
+			return 0, nil, nil, err
+		}
+
+		scores := make(map[string]float64)
+		for _, result := range resultData {
+			for _, criteria := range result.Criteria {
+				if scoreCriteria, ok := criteria.(task.ScoreCriteria); ok {
+					scores[result.Model] = scoreCriteria.MinerScore
+					break
+				}
+			}
+		}
+		votes = append(votes, scores)
+	}
+
+	units := make([][]float64, 0, len(taskData.Responses))
+	for _, response := range taskData.Responses {
+		values := make([]float64, 0, len(votes))
+		for _, scores := range votes {
+			if score, ok := scores[response.Model]; ok {
+				values = append(values, score)
+			}
+		}
+		units = append(units, values)
+	}
+
+	// only workers that scored every response produce a full ranking
+	ratings := make([][]float64, 0, len(votes))
+	for _, scores := range votes {
+		row := make([]float64, 0, len(taskData.Responses))
+		for _, response := range taskData.Responses {
+			score, ok := scores[response.Model]
+			if !ok {
+				break
+			}
+			row = append(row, score)
+		}
+		if len(row) == len(taskData.Responses) {
+			ratings = append(ratings, row)
+		}
+	}
+
+	var krippendorffAlpha, kendallW *float64
+	if alpha, ok := KrippendorffAlpha(units); ok {
+		krippendorffAlpha = &alpha
+	}
+	if w, ok := KendallW(ratings); ok {
+		kendallW = &w
+	}
+	return len(votes), krippendorffAlpha, kendallW, nil
+}
+
+func buildTaskAgreementResponse(taskAgreement *db.TaskAgreementModel) *TaskAgreementResponse {
+	response := &TaskAgreementResponse{
+		TaskId:     taskAgreement.TaskID,
+		NumResults: taskAgreement.NumResults,
+		UpdatedAt:  taskAgreement.UpdatedAt,
+	}
+	if alpha, ok := taskAgreement.KrippendorffAlpha(); ok {
+		response.KrippendorffAlpha = &alpha
+	}
+	if w, ok := taskAgreement.KendallW(); ok {
+		response.KendallW = &w
+	}
+	return response
+}
@@ -0,0 +1,41 @@
+package agreement
+
+import "time"
+
+type TaskAgreementResponse struct {
+	TaskId     string `json:"taskId"`
+	NumResults int    `json:"numResults"`
+	// nil when undefined, e.g. fewer than 2 results or every worker gave the same score
+	KrippendorffAlpha *float64  `json:"krippendorffAlpha"`
+	KendallW          *float64  `json:"kendallW"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+type RollupInterval string
+
+const (
+	RollupIntervalDay   RollupInterval = "day"
+	RollupIntervalWeek  RollupInterval = "week"
+	RollupIntervalMonth RollupInterval = "month"
+)
+
+type RollupParams struct {
+	Interval RollupInterval
+	From     time.Time
+	To       time.Time
+}
+
+type AgreementWindow struct {
+	WindowStart           time.Time `json:"windowStart"`
+	NumTasks              int       `json:"numTasks"`
+	NumResults            int       `json:"numResults"`
+	MeanKrippendorffAlpha *float64  `json:"meanKrippendorffAlpha"`
+	MeanKendallW          *float64  `json:"meanKendallW"`
+}
+
+type MinerAgreementResponse struct {
+	Interval RollupInterval    `json:"interval"`
+	From     time.Time         `json:"from"`
+	To       time.Time         `json:"to"`
+	Windows  []AgreementWindow `json:"windows"`
+}
@@ -0,0 +1,115 @@
+package agreement
+
+import "sort"
+
+// KrippendorffAlpha computes alpha with the interval metric. Each unit is one rated item holding the values given
+// by the coders that rated it, units with fewer than 2 values are not pairable and are ignored.
+// Returns false when alpha is undefined, i.e. less than 2 pairable values or no variation at all.
+func KrippendorffAlpha(units [][]float64) (float64, bool) {
+	var observed float64
+	pairable := make([]float64, 0)
+	for _, values := range units {
+		if len(values) < 2 {
+			continue
+		}
+
+		var disagreement float64
+		for i := range values {
+			for j := range values {
+				if i != j {
+					disagreement += (values[i] - values[j]) * (values[i] - values[j])
+				}
+			}
+		}
+		observed += disagreement / float64(len(values)-1)
+		pairable = append(pairable, values...)
+	}
+
+	n := len(pairable)
+	if n < 2 {
+		return 0, false
+	}
+
+	var expected float64
+	for i := range pairable {
+		for j := range pairable {
+			if i != j {
+				expected += (pairable[i] - pairable[j]) * (pairable[i] - pairable[j])
+			}
+		}
+	}
+
+	observedDisagreement := observed / float64(n)
+	expectedDisagreement := expected / float64(n*(n-1))
+	if expectedDisagreement == 0 {
+		return 0, false
+	}
+	return 1 - observedDisagreement/expectedDisagreement, true
+}
+
+// KendallW computes Kendall's coefficient of concordance with the correction for ties. Each row holds the values
+// one rater gave to the same items in the same order, values are converted to ranks with ties sharing the average rank.
+// Returns false when W is undefined, i.e. fewer than 2 raters or items.
+func KendallW(ratings [][]float64) (float64, bool) {
+	m := len(ratings)
+	if m < 2 {
+		return 0, false
+	}
+	n := len(ratings[0])
+	if n < 2 {
+		return 0, false
+	}
+
+	rankSums := make([]float64, n)
+	var tieCorrection float64
+	for _, values := range ratings {
+		ranks, ties := rankWithTies(values)
+		for i, rank := range ranks {
+			rankSums[i] += rank
+		}
+		tieCorrection += ties
+	}
+
+	meanRankSum := float64(m*(n+1)) / 2
+	var deviations float64
+	for _, rankSum := range rankSums {
+		deviations += (rankSum - meanRankSum) * (rankSum - meanRankSum)
+	}
+
+	denominator := float64(m*m)*float64(n*n*n-n) - float64(m)*tieCorrection
+	if denominator == 0 {
+		return 0, false
+	}
+	return 12 * deviations / denominator, true
+}
+
+// rankWithTies returns the 1-based rank of every value and the sum of t^3 - t over all groups of t tied values
+func rankWithTies(values []float64) ([]float64, float64) {
+	indices := make([]int, len(values))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(a, b int) bool {
+		return values[indices[a]] < values[indices[b]]
+	})
+
+	ranks := make([]float64, len(values))
+	var ties float64
+	for start := 0; start < len(indices); {
+		end := start + 1
+		for end < len(indices) && values[indices[end]] == values[indices[start]] {
+			end++
+		}
+
+		// positions start..end-1 share the average of ranks start+1..end
+		averageRank := float64(start+1+end) / 2
+		for _, index := range indices[start:end] {
+			ranks[index] = averageRank
+		}
+		if t := float64(end - start); t > 1 {
+			ties += t*t*t - t
+		}
+		start = end
+	}
+	return ranks, ties
+}
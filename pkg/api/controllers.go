@@ -11,12 +11,22 @@ import (
 	"time"
 
 	"dojo-api/db"
+	"dojo-api/pkg/agreement"
 	"dojo-api/pkg/auth"
 	"dojo-api/pkg/blockchain/siws"
 	"dojo-api/pkg/cache"
+	"dojo-api/pkg/collusion"
+	"dojo-api/pkg/dwell"
+	"dojo-api/pkg/earnings"
+	"dojo-api/pkg/export"
+	"dojo-api/pkg/leaderboard"
 	"dojo-api/pkg/metric"
 	"dojo-api/pkg/miner"
 	"dojo-api/pkg/orm"
+	"dojo-api/pkg/qualification"
+	"dojo-api/pkg/reputation"
+	"dojo-api/pkg/settlement"
+	"dojo-api/pkg/staking"
 	"dojo-api/pkg/task"
 	"dojo-api/pkg/worker"
 	"dojo-api/utils"
@@ -105,6 +115,9 @@ func WorkerLoginController(c *gin.Context) {
 //	@Param			TaskData		formData	string						true	"Task data in JSON format"
 //	@Param			MaxResults		formData	int							true	"Maximum results"
 //	@Param			TotalRewards	formData	float64						true	"Total rewards"
+//	@Param			IsGold			formData	bool						false	"Mark the tasks as gold tasks used to grade workers"
+//	@Param			MinReputation	formData	number						false	"Minimum worker reputation between 0 and 1 required to see and submit the tasks"
+//	@Param			GoldAnswers		formData	string						false	"Expected answers of gold tasks in JSON format, e.g. [{model, type, value, tolerance}]"
 //	@Param			files			formData	[]file						true	"Files to upload (can upload multiple files)"
 //	@Success		200				{object}	ApiResponse{body=[]string}	"Tasks created successfully"
 //	@Failure		400				{object}	ApiResponse					"Bad request, invalid form data, or failed to process request"
@@ -186,6 +199,279 @@ func CreateTasksController(c *gin.Context) {
 	c.JSON(http.StatusOK, defaultSuccessResponse(taskIds))
 }
 
+// CloseTasksController godoc
+//
+//	@Summary		Cancel or close tasks
+//	@Description	Cancel tasks posted by mistake, or close tasks early once enough results are collected. Only in progress tasks owned by the miner are updated.
+//	@Tags			Tasks
+//	@Accept			json
+//	@Produce		json
+//	@Param			x-api-key	header		string										true	"API Key for Miner Authentication"
+//	@Param			body		body		task.CloseTasksRequest						true	"Request body containing the task IDs and the action, either cancel or close"
+//	@Success		200			{object}	ApiResponse{body=task.CloseTasksResponse}	"Tasks updated successfully"
+//	@Failure		400			{object}	ApiResponse									"Invalid request body or action"
+//	@Failure		401			{object}	ApiResponse									"Unauthorized access"
+//	@Failure		500			{object}	ApiResponse									"Internal server error"
+//	@Router			/tasks/close-tasks [put]
+func CloseTasksController(c *gin.Context) {
+	minerUserInterface, exists := c.Get("minerUser")
+	minerUser, _ := minerUserInterface.(*db.MinerUserModel)
+	if !exists || minerUser == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	var requestBody task.CloseTasksRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		log.Error().Err(err).Msg("Failed to bind JSON to requestBody")
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("Invalid request body"))
+		return
+	}
+
+	if len(requestBody.TaskIds) == 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("taskIds is required"))
+		return
+	}
+
+	if requestBody.Action != task.CloseTaskActionCancel && requestBody.Action != task.CloseTaskActionClose {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("Invalid action, must be either cancel or close"))
+		return
+	}
+
+	taskService := task.NewTaskService()
+	response, err := taskService.CloseTasks(c.Request.Context(), minerUser.ID, requestBody)
+	if err != nil {
+		log.Error().Err(err).Str("minerUserId", minerUser.ID).Msg("Failed to close tasks")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("Failed to close tasks"))
+		return
+	}
+
+	if requestBody.Action == task.CloseTaskActionClose {
+		handleCompletedTasks(response.UpdatedTaskIds)
+	}
+
+	log.Info().Str("minerUserId", minerUser.ID).Str("action", string(requestBody.Action)).
+		Int("numUpdated", len(response.UpdatedTaskIds)).Int("numSkipped", len(response.SkippedTaskIds)).
+		Msg("Tasks closed successfully")
+	c.JSON(http.StatusOK, defaultSuccessResponse(response))
+}
+
+// UpdateTaskController godoc
+//
+//	@Summary		Update a task
+//	@Description	Edit the mutable fields of a task owned by the miner. maxResults cannot be lowered below the number of collected results, and EXPIRED or COMPLETED tasks are only reopened when reopen is set and the task was not settled yet. Every change is written to the task history.
+//	@Tags			Tasks
+//	@Accept			json
+//	@Produce		json
+//	@Param			x-api-key	header		string										true	"API Key for Miner Authentication"
+//	@Param			task-id		path		string										true	"Task ID"
+//	@Param			body		body		task.UpdateTaskRequest						true	"Request body containing the fields to update"
+//	@Success		200			{object}	ApiResponse{body=task.UpdateTaskResponse}	"Task updated successfully"
+//	@Failure		400			{object}	ApiResponse									"Invalid request body or update"
+//	@Failure		401			{object}	ApiResponse									"Unauthorized access"
+//	@Failure		404			{object}	ApiResponse									"Task not found"
+//	@Failure		500			{object}	ApiResponse									"Internal server error"
+//	@Router			/tasks/{task-id} [patch]
+func UpdateTaskController(c *gin.Context) {
+	minerUserInterface, exists := c.Get("minerUser")
+	minerUser, _ := minerUserInterface.(*db.MinerUserModel)
+	if !exists || minerUser == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	taskId := c.Param("task-id")
+	if taskId == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("task id is required"))
+		return
+	}
+
+	var requestBody task.UpdateTaskRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		log.Error().Err(err).Msg("Failed to bind JSON to requestBody")
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("Invalid request body"))
+		return
+	}
+
+	taskService := task.NewTaskService()
+	response, err := taskService.UpdateTask(c.Request.Context(), minerUser.ID, taskId, requestBody)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, defaultErrorResponse("Task not found"))
+			return
+		}
+		if _, ok := err.(*task.ErrInvalidTaskUpdate); ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse(err.Error()))
+			return
+		}
+		log.Error().Err(err).Str("taskId", taskId).Msg("Failed to update task")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("Failed to update task"))
+		return
+	}
+
+	// a task whose maxResults was lowered to its number of results completes with the update
+	if statusChange, ok := response.Changes["status"]; ok && statusChange.To == db.TaskStatusCompleted {
+		handleCompletedTasks([]string{taskId})
+	}
+
+	log.Info().Str("taskId", taskId).Interface("changes", response.Changes).Msg("Task updated successfully")
+	c.JSON(http.StatusOK, defaultSuccessResponse(response))
+}
+
+// GetMinerTasksController godoc
+//
+//	@Summary		List the miner's tasks
+//	@Description	Get the tasks created by the calling miner with their progress, newest first, using cursor based pagination
+//	@Tags			Miner
+//	@Produce		json
+//	@Param			x-api-key	header		string										true	"API Key for Miner Authentication"
+//	@Param			status		query		string										false	"Comma-separated list of task statuses (e.g., IN_PROGRESS,COMPLETED)"
+//	@Param			task		query		string										false	"Comma-separated list of task types (e.g., CODE_GENERATION,DIALOGUE)"
+//	@Param			createdFrom	query		string										false	"Only tasks created at or after this RFC3339 timestamp"
+//	@Param			createdTo	query		string										false	"Only tasks created at or before this RFC3339 timestamp"
+//	@Param			minResults	query		int											false	"Only tasks with at least this many results"
+//	@Param			cursor		query		string										false	"nextCursor returned by the previous page"
+//	@Param			limit		query		int											false	"Number of tasks per page (default is 20, max is 100)"
+//	@Success		200			{object}	ApiResponse{body=task.MinerTaskListResponse}	"Successfully retrieved miner tasks"
+//	@Failure		400			{object}	ApiResponse									"Invalid request parameters"
+//	@Failure		401			{object}	ApiResponse									"Unauthorized access"
+//	@Failure		500			{object}	ApiResponse									"Internal server error"
+//	@Router			/miner/tasks [get]
+func GetMinerTasksController(c *gin.Context) {
+	minerUserInterface, exists := c.Get("minerUser")
+	minerUser, _ := minerUserInterface.(*db.MinerUserModel)
+	if !exists || minerUser == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	params := task.MinerTaskFilterParams{
+		Cursor: c.Query("cursor"),
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("Invalid limit parameter, must be between 1 and 100"))
+		return
+	}
+	params.Limit = limit
+
+	if statusParam := c.Query("status"); statusParam != "" {
+		statuses, err := task.ParseTaskStatuses(strings.Split(statusParam, ","))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse(err.Error()))
+			return
+		}
+		params.Statuses = statuses
+	}
+
+	if taskParam := c.Query("task"); taskParam != "" && taskParam != "All" {
+		taskTypes, err := task.ParseTaskTypes(strings.Split(taskParam, ","))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse(err.Error()))
+			return
+		}
+		params.Types = taskTypes
+	}
+
+	if createdFrom := c.Query("createdFrom"); createdFrom != "" {
+		params.CreatedFrom = utils.ParseDate(createdFrom)
+		if params.CreatedFrom == nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("Invalid createdFrom parameter"))
+			return
+		}
+	}
+
+	if createdTo := c.Query("createdTo"); createdTo != "" {
+		params.CreatedTo = utils.ParseDate(createdTo)
+		if params.CreatedTo == nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("Invalid createdTo parameter"))
+			return
+		}
+	}
+
+	if minResultsParam := c.Query("minResults"); minResultsParam != "" {
+		minResults, err := strconv.Atoi(minResultsParam)
+		if err != nil || minResults < 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("Invalid minResults parameter"))
+			return
+		}
+		params.MinResults = minResults
+	}
+
+	taskService := task.NewTaskService()
+	response, err := taskService.GetTasksByMinerUser(c.Request.Context(), minerUser.ID, params)
+	if err != nil {
+		log.Error().Err(err).Str("minerUserId", minerUser.ID).Msg("Failed to get miner tasks")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get miner tasks"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(response))
+}
+
+// ExportTaskResultsController godoc
+//
+//	@Summary		Export task results
+//	@Description	Stream all results of the miner's tasks as JSONL (full task data and result data) or flattened CSV. Pass the last exported taskResultId as checkpoint to resume an interrupted export.
+//	@Description	With mode=preference, the scores of each finished task are aggregated into {prompt, chosen, rejected, margin, num_votes} pairs instead, and the checkpoint is the last exported task_id.
+//	@Tags			Miner
+//	@Produce		plain
+//	@Param			x-api-key		header		string		true	"API Key for Miner Authentication"
+//	@Param			format			query		string		false	"Export format, jsonl or csv (default is jsonl)"
+//	@Param			mode			query		string		false	"Export mode, results or preference (default is results)"
+//	@Param			from			query		string		false	"Only results submitted (tasks created in preference mode) at or after this RFC3339 timestamp"
+//	@Param			to				query		string		false	"Only results submitted (tasks created in preference mode) at or before this RFC3339 timestamp"
+//	@Param			task			query		string		false	"Comma-separated list of task types (e.g., CODE_GENERATION,DIALOGUE)"
+//	@Param			status			query		string		false	"Comma-separated list of task statuses (e.g., COMPLETED,EXPIRED)"
+//	@Param			checkpoint		query		string		false	"Task result ID, or task ID in preference mode, to resume the export after"
+//	@Param			tieHandling		query		string		false	"Preference mode only, skip or split tied votes (default is skip)"
+//	@Param			minAgreement	query		number		false	"Preference mode only, minimum fraction of votes preferring the chosen response (default is 0)"
+//	@Param			minVotes		query		int			false	"Preference mode only, minimum number of counted votes per pair (default is 1)"
+//	@Param			calibration		query		string		false	"Results mode only, zscore or quantile to add per worker calibrated scores next to the raw ones"
+//	@Success		200				{string}	string		"Exported task results"
+//	@Failure		400				{object}	ApiResponse	"Invalid request parameters"
+//	@Failure		401				{object}	ApiResponse	"Unauthorized access"
+//	@Router			/miner/tasks/results/export [get]
+func ExportTaskResultsController(c *gin.Context) {
+	minerUserInterface, exists := c.Get("minerUser")
+	minerUser, _ := minerUserInterface.(*db.MinerUserModel)
+	if !exists || minerUser == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	params, err := parseExportParams(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse(err.Error()))
+		return
+	}
+
+	contentType := "application/x-ndjson"
+	if params.Format == export.ExportFormatCSV {
+		contentType = "text/csv"
+	}
+	filename := fmt.Sprintf("task_results_%s.%s", time.Now().UTC().Format("20060102T150405Z"), params.Format)
+	if params.Mode == export.ExportModePreference {
+		filename = fmt.Sprintf("preference_pairs_%s.%s", time.Now().UTC().Format("20060102T150405Z"), params.Format)
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Status(http.StatusOK)
+
+	// headers are already sent once streaming starts, so failures can only be logged
+	exportService := export.NewExportService()
+	if params.Mode == export.ExportModePreference {
+		err = exportService.ExportPreferencePairs(c.Request.Context(), minerUser.ID, *params, c.Writer)
+	} else {
+		err = exportService.ExportTaskResults(c.Request.Context(), minerUser.ID, *params, c.Writer)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("minerUserId", minerUser.ID).Msg("Failed to export task results")
+		return
+	}
+}
+
 // SubmitTaskResultController godoc
 //
 //	@Summary		Submit task result
@@ -199,6 +485,7 @@ func CreateTasksController(c *gin.Context) {
 //	@Success		200				{object}	ApiResponse{body=task.SubmitTaskResultResponse}	"Task result submitted successfully"
 //	@Failure		400				{object}	ApiResponse										"Invalid request body or task is expired"
 //	@Failure		401				{object}	ApiResponse										"Unauthorized"
+//	@Failure		403				{object}	ApiResponse										"Reputation is too low or qualification required for this task"
 //	@Failure		404				{object}	ApiResponse										"Task not found"
 //	@Failure		409				{object}	ApiResponse										"Task result already completed by worker"
 //	@Failure		409				{object}	ApiResponse										"Task has reached max results"
@@ -255,6 +542,14 @@ func SubmitTaskResultController(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	// Check if the task was cancelled by the miner
+	if taskData.Status == db.TaskStatusCancelled {
+		log.Info().Str("taskId", taskId).Msg("Task is cancelled")
+		c.JSON(http.StatusBadRequest, defaultErrorResponse("Task is cancelled"))
+		c.Abort()
+		return
+	}
+
 	// Check if the task is expired
 	if taskData.ExpireAt.Before(time.Now()) || taskData.Status == db.TaskStatusExpired {
 		log.Info().Str("taskId", taskId).Msg("Task is expired")
@@ -271,6 +566,38 @@ func SubmitTaskResultController(c *gin.Context) {
 		return
 	}
 
+	// Check if the worker's reputation meets the minimum set by the miner
+	if minReputation, ok := taskData.MinReputation(); ok {
+		workerReputation, err := reputation.NewReputationService().GetWorkerReputation(ctx, worker)
+		if err != nil {
+			log.Error().Err(err).Str("workerId", worker.ID).Msg("Error getting worker reputation")
+			c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker reputation"))
+			c.Abort()
+			return
+		}
+		if workerReputation.Score < minReputation {
+			log.Info().Str("taskId", taskId).Str("workerId", worker.ID).Float64("reputation", workerReputation.Score).Msg("Worker reputation is below the task minimum")
+			c.JSON(http.StatusForbidden, defaultErrorResponse("Reputation is too low for this task"))
+			c.Abort()
+			return
+		}
+	}
+
+	// Check if the worker passed the miner's qualification tests for this task type
+	isQualified, err := qualification.NewQualificationService().IsQualifiedForTask(ctx, worker.ID, taskData)
+	if err != nil {
+		log.Error().Err(err).Str("workerId", worker.ID).Msg("Error checking worker qualifications")
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker qualifications"))
+		c.Abort()
+		return
+	}
+	if !isQualified {
+		log.Info().Str("taskId", taskId).Str("workerId", worker.ID).Msg("Worker has not passed the qualification test for this task")
+		c.JSON(http.StatusForbidden, defaultErrorResponse("Qualification required for this task"))
+		c.Abort()
+		return
+	}
+
 	// Check if the task result is already completed by the worker
 	isCompletedTResult, err := taskService.ValidateCompletedTResultByWorker(ctx, taskId, worker.ID)
 	if err != nil {
@@ -287,23 +614,72 @@ func SubmitTaskResultController(c *gin.Context) {
 		return
 	}
 
+	// Check if the worker spent at least the miner's minimum time on the task
+	dwellCheck, err := dwell.NewDwellService().CheckSubmission(ctx, taskData, worker.ID)
+	if err != nil {
+		log.Error().Err(err).Str("taskId", taskId).Msg("Error checking time on task")
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to check time on task"))
+		c.Abort()
+		return
+	}
+	submissionMeta := task.SubmissionMeta{
+		ClientIP:   getCallerIP(c),
+		TimeOnTask: dwellCheck.TimeOnTask,
+		// a fetch is only recorded when the task was served to the worker's token in their shuffled order
+		ShuffledOrderServed: dwellCheck.TimeOnTask != nil,
+	}
+	if dwellCheck.TooFast {
+		log.Info().Str("taskId", taskId).Str("workerId", worker.ID).Int("minSeconds", dwellCheck.MinSeconds).Msg("Task result was submitted too quickly")
+		if dwellCheck.Action == db.DwellTimeActionReject {
+			c.JSON(http.StatusBadRequest, defaultErrorResponse("Submission was made too quickly"))
+			c.Abort()
+			return
+		}
+		submissionMeta.FlagReasons = []string{string(collusion.ReasonTooFast)}
+	}
+
+	// Lock the worker's stake for the submission, it is released or slashed when the task is settled
+	stakingService := staking.NewStakingService()
+	stakeLock, err := stakingService.LockStake(ctx, worker.ID, taskData)
+	if err != nil {
+		if errors.Is(err, staking.ErrInsufficientStake) {
+			log.Info().Str("taskId", taskId).Str("workerId", worker.ID).Msg("Worker does not have enough stake to submit")
+			c.JSON(http.StatusBadRequest, defaultErrorResponse("Insufficient stake to submit this task"))
+			c.Abort()
+			return
+		}
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to lock stake"))
+		c.Abort()
+		return
+	}
+	if stakeLock != nil {
+		submissionMeta.Stake = &task.SubmissionStake{
+			TaskResultID:    stakeLock.TaskResultId,
+			StakeAmount:     stakeLock.StakeAmount,
+			PotentialReward: stakeLock.PotentialReward,
+			PotentialLoss:   stakeLock.PotentialLoss,
+		}
+	}
+
 	log.Info().Str("Dojo Worker ID", worker.ID).Str("Task ID", taskId).Msg("Dojo Worker and Task ID pulled")
 
 	// Update the task with the result data
-	updatedTask, err := taskService.UpdateTaskResults(ctx, taskData, worker.ID, requestBody.ResultData)
+	updatedTask, err := taskService.UpdateTaskResults(ctx, taskData, worker.ID, requestBody.ResultData, submissionMeta)
 	if err != nil {
+		if stakeLock != nil {
+			_ = stakingService.ReleaseLock(ctx, stakeLock)
+		}
 		log.Error().Err(err).Str("Dojo Worker ID", worker.ID).Str("Task ID", taskId).Msg("Error updating task with result data")
 		c.JSON(http.StatusInternalServerError, defaultErrorResponse(err.Error()))
 		c.Abort()
 		return
 	}
 
-	// Remove from cache
-	cache := cache.GetCacheInstance()
-	cache.DeleteWithSuffix(cache.Keys.TaskResultByWorker, worker.ID)
-
 	// Update the metric data with goroutine
 	handleMetricData(taskData, updatedTask)
+	handleTaskAgreement(updatedTask)
+	handleWorkerReputation(worker, updatedTask)
+	handleCollusionDetection(worker, updatedTask)
 
 	c.JSON(http.StatusOK, defaultSuccessResponse(task.SubmitTaskResultResponse{
 		NumResults: updatedTask.NumResults,
@@ -380,134 +756,1308 @@ func WorkerPartnerCreateController(c *gin.Context) {
 
 	_, err = orm.NewWorkerPartnerORM().CreateWorkerPartner(workerData.ID, requestBody.MinerSubscriptionKey, requestBody.Name)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to create worker-miner partnership"))
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to create worker-miner partnership"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse("Successfully created worker-miner partnership"))
+}
+
+// GetWorkerPartnerListController godoc
+//
+//	@Summary		Get worker-miner partnership list
+//	@Description	Retrieve a list of partnerships between a worker and miners
+//	@Tags			Worker Partner
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string												true	"Bearer token"
+//	@Success		200				{object}	ApiResponse{body=worker.ListWorkerPartnersResponse}	"Successfully retrieved worker-miner partnership list"
+//	@Failure		400				{object}	ApiResponse											"Invalid request or missing required fields"
+//	@Failure		401				{object}	ApiResponse											"Unauthorized"
+//	@Failure		404				{object}	ApiResponse											"Worker not found"
+//	@Failure		500				{object}	ApiResponse											"Internal server error"
+//	@Router			/worker/partner/list [get]
+func GetWorkerPartnerListController(c *gin.Context) {
+	jwtClaims, ok := c.Get("userInfo")
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	userInfo, ok := jwtClaims.(*jwt.RegisteredClaims)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	walletAddress := userInfo.Subject
+	foundWorker, err := orm.NewDojoWorkerORM().GetDojoWorkerByWalletAddress(walletAddress)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get worker")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker"))
+		return
+	}
+
+	if foundWorker == nil {
+		log.Error().Msg("Worker not found")
+		c.AbortWithStatusJSON(http.StatusNotFound, defaultErrorResponse("Worker not found"))
+		return
+	}
+	workerPartners, err := orm.NewWorkerPartnerORM().GetWorkerPartnerByWorkerId(foundWorker.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get worker partners")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker partners"))
+		return
+	}
+
+	listWorkerPartnersResponse := &worker.ListWorkerPartnersResponse{
+		Partners: make([]worker.WorkerPartner, 0),
+	}
+	for _, workerPartner := range workerPartners {
+		if workerPartner.IsDeleteByWorker {
+			continue
+		}
+		name, _ := workerPartner.Name()
+		listWorkerPartnersResponse.Partners = append(listWorkerPartnersResponse.Partners, worker.WorkerPartner{
+			Id:              workerPartner.ID,
+			CreatedAt:       workerPartner.CreatedAt,
+			SubscriptionKey: workerPartner.MinerSubscriptionKey,
+			Name:            name,
+		})
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(listWorkerPartnersResponse))
+}
+
+// GetTaskById godoc
+//
+//	@Summary		Retrieve task by ID
+//	@Description	Get details of a task by its ID
+//	@Tags			Tasks
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string								false	"Bearer token, shuffles the responses for the worker and records when the worker first fetched the task"
+//	@Param			x-api-key		header		string								false	"API key of the miner who owns the task, shows the real model names"
+//	@Param			task-id			path		string								true	"Task ID"
+//	@Success		200				{object}	ApiResponse{body=task.TaskResponse}	"Successfully retrieved task response"
+//	@Failure		404				{object}	ApiResponse{error=string}			"Task not found"
+//	@Failure		500				{object}	ApiResponse{error=string}			"Internal server error"
+//	@Router			/tasks/{task-id} [get]
+func GetTaskByIdController(c *gin.Context) {
+	taskID := c.Param("task-id")
+	taskService := task.NewTaskService()
+
+	// workers fetching with their token see their own response order and start the clock on their time on task
+	workerId := ""
+	if jwtClaims, ok := c.Get("userInfo"); ok {
+		if userInfo, ok := jwtClaims.(*jwt.RegisteredClaims); ok {
+			if worker, err := orm.NewDojoWorkerORM().GetDojoWorkerByWalletAddress(userInfo.Subject); err == nil {
+				workerId = worker.ID
+			} else {
+				log.Warn().Err(err).Str("walletAddress", userInfo.Subject).Msg("Failed to get worker for task fetch")
+			}
+		}
+	}
+
+	// the miner who owns the task sees the real model names
+	minerUserId := ""
+	if minerUser, ok := c.Get("minerUser"); ok {
+		if minerUser, ok := minerUser.(*db.MinerUserModel); ok && minerUser != nil {
+			minerUserId = minerUser.ID
+		}
+	}
+
+	task, err := taskService.GetTaskResponseById(c.Request.Context(), taskID, workerId, minerUserId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Internal server error"))
+		c.Abort()
+		return
+	}
+
+	if task == nil {
+		c.JSON(http.StatusNotFound, defaultErrorResponse("Task not found"))
+		return
+	}
+
+	if workerId != "" {
+		_ = dwell.NewDwellService().RecordFetch(c.Request.Context(), taskID, workerId)
+	}
+
+	// Successful response
+	c.JSON(http.StatusOK, defaultSuccessResponse(task))
+}
+
+// GetTasksByPageController godoc
+//
+//	@Summary		Retrieve tasks by pagination
+//	@Description	Get a paginated list of tasks based on the specified parameters
+//	@Tags			Tasks
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string									true	"Bearer token"
+//	@Param			task			query		string									true	"Comma-separated list of task types (e.g., CODE_GENERATION,TEXT_TO_IMAGE,DIALOGUE). Use 'All' to include all types."
+//	@Param			page			query		int										false	"Page number (default is 1)"
+//	@Param			limit			query		int										false	"Number of tasks per page (default is 10)"
+//	@Param			sort			query		string									false	"Sort field (default is createdAt)"
+//	@Param			order			query		string									false	"Order field (default is desc order) e.g., asc or desc"
+//	@Success		200				{object}	ApiResponse{body=task.TaskPagination}	"Successfully retrieved task pagination response"
+//	@Failure		400				{object}	ApiResponse								"Invalid request parameters"
+//	@Failure		401				{object}	ApiResponse								"Unauthorized"
+//	@Failure		404				{object}	ApiResponse								"No tasks found"
+//	@Failure		500				{object}	ApiResponse								"Internal server error"
+//	@Router			/tasks [get]
+func GetTasksByPageController(c *gin.Context) {
+	jwtClaims, ok := c.Get("userInfo")
+	if !ok {
+		log.Error().Str("userInfo", fmt.Sprintf("%+v", jwtClaims)).Msg("No user info found in context")
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	userInfo, ok := jwtClaims.(*jwt.RegisteredClaims)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	worker, err := orm.NewDojoWorkerORM().GetDojoWorkerByWalletAddress(userInfo.Subject)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker"))
+		return
+	}
+
+	// Get the task query parameter as a single string
+	taskParam := c.Query("task")
+	if taskParam == "" {
+		c.JSON(http.StatusBadRequest, defaultErrorResponse("task parameter is required"))
+		return
+	}
+	// Split the string into a slice of strings
+	taskTypes := strings.Split(taskParam, ",")
+	if len(taskTypes) == 0 {
+		c.JSON(http.StatusBadRequest, defaultErrorResponse("task parameter is required"))
+		return
+	}
+
+	if len(taskTypes) == 1 && taskTypes[0] == "All" {
+		taskTypes = []string{"CODE_GENERATION", "TEXT_TO_IMAGE", "DIALOGUE", "TEXT_TO_THREE_D"}
+	}
+
+	// Parsing "page" and "limit" as integers with default values
+	pageStr := c.DefaultQuery("page", "1")
+	limitStr := c.DefaultQuery("limit", "10")
+	sort := c.DefaultQuery("sort", "createdAt")
+	orderStr := c.DefaultQuery("order", "desc")
+	page, err := strconv.Atoi(pageStr)
+	if err != nil {
+		log.Error().Err(err).Msg("Error converting page to integer:")
+		c.JSON(http.StatusBadRequest, defaultErrorResponse("Invalid page parameter"))
+		return
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		log.Error().Err(err).Msg("Error converting page to integer:")
+		c.JSON(http.StatusBadRequest, defaultErrorResponse("Invalid limit parameter"))
+		return
+	}
+
+	if orderStr != "asc" && orderStr != "desc" {
+		log.Error().Msgf("Invalid order parameter: %s", orderStr)
+		c.JSON(http.StatusBadRequest, defaultErrorResponse("Invalid order parameter"))
+		return
+	}
+
+	order := db.SortOrderDesc
+	if orderStr == "asc" {
+		order = db.SortOrderAsc
+	}
+
+	workerReputation, err := reputation.NewReputationService().GetWorkerReputation(c.Request.Context(), worker)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker reputation"))
+		return
+	}
+
+	unqualifiedScopes, err := qualification.NewQualificationService().GetUnqualifiedScopes(c.Request.Context(), worker.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker qualifications"))
+		return
+	}
+
+	paginationParams := task.PaginationParams{
+		Page:              page,
+		Limit:             limit,
+		Sort:              sort,
+		Types:             taskTypes,
+		Order:             order,
+		WorkerReputation:  workerReputation.Score,
+		UnqualifiedScopes: unqualifiedScopes,
+	}
+
+	// fetching tasks by pagination
+	taskService := task.NewTaskService()
+	taskPagination, taskErrors := taskService.GetTasksByPagination(c.Request.Context(), worker.ID, paginationParams)
+	if len(taskErrors) > 0 {
+		isBadRequest := false
+		errorDetails := make([]string, 0)
+		for _, err := range taskErrors {
+			errorDetails = append(errorDetails, err.Error())
+			if _, ok := err.(*task.ErrInvalidTaskType); ok {
+				isBadRequest = true
+			}
+		}
+		log.Error().Interface("errors", errorDetails).Msg("Error getting tasks by pagination")
+		if isBadRequest {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse(errorDetails))
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse(errorDetails))
+		return
+	}
+
+	if taskPagination == nil {
+		log.Error().Err(err).Msg("Error getting tasks by pagination")
+		c.JSON(http.StatusNotFound, defaultErrorResponse("no tasks found"))
+		return
+	}
+
+	// the listed tasks are shown to the worker, which starts the clock on their time on task
+	listedTaskIds := make([]string, 0, len(taskPagination.Tasks))
+	for _, listedTask := range taskPagination.Tasks {
+		listedTaskIds = append(listedTaskIds, listedTask.ID)
+	}
+	_ = dwell.NewDwellService().RecordListFetch(c.Request.Context(), listedTaskIds, worker.ID)
+
+	// Successful response
+	c.JSON(http.StatusOK, defaultSuccessResponse(taskPagination))
+}
+
+func GetTaskResultsController(c *gin.Context) {
+	taskId := c.Param("task-id")
+	if taskId == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("task id is required"))
+		return
+	}
+
+	var calibrationMethod task.CalibrationMethod
+	if calibrationStr := c.Query("calibration"); calibrationStr != "" {
+		parsedMethod, err := task.ParseCalibrationMethod(calibrationStr)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse(err.Error()))
+			return
+		}
+		calibrationMethod = parsedMethod
+	}
+
+	taskResultORM := orm.NewTaskResultORM()
+	taskResults, err := taskResultORM.GetTaskResultsByTaskId(c.Request.Context(), taskId)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("failed to fetch task results"))
+		return
+	}
+
+	var calibrator *task.Calibrator
+	if calibrationMethod != "" {
+		calibrator, err = task.NewCalibrator(c.Request.Context(), calibrationMethod, taskResults)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("failed to calibrate task results"))
+			return
+		}
+	}
+
+	var formattedTaskResults []task.TaskResult
+
+	for _, taskResult := range taskResults {
+		var resultDataItem []task.Result
+		err = json.Unmarshal([]byte(string(taskResult.ResultData)), &resultDataItem)
+		if err != nil {
+			log.Error().Err(err).Str("taskResult.ResultData", string(taskResult.ResultData)).Msg("failed to convert task results")
+			c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("failed to convert result data to tempResult"))
+			return
+		}
+
+		tempResult := task.NewTaskResult(taskResult, resultDataItem)
+		if calibrator != nil {
+			tempResult.CalibratedScores = calibrator.Calibrate(taskResult.WorkerID, resultDataItem)
+		}
+		formattedTaskResults = append(formattedTaskResults, tempResult)
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(task.TaskResultResponse{TaskResults: formattedTaskResults}))
+}
+
+// GetTaskResultAggregateController godoc
+//
+//	@Summary		Get aggregated task results
+//	@Description	Get the count, mean, median, standard deviation, min/max and histogram of the submitted values per model response and criterion
+//	@Tags			Tasks
+//	@Produce		json
+//	@Param			task-id	path		string												true	"Task ID"
+//	@Success		200		{object}	ApiResponse{body=task.TaskResultAggregateResponse}	"Aggregated task results"
+//	@Failure		400		{object}	ApiResponse											"Task ID is required"
+//	@Failure		404		{object}	ApiResponse											"Task not found"
+//	@Failure		500		{object}	ApiResponse											"Failed to aggregate task results"
+//	@Router			/tasks/task-result/{task-id}/aggregate [get]
+func GetTaskResultAggregateController(c *gin.Context) {
+	taskId := c.Param("task-id")
+	if taskId == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("task id is required"))
+		return
+	}
+
+	taskService := task.NewTaskService()
+	aggregate, err := taskService.GetTaskResultAggregate(c.Request.Context(), taskId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, defaultErrorResponse("task not found"))
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("failed to aggregate task results"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(aggregate))
+}
+
+// GetTaskAgreementController godoc
+//
+//	@Summary		Get inter-annotator agreement of a task
+//	@Description	Get Krippendorff's alpha over the submitted scores and Kendall's W over the rankings implied by each worker's scores, computed over the task's COMPLETED results
+//	@Tags			Tasks
+//	@Produce		json
+//	@Param			task-id	path		string											true	"Task ID"
+//	@Success		200		{object}	ApiResponse{body=agreement.TaskAgreementResponse}	"Task agreement"
+//	@Failure		400		{object}	ApiResponse										"Task ID is required"
+//	@Failure		404		{object}	ApiResponse										"Task not found"
+//	@Failure		500		{object}	ApiResponse										"Failed to get task agreement"
+//	@Router			/tasks/task-result/{task-id}/agreement [get]
+func GetTaskAgreementController(c *gin.Context) {
+	taskId := c.Param("task-id")
+	if taskId == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("task id is required"))
+		return
+	}
+
+	agreementService := agreement.NewAgreementService()
+	taskAgreement, err := agreementService.GetTaskAgreement(c.Request.Context(), taskId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, defaultErrorResponse("task not found"))
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("failed to get task agreement"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(taskAgreement))
+}
+
+// GetTaskSettlementController godoc
+//
+//	@Summary		Get the settlement of a task
+//	@Description	Get how the total reward of a finished task was split among its results, with the finalised reward and loss of every result. Finished tasks that were not settled yet are settled first.
+//	@Tags			Miner
+//	@Produce		json
+//	@Param			x-api-key	header		string													true	"API Key for Miner Authentication"
+//	@Param			task-id		path		string													true	"Task ID"
+//	@Success		200			{object}	ApiResponse{body=settlement.SettlementReportResponse}	"Task settlement"
+//	@Failure		400			{object}	ApiResponse												"Task has not finished yet or has flags awaiting review"
+//	@Failure		401			{object}	ApiResponse												"Unauthorized access"
+//	@Failure		404			{object}	ApiResponse												"Task not found"
+//	@Failure		500			{object}	ApiResponse												"Failed to get task settlement"
+//	@Router			/miner/tasks/{task-id}/settlement [get]
+func GetTaskSettlementController(c *gin.Context) {
+	minerUserInterface, exists := c.Get("minerUser")
+	minerUser, _ := minerUserInterface.(*db.MinerUserModel)
+	if !exists || minerUser == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	// other miners' tasks are reported as missing
+	taskModel, err := orm.NewTaskORM().GetByIdAndMinerUser(c.Request.Context(), c.Param("task-id"), minerUser.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, defaultErrorResponse("task not found"))
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("failed to get task"))
+		return
+	}
+
+	report, err := settlement.NewSettlementService().GetSettlementReport(c.Request.Context(), taskModel)
+	if err != nil {
+		if errors.Is(err, settlement.ErrTaskNotFinished) || errors.Is(err, settlement.ErrSettlementOnHold) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse(err.Error()))
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("failed to get task settlement"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(report))
+}
+
+// GetMinerAgreementController godoc
+//
+//	@Summary		Get inter-annotator agreement of the miner's tasks over time
+//	@Description	Average Krippendorff's alpha and Kendall's W of the miner's tasks per time window, tasks are bucketed by creation time
+//	@Tags			Miner
+//	@Produce		json
+//	@Param			x-api-key	header		string												true	"API Key for Miner Authentication"
+//	@Param			from		query		string												false	"Start of the range as an RFC3339 timestamp (default is 30 days before to)"
+//	@Param			to			query		string												false	"End of the range as an RFC3339 timestamp (default is now)"
+//	@Param			interval	query		string												false	"Window size, day, week or month (default is day)"
+//	@Success		200			{object}	ApiResponse{body=agreement.MinerAgreementResponse}	"Agreement per time window"
+//	@Failure		400			{object}	ApiResponse											"Invalid request parameters"
+//	@Failure		401			{object}	ApiResponse											"Unauthorized access"
+//	@Failure		500			{object}	ApiResponse											"Failed to get agreement"
+//	@Router			/miner/agreement [get]
+func GetMinerAgreementController(c *gin.Context) {
+	minerUserInterface, exists := c.Get("minerUser")
+	minerUser, _ := minerUserInterface.(*db.MinerUserModel)
+	if !exists || minerUser == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	params := agreement.RollupParams{
+		Interval: agreement.RollupInterval(c.DefaultQuery("interval", string(agreement.RollupIntervalDay))),
+		To:       time.Now().UTC(),
+	}
+	if params.Interval != agreement.RollupIntervalDay && params.Interval != agreement.RollupIntervalWeek && params.Interval != agreement.RollupIntervalMonth {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid interval parameter, must be day, week or month"))
+		return
+	}
+
+	if to := c.Query("to"); to != "" {
+		parsedTo := utils.ParseDate(to)
+		if parsedTo == nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid to parameter"))
+			return
+		}
+		params.To = *parsedTo
+	}
+
+	params.From = params.To.AddDate(0, 0, -30)
+	if from := c.Query("from"); from != "" {
+		parsedFrom := utils.ParseDate(from)
+		if parsedFrom == nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid from parameter"))
+			return
+		}
+		params.From = *parsedFrom
+	}
+
+	if !params.From.Before(params.To) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("from must be before to"))
+		return
+	}
+
+	agreementService := agreement.NewAgreementService()
+	rollup, err := agreementService.GetMinerAgreementRollup(c.Request.Context(), minerUser.ID, params)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("failed to get agreement"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(rollup))
+}
+
+// GetMinerDashboardController godoc
+//
+//	@Summary		Get miner dashboard
+//	@Description	Get the authenticated miner's task, completion, result and partnered worker figures over a range, in total and per time bucket. Authenticates with an API key or a session cookie.
+//	@Tags			Miner
+//	@Produce		json
+//	@Param			x-api-key	header		string											false	"API Key for Miner Authentication, the session cookie is used when not set"
+//	@Param			interval	query		string											false	"Time bucket size, one of day, week or month (default is day)"
+//	@Param			from		query		string											false	"Start of the range in RFC3339 (default is 30 days before to)"
+//	@Param			to			query		string											false	"End of the range in RFC3339, exclusive (default is now)"
+//	@Success		200			{object}	ApiResponse{body=miner.MinerDashboardResponse}	"Miner dashboard"
+//	@Failure		400			{object}	ApiResponse										"Invalid query parameters"
+//	@Failure		401			{object}	ApiResponse										"Unauthorized"
+//	@Failure		500			{object}	ApiResponse										"Failed to get dashboard"
+//	@Router			/miner/dashboard [get]
+func GetMinerDashboardController(c *gin.Context) {
+	minerUserInterface, exists := c.Get("minerUser")
+	minerUser, _ := minerUserInterface.(*db.MinerUserModel)
+	if !exists || minerUser == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	params := miner.DashboardParams{
+		Interval: miner.DashboardInterval(c.DefaultQuery("interval", string(miner.DashboardIntervalDay))),
+	}
+	if params.Interval != miner.DashboardIntervalDay && params.Interval != miner.DashboardIntervalWeek && params.Interval != miner.DashboardIntervalMonth {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid interval parameter, must be day, week or month"))
+		return
+	}
+
+	var err error
+	params.From, params.To, err = parseDateRange(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse(err.Error()))
+		return
+	}
+
+	dashboard, err := miner.NewDashboardService().GetDashboard(c.Request.Context(), minerUser.ID, params)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("failed to get dashboard"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(dashboard))
+}
+
+// GetWorkerGoldAccuracyController godoc
+//
+//	@Summary		Get gold accuracy of workers
+//	@Description	Get how many gold task submissions were graded and passed for every worker that submitted to one of the miner's gold tasks
+//	@Tags			Miner
+//	@Produce		json
+//	@Param			x-api-key	header		string													true	"API Key for Miner Authentication"
+//	@Success		200			{object}	ApiResponse{body=task.WorkerGoldAccuracyListResponse}	"Worker gold accuracy"
+//	@Failure		401			{object}	ApiResponse												"Unauthorized access"
+//	@Failure		500			{object}	ApiResponse												"Failed to get worker gold accuracy"
+//	@Router			/miner/gold/accuracy [get]
+func GetWorkerGoldAccuracyController(c *gin.Context) {
+	minerUserInterface, exists := c.Get("minerUser")
+	minerUser, _ := minerUserInterface.(*db.MinerUserModel)
+	if !exists || minerUser == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	taskService := task.NewTaskService()
+	accuracy, err := taskService.GetWorkerGoldAccuracyByMinerUser(c.Request.Context(), minerUser.ID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("failed to get worker gold accuracy"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(accuracy))
+}
+
+// GetWorkerReputationController godoc
+//
+//	@Summary		Get worker reputation
+//	@Description	Get the reputation score of the authenticated worker together with the components it is combined from
+//	@Tags			Worker
+//	@Produce		json
+//	@Param			Authorization	header		string												true	"Bearer token"
+//	@Success		200				{object}	ApiResponse{body=reputation.WorkerReputationResponse}	"Worker reputation"
+//	@Failure		401				{object}	ApiResponse											"Unauthorized"
+//	@Failure		500				{object}	ApiResponse											"Failed to get worker reputation"
+//	@Router			/worker/reputation [get]
+func GetWorkerReputationController(c *gin.Context) {
+	jwtClaims, ok := c.Get("userInfo")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	userInfo, ok := jwtClaims.(*jwt.RegisteredClaims)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	worker, err := orm.NewDojoWorkerORM().GetDojoWorkerByWalletAddress(userInfo.Subject)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker"))
+		return
+	}
+
+	workerReputation, err := reputation.NewReputationService().GetWorkerReputation(c.Request.Context(), worker)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker reputation"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(workerReputation))
+}
+
+// DepositStakeController godoc
+//
+//	@Summary		Credit a worker's stake
+//	@Description	Add to a worker's off-chain stake once the operator has verified the deposit, part of the stake is locked by every submission while staking is enabled
+//	@Tags			Operator
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-OPERATOR-KEY	header		string											true	"Operator key"
+//	@Param			wallet-address	path		string											true	"Wallet address of the worker"
+//	@Param			body			body		staking.DepositStakeRequest						true	"Request body containing the amount and the verified deposit it comes from"
+//	@Success		200				{object}	ApiResponse{body=staking.StakeBalanceResponse}	"Stake balance after the deposit"
+//	@Failure		400				{object}	ApiResponse										"Invalid request body"
+//	@Failure		401				{object}	ApiResponse										"Unauthorized"
+//	@Failure		404				{object}	ApiResponse										"Worker not found"
+//	@Failure		500				{object}	ApiResponse										"Failed to deposit stake"
+//	@Router			/operator/workers/{wallet-address}/stake/deposit [post]
+func DepositStakeController(c *gin.Context) {
+	var requestBody staking.DepositStakeRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		log.Error().Err(err).Msg("Failed to bind JSON to requestBody")
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("Invalid request body"))
+		return
+	}
+
+	worker, err := orm.NewDojoWorkerORM().GetDojoWorkerByWalletAddress(c.Param("wallet-address"))
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, defaultErrorResponse("worker not found"))
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker"))
+		return
+	}
+
+	balance, err := staking.NewStakingService().Deposit(c.Request.Context(), worker, requestBody)
+	if err != nil {
+		if errors.Is(err, staking.ErrInvalidStakeAmount) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse(err.Error()))
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("Failed to deposit stake"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(balance))
+}
+
+// SetLeaderboardOptInController godoc
+//
+//	@Summary		Show or hide wallet address on the leaderboard
+//	@Description	Opt in to show the authenticated worker's wallet address on the public leaderboard, or opt out to be shown under a pseudonym. Takes effect on the next leaderboard refresh.
+//	@Tags			Worker
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string													true	"Bearer token"
+//	@Param			body			body		leaderboard.LeaderboardOptInRequest						true	"Request body containing the opt in choice"
+//	@Success		200				{object}	ApiResponse{body=leaderboard.LeaderboardOptInResponse}	"Opt in choice saved"
+//	@Failure		400				{object}	ApiResponse												"Invalid request body"
+//	@Failure		401				{object}	ApiResponse												"Unauthorized"
+//	@Failure		500				{object}	ApiResponse												"Failed to update leaderboard opt in"
+//	@Router			/worker/leaderboard/opt-in [put]
+func SetLeaderboardOptInController(c *gin.Context) {
+	jwtClaims, ok := c.Get("userInfo")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	userInfo, ok := jwtClaims.(*jwt.RegisteredClaims)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	workerORM := orm.NewDojoWorkerORM()
+	worker, err := workerORM.GetDojoWorkerByWalletAddress(userInfo.Subject)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker"))
+		return
+	}
+
+	var requestBody leaderboard.LeaderboardOptInRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		log.Error().Err(err).Msg("Failed to bind JSON to requestBody")
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("Invalid request body"))
+		return
+	}
+
+	updated, err := workerORM.SetLeaderboardOptIn(c.Request.Context(), worker, requestBody.OptIn)
+	if err != nil {
+		log.Error().Err(err).Str("workerId", worker.ID).Msg("Failed to update leaderboard opt in")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("Failed to update leaderboard opt in"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(leaderboard.LeaderboardOptInResponse{OptIn: updated.LeaderboardOptIn}))
+}
+
+// GetWorkerStakeController godoc
+//
+//	@Summary		Get worker stake
+//	@Description	Get the authenticated worker's available and locked stake, rebuilt from the stake ledger
+//	@Tags			Worker
+//	@Produce		json
+//	@Param			Authorization	header		string										true	"Bearer token"
+//	@Success		200				{object}	ApiResponse{body=staking.StakeBalanceResponse}	"Stake balance"
+//	@Failure		401				{object}	ApiResponse									"Unauthorized"
+//	@Failure		500				{object}	ApiResponse									"Failed to get stake"
+//	@Router			/worker/stake [get]
+func GetWorkerStakeController(c *gin.Context) {
+	jwtClaims, ok := c.Get("userInfo")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	userInfo, ok := jwtClaims.(*jwt.RegisteredClaims)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	worker, err := orm.NewDojoWorkerORM().GetDojoWorkerByWalletAddress(userInfo.Subject)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker"))
+		return
+	}
+
+	balance, err := staking.NewStakingService().GetBalance(c.Request.Context(), worker.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get stake"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(balance))
+}
+
+// GetWorkerStakeLedgerController godoc
+//
+//	@Summary		Get worker stake ledger
+//	@Description	Get a page of the authenticated worker's stake ledger, newest entries first
+//	@Tags			Worker
+//	@Produce		json
+//	@Param			Authorization	header		string										true	"Bearer token"
+//	@Param			page			query		int											false	"Page number (default is 1)"
+//	@Param			limit			query		int											false	"Number of entries per page, at most 500 (default is 100)"
+//	@Success		200				{object}	ApiResponse{body=staking.StakeLedgerResponse}	"Stake ledger"
+//	@Failure		400				{object}	ApiResponse									"Invalid query parameters"
+//	@Failure		401				{object}	ApiResponse									"Unauthorized"
+//	@Failure		500				{object}	ApiResponse									"Failed to get stake ledger"
+//	@Router			/worker/stake/ledger [get]
+func GetWorkerStakeLedgerController(c *gin.Context) {
+	jwtClaims, ok := c.Get("userInfo")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	userInfo, ok := jwtClaims.(*jwt.RegisteredClaims)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	worker, err := orm.NewDojoWorkerORM().GetDojoWorkerByWalletAddress(userInfo.Subject)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker"))
+		return
+	}
+
+	page, limit := 1, 100
+	if value := c.Query("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid page parameter"))
+			return
+		}
+		page = parsed
+	}
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 500 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid limit parameter, must be between 1 and 500"))
+			return
+		}
+		limit = parsed
+	}
+
+	ledger, err := staking.NewStakingService().GetLedger(c.Request.Context(), worker.ID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get stake ledger"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(ledger))
+}
+
+// GetWorkerEarningsController godoc
+//
+//	@Summary		Get worker earnings
+//	@Description	Get a page of the authenticated worker's task results with their stake, potential and finalised reward and loss, newest first. With format=csv every result in the range is downloaded as CSV instead.
+//	@Tags			Worker
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			Authorization	header		string											true	"Bearer token"
+//	@Param			from			query		string											false	"Start of the range in RFC3339 (default is 30 days before to)"
+//	@Param			to				query		string											false	"End of the range in RFC3339, exclusive (default is now)"
+//	@Param			page			query		int												false	"Page number (default is 1)"
+//	@Param			limit			query		int												false	"Number of results per page, at most 500 (default is 100)"
+//	@Param			format			query		string											false	"Set to csv to download every result in the range"
+//	@Success		200				{object}	ApiResponse{body=earnings.EarningsListResponse}	"Worker earnings"
+//	@Failure		400				{object}	ApiResponse										"Invalid query parameters"
+//	@Failure		401				{object}	ApiResponse										"Unauthorized"
+//	@Failure		500				{object}	ApiResponse										"Failed to get earnings"
+//	@Router			/worker/earnings [get]
+func GetWorkerEarningsController(c *gin.Context) {
+	jwtClaims, ok := c.Get("userInfo")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	userInfo, ok := jwtClaims.(*jwt.RegisteredClaims)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	worker, err := orm.NewDojoWorkerORM().GetDojoWorkerByWalletAddress(userInfo.Subject)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker"))
+		return
+	}
+
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse(err.Error()))
+		return
+	}
+	params := earnings.EarningsParams{From: from, To: to}
+
+	format := c.Query("format")
+	if format != "" && format != "json" && format != "csv" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid format parameter, must be json or csv"))
+		return
+	}
+
+	earningsService := earnings.NewEarningsService()
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"earnings_%s.csv\"", time.Now().UTC().Format("20060102T150405Z")))
+		c.Status(http.StatusOK)
+
+		// headers are already sent once streaming starts, so failures can only be logged
+		if err := earningsService.WriteEarningsCSV(c.Request.Context(), worker.ID, params, c.Writer); err != nil {
+			log.Error().Err(err).Str("workerId", worker.ID).Msg("Failed to write earnings CSV")
+		}
+		return
+	}
+
+	page, limit := 1, 100
+	if value := c.Query("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid page parameter"))
+			return
+		}
+		page = parsed
+	}
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 500 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid limit parameter, must be between 1 and 500"))
+			return
+		}
+		limit = parsed
+	}
+
+	earningsList, err := earningsService.ListEarnings(c.Request.Context(), worker.ID, params, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get earnings"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(earningsList))
+}
+
+// GetWorkerEarningsSummaryController godoc
+//
+//	@Summary		Get worker earnings summary
+//	@Description	Get the authenticated worker's finalised and pending reward and loss, in total, per miner and per time window
+//	@Tags			Worker
+//	@Produce		json
+//	@Param			Authorization	header		string												true	"Bearer token"
+//	@Param			interval		query		string												false	"Time window size, one of day, week or month (default is day)"
+//	@Param			from			query		string												false	"Start of the range in RFC3339 (default is 30 days before to)"
+//	@Param			to				query		string												false	"End of the range in RFC3339, exclusive (default is now)"
+//	@Success		200				{object}	ApiResponse{body=earnings.EarningsSummaryResponse}	"Worker earnings summary"
+//	@Failure		400				{object}	ApiResponse											"Invalid query parameters"
+//	@Failure		401				{object}	ApiResponse											"Unauthorized"
+//	@Failure		500				{object}	ApiResponse											"Failed to get earnings summary"
+//	@Router			/worker/earnings/summary [get]
+func GetWorkerEarningsSummaryController(c *gin.Context) {
+	jwtClaims, ok := c.Get("userInfo")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	userInfo, ok := jwtClaims.(*jwt.RegisteredClaims)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	worker, err := orm.NewDojoWorkerORM().GetDojoWorkerByWalletAddress(userInfo.Subject)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker"))
+		return
+	}
+
+	params := earnings.SummaryParams{
+		Interval: earnings.EarningsInterval(c.DefaultQuery("interval", string(earnings.EarningsIntervalDay))),
+	}
+	if params.Interval != earnings.EarningsIntervalDay && params.Interval != earnings.EarningsIntervalWeek && params.Interval != earnings.EarningsIntervalMonth {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid interval parameter, must be day, week or month"))
+		return
+	}
+
+	params.From, params.To, err = parseDateRange(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse(err.Error()))
+		return
+	}
+
+	summary, err := earnings.NewEarningsService().GetSummary(c.Request.Context(), worker.ID, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get earnings summary"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(summary))
+}
+
+// GetWorkerHistoryController godoc
+//
+//	@Summary		Get worker submission history
+//	@Description	Get a page of the authenticated worker's submissions, newest first, with the submitted scores, status and any finalised reward
+//	@Tags			Worker
+//	@Produce		json
+//	@Param			Authorization	header		string											true	"Bearer token"
+//	@Param			task			query		string											false	"Comma separated task types, or All (default is All)"
+//	@Param			from			query		string											false	"Only submissions created at or after this time, in RFC3339"
+//	@Param			to				query		string											false	"Only submissions created at or before this time, in RFC3339"
+//	@Param			page			query		int												false	"Page number (default is 1)"
+//	@Param			limit			query		int												false	"Number of submissions per page, at most 100 (default is 10)"
+//	@Success		200				{object}	ApiResponse{body=task.WorkerHistoryResponse}	"Worker submission history"
+//	@Failure		400				{object}	ApiResponse										"Invalid query parameters"
+//	@Failure		401				{object}	ApiResponse										"Unauthorized"
+//	@Failure		500				{object}	ApiResponse										"Failed to get history"
+//	@Router			/worker/history [get]
+func GetWorkerHistoryController(c *gin.Context) {
+	jwtClaims, ok := c.Get("userInfo")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	userInfo, ok := jwtClaims.(*jwt.RegisteredClaims)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	worker, err := orm.NewDojoWorkerORM().GetDojoWorkerByWalletAddress(userInfo.Subject)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker"))
+		return
+	}
+
+	params := task.WorkerHistoryParams{Page: 1, Limit: 10}
+	if value := c.Query("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid page parameter"))
+			return
+		}
+		params.Page = parsed
+	}
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 100 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid limit parameter, must be between 1 and 100"))
+			return
+		}
+		params.Limit = parsed
+	}
+
+	if taskParam := c.Query("task"); taskParam != "" && taskParam != "All" {
+		taskTypes, err := task.ParseTaskTypes(strings.Split(taskParam, ","))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse(err.Error()))
+			return
+		}
+		params.Types = taskTypes
+	}
+
+	if from := c.Query("from"); from != "" {
+		params.From = utils.ParseDate(from)
+		if params.From == nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid from parameter"))
+			return
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		params.To = utils.ParseDate(to)
+		if params.To == nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid to parameter"))
+			return
+		}
+	}
+
+	history, err := task.NewTaskService().GetWorkerHistory(c.Request.Context(), worker.ID, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get history"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(history))
+}
+
+// CreateQualificationTestController godoc
+//
+//	@Summary		Create a qualification test
+//	@Description	Create a set of gold questions that workers must pass before they can see or submit the miner's tasks of the given task type
+//	@Tags			Miner
+//	@Accept			json
+//	@Produce		json
+//	@Param			x-api-key	header		string															true	"API Key for Miner Authentication"
+//	@Param			body		body		qualification.CreateQualificationTestRequest					true	"Request body containing the test definition"
+//	@Success		200			{object}	ApiResponse{body=qualification.QualificationTestResponse}	"Qualification test created"
+//	@Failure		400			{object}	ApiResponse														"Invalid request body"
+//	@Failure		401			{object}	ApiResponse														"Unauthorized access"
+//	@Failure		500			{object}	ApiResponse														"Failed to create qualification test"
+//	@Router			/miner/qualification-tests [post]
+func CreateQualificationTestController(c *gin.Context) {
+	minerUserInterface, exists := c.Get("minerUser")
+	minerUser, _ := minerUserInterface.(*db.MinerUserModel)
+	if !exists || minerUser == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	var requestBody qualification.CreateQualificationTestRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		log.Error().Err(err).Msg("Failed to bind JSON to requestBody")
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("Invalid request body"))
+		return
+	}
+
+	test, err := qualification.NewQualificationService().CreateTest(c.Request.Context(), minerUser.ID, requestBody)
+	if err != nil {
+		var invalidErr *qualification.ErrInvalidQualificationTest
+		if errors.As(err, &invalidErr) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse(invalidErr.Error()))
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("failed to create qualification test"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(test))
+}
+
+// GetMinerQualificationTestsController godoc
+//
+//	@Summary		List qualification tests
+//	@Description	List the qualification tests created by the miner
+//	@Tags			Miner
+//	@Produce		json
+//	@Param			x-api-key	header		string																true	"API Key for Miner Authentication"
+//	@Success		200			{object}	ApiResponse{body=qualification.MinerQualificationTestListResponse}	"Qualification tests"
+//	@Failure		401			{object}	ApiResponse															"Unauthorized access"
+//	@Failure		500			{object}	ApiResponse															"Failed to get qualification tests"
+//	@Router			/miner/qualification-tests [get]
+func GetMinerQualificationTestsController(c *gin.Context) {
+	minerUserInterface, exists := c.Get("minerUser")
+	minerUser, _ := minerUserInterface.(*db.MinerUserModel)
+	if !exists || minerUser == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	tests, err := qualification.NewQualificationService().GetTestsByMinerUser(c.Request.Context(), minerUser.ID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("failed to get qualification tests"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(tests))
+}
+
+// SetMinerDwellTimeController godoc
+//
+//	@Summary		Set minimum dwell time
+//	@Description	Set the minimum number of seconds workers must spend on the miner's tasks of a task type between fetching and submitting, too fast submissions are rejected or flagged
+//	@Tags			Miner
+//	@Accept			json
+//	@Produce		json
+//	@Param			x-api-key	header		string										true	"API Key for Miner Authentication"
+//	@Param			body		body		dwell.SetDwellTimeRequest					true	"Request body containing the task type, minimum seconds and action"
+//	@Success		200			{object}	ApiResponse{body=dwell.DwellTimeResponse}	"Dwell time set"
+//	@Failure		400			{object}	ApiResponse									"Invalid request body"
+//	@Failure		401			{object}	ApiResponse									"Unauthorized access"
+//	@Failure		500			{object}	ApiResponse									"Failed to set dwell time"
+//	@Router			/miner/dwell-time [put]
+func SetMinerDwellTimeController(c *gin.Context) {
+	minerUserInterface, exists := c.Get("minerUser")
+	minerUser, _ := minerUserInterface.(*db.MinerUserModel)
+	if !exists || minerUser == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	var requestBody dwell.SetDwellTimeRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		log.Error().Err(err).Msg("Failed to bind JSON to requestBody")
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("Invalid request body"))
+		return
+	}
+
+	dwellTime, err := dwell.NewDwellService().SetMinerDwellTime(c.Request.Context(), minerUser.ID, requestBody)
+	if err != nil {
+		var invalidErr *dwell.ErrInvalidDwellTime
+		if errors.As(err, &invalidErr) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse(invalidErr.Error()))
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("failed to set dwell time"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(dwellTime))
+}
+
+// GetMinerDwellTimesController godoc
+//
+//	@Summary		List minimum dwell times
+//	@Description	List the minimum dwell time the miner set for each task type
+//	@Tags			Miner
+//	@Produce		json
+//	@Param			x-api-key	header		string												true	"API Key for Miner Authentication"
+//	@Success		200			{object}	ApiResponse{body=dwell.MinerDwellTimeListResponse}	"Dwell times"
+//	@Failure		401			{object}	ApiResponse											"Unauthorized access"
+//	@Failure		500			{object}	ApiResponse											"Failed to get dwell times"
+//	@Router			/miner/dwell-time [get]
+func GetMinerDwellTimesController(c *gin.Context) {
+	minerUserInterface, exists := c.Get("minerUser")
+	minerUser, _ := minerUserInterface.(*db.MinerUserModel)
+	if !exists || minerUser == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	dwellTimes, err := dwell.NewDwellService().GetMinerDwellTimes(c.Request.Context(), minerUser.ID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("failed to get dwell times"))
 		return
 	}
 
-	c.JSON(http.StatusOK, defaultSuccessResponse("Successfully created worker-miner partnership"))
+	c.JSON(http.StatusOK, defaultSuccessResponse(dwellTimes))
 }
 
-// GetWorkerPartnerListController godoc
+// GetWorkerQualificationsController godoc
 //
-//	@Summary		Get worker-miner partnership list
-//	@Description	Retrieve a list of partnerships between a worker and miners
-//	@Tags			Worker Partner
-//	@Accept			json
+//	@Summary		List worker qualifications
+//	@Description	List the qualification tests that apply to the authenticated worker together with the worker's latest attempt
+//	@Tags			Worker
 //	@Produce		json
-//	@Param			Authorization	header		string												true	"Bearer token"
-//	@Success		200				{object}	ApiResponse{body=worker.ListWorkerPartnersResponse}	"Successfully retrieved worker-miner partnership list"
-//	@Failure		400				{object}	ApiResponse											"Invalid request or missing required fields"
-//	@Failure		401				{object}	ApiResponse											"Unauthorized"
-//	@Failure		404				{object}	ApiResponse											"Worker not found"
-//	@Failure		500				{object}	ApiResponse											"Internal server error"
-//	@Router			/worker/partner/list [get]
-func GetWorkerPartnerListController(c *gin.Context) {
+//	@Param			Authorization	header		string															true	"Bearer token"
+//	@Success		200				{object}	ApiResponse{body=qualification.WorkerQualificationListResponse}	"Worker qualifications"
+//	@Failure		401				{object}	ApiResponse														"Unauthorized"
+//	@Failure		500				{object}	ApiResponse														"Failed to get qualifications"
+//	@Router			/worker/qualifications [get]
+func GetWorkerQualificationsController(c *gin.Context) {
 	jwtClaims, ok := c.Get("userInfo")
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
 		return
 	}
 
 	userInfo, ok := jwtClaims.(*jwt.RegisteredClaims)
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
 		return
 	}
 
-	walletAddress := userInfo.Subject
-	foundWorker, err := orm.NewDojoWorkerORM().GetDojoWorkerByWalletAddress(walletAddress)
+	worker, err := orm.NewDojoWorkerORM().GetDojoWorkerByWalletAddress(userInfo.Subject)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to get worker")
-		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker"))
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker"))
 		return
 	}
 
-	if foundWorker == nil {
-		log.Error().Msg("Worker not found")
-		c.AbortWithStatusJSON(http.StatusNotFound, defaultErrorResponse("Worker not found"))
-		return
-	}
-	workerPartners, err := orm.NewWorkerPartnerORM().GetWorkerPartnerByWorkerId(foundWorker.ID)
+	qualifications, err := qualification.NewQualificationService().GetWorkerQualifications(c.Request.Context(), worker.ID)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to get worker partners")
-		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker partners"))
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get qualifications"))
 		return
 	}
 
-	listWorkerPartnersResponse := &worker.ListWorkerPartnersResponse{
-		Partners: make([]worker.WorkerPartner, 0),
-	}
-	for _, workerPartner := range workerPartners {
-		if workerPartner.IsDeleteByWorker {
-			continue
-		}
-		name, _ := workerPartner.Name()
-		listWorkerPartnersResponse.Partners = append(listWorkerPartnersResponse.Partners, worker.WorkerPartner{
-			Id:              workerPartner.ID,
-			CreatedAt:       workerPartner.CreatedAt,
-			SubscriptionKey: workerPartner.MinerSubscriptionKey,
-			Name:            name,
-		})
-	}
-
-	c.JSON(http.StatusOK, defaultSuccessResponse(listWorkerPartnersResponse))
+	c.JSON(http.StatusOK, defaultSuccessResponse(qualifications))
 }
 
-// GetTaskById godoc
+// GetQualificationTestController godoc
 //
-//	@Summary		Retrieve task by ID
-//	@Description	Get details of a task by its ID
-//	@Tags			Tasks
-//	@Accept			json
+//	@Summary		Get qualification test questions
+//	@Description	Get the questions of a qualification test that applies to the authenticated worker
+//	@Tags			Worker
 //	@Produce		json
-//	@Param			task-id	path		string								true	"Task ID"
-//	@Success		200		{object}	ApiResponse{body=task.TaskResponse}	"Successfully retrieved task response"
-//	@Failure		404		{object}	ApiResponse{error=string}			"Task not found"
-//	@Failure		500		{object}	ApiResponse{error=string}			"Internal server error"
-//	@Router			/tasks/{task-id} [get]
-func GetTaskByIdController(c *gin.Context) {
-	taskID := c.Param("task-id")
-	taskService := task.NewTaskService()
+//	@Param			Authorization	header		string																true	"Bearer token"
+//	@Param			test-id			path		string																true	"Qualification test ID"
+//	@Success		200				{object}	ApiResponse{body=qualification.QualificationTestQuestionsResponse}	"Qualification test questions"
+//	@Failure		401				{object}	ApiResponse															"Unauthorized"
+//	@Failure		404				{object}	ApiResponse															"Qualification test not found"
+//	@Failure		500				{object}	ApiResponse															"Failed to get qualification test"
+//	@Router			/worker/qualifications/{test-id} [get]
+func GetQualificationTestController(c *gin.Context) {
+	jwtClaims, ok := c.Get("userInfo")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
 
-	task, err := taskService.GetTaskResponseById(c.Request.Context(), taskID)
+	userInfo, ok := jwtClaims.(*jwt.RegisteredClaims)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return
+	}
+
+	worker, err := orm.NewDojoWorkerORM().GetDojoWorkerByWalletAddress(userInfo.Subject)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Internal server error"))
-		c.Abort()
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get worker"))
 		return
 	}
 
-	if task == nil {
-		c.JSON(http.StatusNotFound, defaultErrorResponse("Task not found"))
+	test, err := qualification.NewQualificationService().GetTestForWorker(c.Request.Context(), worker.ID, c.Param("test-id"))
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, defaultErrorResponse("Qualification test not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get qualification test"))
 		return
 	}
 
-	// Successful response
-	c.JSON(http.StatusOK, defaultSuccessResponse(task))
+	c.JSON(http.StatusOK, defaultSuccessResponse(test))
 }
 
-// GetTasksByPageController godoc
+// SubmitQualificationTestController godoc
 //
-//	@Summary		Retrieve tasks by pagination
-//	@Description	Get a paginated list of tasks based on the specified parameters
-//	@Tags			Tasks
+//	@Summary		Submit qualification test
+//	@Description	Submit answers to a qualification test, a failed test can be retaken once the cooldown set by the miner is over
+//	@Tags			Worker
 //	@Accept			json
 //	@Produce		json
-//	@Param			Authorization	header		string									true	"Bearer token"
-//	@Param			task			query		string									true	"Comma-separated list of task types (e.g., CODE_GENERATION,TEXT_TO_IMAGE,DIALOGUE). Use 'All' to include all types."
-//	@Param			page			query		int										false	"Page number (default is 1)"
-//	@Param			limit			query		int										false	"Number of tasks per page (default is 10)"
-//	@Param			sort			query		string									false	"Sort field (default is createdAt)"
-//	@Param			order			query		string									false	"Order field (default is desc order) e.g., asc or desc"
-//	@Success		200				{object}	ApiResponse{body=task.TaskPagination}	"Successfully retrieved task pagination response"
-//	@Failure		400				{object}	ApiResponse								"Invalid request parameters"
-//	@Failure		401				{object}	ApiResponse								"Unauthorized"
-//	@Failure		404				{object}	ApiResponse								"No tasks found"
-//	@Failure		500				{object}	ApiResponse								"Internal server error"
-//	@Router			/tasks [get]
-func GetTasksByPageController(c *gin.Context) {
+//	@Param			Authorization	header		string																true	"Bearer token"
+//	@Param			test-id			path		string																true	"Qualification test ID"
+//	@Param			body			body		qualification.SubmitQualificationTestRequest						true	"Request body containing the answers"
+//	@Success		200				{object}	ApiResponse{body=qualification.SubmitQualificationTestResponse}	"Qualification test graded"
+//	@Failure		400				{object}	ApiResponse															"Invalid request body"
+//	@Failure		401				{object}	ApiResponse															"Unauthorized"
+//	@Failure		404				{object}	ApiResponse															"Qualification test not found"
+//	@Failure		409				{object}	ApiResponse															"Qualification test already passed"
+//	@Failure		429				{object}	ApiResponse															"Qualification test is in cooldown"
+//	@Failure		500				{object}	ApiResponse															"Failed to submit qualification test"
+//	@Router			/worker/qualifications/{test-id}/submit [post]
+func SubmitQualificationTestController(c *gin.Context) {
 	jwtClaims, ok := c.Get("userInfo")
 	if !ok {
-		log.Error().Str("userInfo", fmt.Sprintf("%+v", jwtClaims)).Msg("No user info found in context")
 		c.JSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
 		return
 	}
@@ -524,125 +2074,149 @@ func GetTasksByPageController(c *gin.Context) {
 		return
 	}
 
-	// Get the task query parameter as a single string
-	taskParam := c.Query("task")
-	if taskParam == "" {
-		c.JSON(http.StatusBadRequest, defaultErrorResponse("task parameter is required"))
-		return
-	}
-	// Split the string into a slice of strings
-	taskTypes := strings.Split(taskParam, ",")
-	if len(taskTypes) == 0 {
-		c.JSON(http.StatusBadRequest, defaultErrorResponse("task parameter is required"))
+	var requestBody qualification.SubmitQualificationTestRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		log.Error().Err(err).Msg("Failed to bind JSON to requestBody")
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("Invalid request body"))
 		return
 	}
 
-	if len(taskTypes) == 1 && taskTypes[0] == "All" {
-		taskTypes = []string{"CODE_GENERATION", "TEXT_TO_IMAGE", "DIALOGUE", "TEXT_TO_THREE_D"}
-	}
-
-	// Parsing "page" and "limit" as integers with default values
-	pageStr := c.DefaultQuery("page", "1")
-	limitStr := c.DefaultQuery("limit", "10")
-	sort := c.DefaultQuery("sort", "createdAt")
-	orderStr := c.DefaultQuery("order", "desc")
-	page, err := strconv.Atoi(pageStr)
+	result, err := qualification.NewQualificationService().SubmitTest(c.Request.Context(), worker.ID, c.Param("test-id"), requestBody.Answers)
 	if err != nil {
-		log.Error().Err(err).Msg("Error converting page to integer:")
-		c.JSON(http.StatusBadRequest, defaultErrorResponse("Invalid page parameter"))
+		var invalidErr *qualification.ErrInvalidQualificationTest
+		var cooldownErr *qualification.ErrQualificationCooldown
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			c.AbortWithStatusJSON(http.StatusNotFound, defaultErrorResponse("Qualification test not found"))
+		case errors.Is(err, qualification.ErrAlreadyQualified):
+			c.AbortWithStatusJSON(http.StatusConflict, defaultErrorResponse(err.Error()))
+		case errors.As(err, &cooldownErr):
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, defaultErrorResponse(cooldownErr.Error()))
+		case errors.As(err, &invalidErr):
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse(invalidErr.Error()))
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("Failed to submit qualification test"))
+		}
 		return
 	}
 
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil {
-		log.Error().Err(err).Msg("Error converting page to integer:")
-		c.JSON(http.StatusBadRequest, defaultErrorResponse("Invalid limit parameter"))
-		return
-	}
+	c.JSON(http.StatusOK, defaultSuccessResponse(result))
+}
 
-	if orderStr != "asc" && orderStr != "desc" {
-		log.Error().Msgf("Invalid order parameter: %s", orderStr)
-		c.JSON(http.StatusBadRequest, defaultErrorResponse("Invalid order parameter"))
-		return
+// GetCollusionReportController godoc
+//
+//	@Summary		Get collusion report
+//	@Description	Get task results flagged as suspected collusion, grouped into clusters of duplicate submissions
+//	@Tags			Operator
+//	@Produce		json
+//	@Param			X-OPERATOR-KEY	header		string												true	"Operator key"
+//	@Param			status			query		string												false	"PENDING, CONFIRMED or DISMISSED (default is PENDING), ALL for every status"
+//	@Param			from			query		string												false	"Only flags raised at or after this RFC3339 timestamp"
+//	@Param			to				query		string												false	"Only flags raised at or before this RFC3339 timestamp"
+//	@Param			page			query		int													false	"Page number (default is 1)"
+//	@Param			limit			query		int													false	"Number of flags per page (default is 100, max is 500)"
+//	@Success		200				{object}	ApiResponse{body=collusion.CollusionReportResponse}	"Collusion report"
+//	@Failure		400				{object}	ApiResponse											"Invalid request parameters"
+//	@Failure		401				{object}	ApiResponse											"Unauthorized"
+//	@Failure		500				{object}	ApiResponse											"Failed to get collusion report"
+//	@Router			/operator/collusion/report [get]
+func GetCollusionReportController(c *gin.Context) {
+	params := collusion.ReportParams{Page: 1, Limit: 100}
+
+	statusStr := c.DefaultQuery("status", string(db.ResultFlagStatusPending))
+	if statusStr != "ALL" {
+		status := db.ResultFlagStatus(statusStr)
+		if status != db.ResultFlagStatusPending && status != db.ResultFlagStatusConfirmed && status != db.ResultFlagStatusDismissed {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid status parameter"))
+			return
+		}
+		params.Status = &status
 	}
 
-	order := db.SortOrderDesc
-	if orderStr == "asc" {
-		order = db.SortOrderAsc
+	if from := c.Query("from"); from != "" {
+		params.From = utils.ParseDate(from)
+		if params.From == nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid from parameter"))
+			return
+		}
 	}
 
-	paginationParams := task.PaginationParams{
-		Page:  page,
-		Limit: limit,
-		Sort:  sort,
-		Types: taskTypes,
-		Order: order,
+	if to := c.Query("to"); to != "" {
+		params.To = utils.ParseDate(to)
+		if params.To == nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid to parameter"))
+			return
+		}
 	}
 
-	// fetching tasks by pagination
-	taskService := task.NewTaskService()
-	taskPagination, taskErrors := taskService.GetTasksByPagination(c.Request.Context(), worker.ID, paginationParams)
-	if len(taskErrors) > 0 {
-		isBadRequest := false
-		errorDetails := make([]string, 0)
-		for _, err := range taskErrors {
-			errorDetails = append(errorDetails, err.Error())
-			if _, ok := err.(*task.ErrInvalidTaskType); ok {
-				isBadRequest = true
-			}
+	if page := c.Query("page"); page != "" {
+		value, err := strconv.Atoi(page)
+		if err != nil || value < 1 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid page parameter"))
+			return
 		}
-		log.Error().Interface("errors", errorDetails).Msg("Error getting tasks by pagination")
-		if isBadRequest {
-			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse(errorDetails))
+		params.Page = value
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > 500 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid limit parameter, must be between 1 and 500"))
 			return
 		}
-		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse(errorDetails))
-		return
+		params.Limit = value
 	}
 
-	if taskPagination == nil {
-		log.Error().Err(err).Msg("Error getting tasks by pagination")
-		c.JSON(http.StatusNotFound, defaultErrorResponse("no tasks found"))
+	report, err := collusion.NewCollusionService().GetReport(c.Request.Context(), params)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("failed to get collusion report"))
 		return
 	}
 
-	// Successful response
-	c.JSON(http.StatusOK, defaultSuccessResponse(taskPagination))
+	c.JSON(http.StatusOK, defaultSuccessResponse(report))
 }
 
-func GetTaskResultsController(c *gin.Context) {
-	taskId := c.Param("task-id")
-	if taskId == "" {
-		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("task id is required"))
+// UpdateResultFlagController godoc
+//
+//	@Summary		Review a collusion flag
+//	@Description	Confirm or dismiss a task result flagged as suspected collusion
+//	@Tags			Operator
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-OPERATOR-KEY	header		string								true	"Operator key"
+//	@Param			flag-id			path		string								true	"Flag ID"
+//	@Param			body			body		collusion.UpdateResultFlagRequest	true	"Request body containing the review outcome"
+//	@Success		200				{object}	ApiResponse							"Flag updated"
+//	@Failure		400				{object}	ApiResponse							"Invalid request body"
+//	@Failure		401				{object}	ApiResponse							"Unauthorized"
+//	@Failure		404				{object}	ApiResponse							"Flag not found"
+//	@Failure		500				{object}	ApiResponse							"Failed to update flag"
+//	@Router			/operator/collusion/flags/{flag-id} [put]
+func UpdateResultFlagController(c *gin.Context) {
+	var requestBody collusion.UpdateResultFlagRequest
+	if err := c.BindJSON(&requestBody); err != nil {
+		log.Error().Err(err).Msg("Failed to bind JSON to requestBody")
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("Invalid request body"))
 		return
 	}
 
-	taskResultORM := orm.NewTaskResultORM()
-	taskResults, err := taskResultORM.GetTaskResultsByTaskId(c.Request.Context(), taskId)
-	if err != nil {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("failed to fetch task results"))
+	if requestBody.Status != db.ResultFlagStatusPending && requestBody.Status != db.ResultFlagStatusConfirmed && requestBody.Status != db.ResultFlagStatusDismissed {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid status"))
 		return
 	}
 
-	var formattedTaskResults []task.TaskResult
-
-	for _, taskResult := range taskResults {
-		var resultDataItem []task.Result
-		err = json.Unmarshal([]byte(string(taskResult.ResultData)), &resultDataItem)
-		if err != nil {
-			log.Error().Err(err).Str("taskResult.ResultData", string(taskResult.ResultData)).Msg("failed to convert task results")
-			c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("failed to convert result data to tempResult"))
+	flag, err := collusion.NewCollusionService().UpdateFlagStatus(c.Request.Context(), c.Param("flag-id"), requestBody.Status)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, defaultErrorResponse("flag not found"))
 			return
 		}
-
-		tempResult := task.TaskResult{
-			ResultData:      resultDataItem,
-			TaskResultModel: taskResult,
-		}
-		formattedTaskResults = append(formattedTaskResults, tempResult)
+		log.Error().Err(err).Str("flagId", c.Param("flag-id")).Msg("Error updating result flag")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("failed to update flag"))
+		return
 	}
 
-	c.JSON(http.StatusOK, defaultSuccessResponse(task.TaskResultResponse{TaskResults: formattedTaskResults}))
+	c.JSON(http.StatusOK, defaultSuccessResponse(flag))
 }
 
 // UpdateWorkerPartnerController godoc
@@ -901,6 +2475,121 @@ func GetAvgTaskCompletionTimeController(c *gin.Context) {
 	c.JSON(http.StatusOK, defaultSuccessResponse(metric.AvgTaskCompletionTimeResponse{AvgTaskCompletionTime: avgCompletionTime.AverageTaskCompletionTime}))
 }
 
+// GetTaskCompletionTimeStatsController godoc
+//
+//	@Summary		Get task completion time statistics
+//	@Description	Retrieves the count, mean, p50, p90 and p99 of the time tasks took to complete in seconds, over all task types and per task type
+//	@Tags			Metrics
+//	@Produce		json
+//	@Success		200	{object}	ApiResponse{body=metric.TaskCompletionTimeResponse}	"Task completion time statistics retrieved successfully"
+//	@Failure		500	{object}	ApiResponse											"Failed to get task completion time statistics"
+//	@Router			/metrics/task-completion-time [get]
+func GetTaskCompletionTimeStatsController(c *gin.Context) {
+	stats, err := metric.NewMetricService().GetTaskCompletionTimeStats(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get task completion time statistics"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(stats))
+}
+
+// GetMetricSeriesController godoc
+//
+//	@Summary		Get metric history
+//	@Description	Get the worker count, completed task count, task result count and average task completion time at the end of each time bucket in the range, for growth charts
+//	@Tags			Metrics
+//	@Produce		json
+//	@Param			interval	query		string										false	"Time bucket size, one of hour, day, week or month (default is day)"
+//	@Param			from		query		string										false	"Start of the range in RFC3339 (default is 30 days before to)"
+//	@Param			to			query		string										false	"End of the range in RFC3339, exclusive (default is now)"
+//	@Success		200			{object}	ApiResponse{body=metric.MetricSeriesResponse}	"Metric history"
+//	@Failure		400			{object}	ApiResponse									"Invalid query parameters"
+//	@Failure		500			{object}	ApiResponse									"Failed to get metric history"
+//	@Router			/metrics/series [get]
+func GetMetricSeriesController(c *gin.Context) {
+	params := metric.SeriesParams{
+		Interval: metric.SeriesInterval(c.DefaultQuery("interval", string(metric.SeriesIntervalDay))),
+	}
+	if params.Interval != metric.SeriesIntervalHour && params.Interval != metric.SeriesIntervalDay && params.Interval != metric.SeriesIntervalWeek && params.Interval != metric.SeriesIntervalMonth {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid interval parameter, must be hour, day, week or month"))
+		return
+	}
+
+	var err error
+	params.From, params.To, err = parseDateRange(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse(err.Error()))
+		return
+	}
+
+	series, err := metric.NewMetricService().GetMetricSeries(c.Request.Context(), params)
+	if err != nil {
+		if errors.Is(err, metric.ErrTooManyBuckets) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get metric history"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(series))
+}
+
+// GetLeaderboardController godoc
+//
+//	@Summary		Get the worker leaderboard
+//	@Description	Ranks workers by completed results, reputation score or finalised earnings over a window. The leaderboard is precomputed periodically, workers are named by wallet address only if they opted in.
+//	@Tags			Metrics
+//	@Produce		json
+//	@Param			metric	query		string											false	"One of completed, quality or earnings (default is completed)"
+//	@Param			window	query		string											false	"One of daily, weekly or all_time (default is weekly)"
+//	@Param			page	query		int												false	"Page number (default is 1)"
+//	@Param			limit	query		int												false	"Number of workers per page, at most 100 (default is 50)"
+//	@Success		200		{object}	ApiResponse{body=leaderboard.LeaderboardResponse}	"Leaderboard"
+//	@Failure		400		{object}	ApiResponse										"Invalid query parameters"
+//	@Failure		500		{object}	ApiResponse										"Failed to get leaderboard"
+//	@Router			/metrics/leaderboard [get]
+func GetLeaderboardController(c *gin.Context) {
+	metricParam := leaderboard.LeaderboardMetric(c.DefaultQuery("metric", string(leaderboard.LeaderboardMetricCompleted)))
+	if metricParam != leaderboard.LeaderboardMetricCompleted && metricParam != leaderboard.LeaderboardMetricQuality && metricParam != leaderboard.LeaderboardMetricEarnings {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid metric parameter, must be completed, quality or earnings"))
+		return
+	}
+
+	window := leaderboard.LeaderboardWindow(c.DefaultQuery("window", string(leaderboard.LeaderboardWindowWeekly)))
+	if window != leaderboard.LeaderboardWindowDaily && window != leaderboard.LeaderboardWindowWeekly && window != leaderboard.LeaderboardWindowAllTime {
+		c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid window parameter, must be daily, weekly or all_time"))
+		return
+	}
+
+	page, limit := 1, 50
+	if value := c.Query("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid page parameter"))
+			return
+		}
+		page = parsed
+	}
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 100 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, defaultErrorResponse("invalid limit parameter, must be between 1 and 100"))
+			return
+		}
+		limit = parsed
+	}
+
+	board, err := leaderboard.NewLeaderboardService().GetLeaderboard(c.Request.Context(), metricParam, window, (page-1)*limit, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, defaultErrorResponse("Failed to get leaderboard"))
+		return
+	}
+
+	c.JSON(http.StatusOK, defaultSuccessResponse(board))
+}
+
 // GenerateCookieAuth godoc
 //
 //	@Summary		Generates a session given valid proof of ownership
@@ -1377,5 +3066,7 @@ func GetNextInProgressTaskController(c *gin.Context) {
 		return
 	}
 
+	_ = dwell.NewDwellService().RecordFetch(c.Request.Context(), taskData.ID, worker.ID)
+
 	c.JSON(http.StatusOK, defaultSuccessResponse(task.NextTaskResponse{NextInProgressTaskId: taskData.ID}))
 }
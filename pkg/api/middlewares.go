@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -70,6 +71,31 @@ func WorkerAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// OptionalWorkerAuthMiddleware sets userInfo when the request carries a valid worker token,
+// requests without one are still let through for routes that are public but track workers
+func OptionalWorkerAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetHeader("Authorization")
+		if len(token) <= 7 || !strings.HasPrefix(token, "Bearer ") {
+			c.Next()
+			return
+		}
+
+		claims := &jwt.RegisteredClaims{}
+		parsedToken, err := jwt.ParseWithClaims(token[7:], claims, func(token *jwt.Token) (interface{}, error) {
+			return []byte(os.Getenv("JWT_SECRET")), nil
+		})
+		if err != nil || !parsedToken.Valid || claims.ExpiresAt == nil || claims.ExpiresAt.Unix() < time.Now().Unix() {
+			log.Debug().Err(err).Msg("Ignoring invalid optional worker token")
+			c.Next()
+			return
+		}
+
+		c.Set("userInfo", claims)
+		c.Next()
+	}
+}
+
 func WorkerLoginMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var requestBody worker.WorkerLoginRequest
@@ -269,6 +295,72 @@ func MinerAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// OptionalMinerAuthMiddleware sets minerUser when the request carries a valid miner API key, requests without
+// one are still let through for public routes that show the owning miner more
+func OptionalMinerAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey := c.GetHeader("X-API-KEY")
+		if apiKey == "" {
+			c.Next()
+			return
+		}
+
+		foundApiKey, err := orm.NewApiKeyORM().GetByApiKey(apiKey)
+		if err != nil || foundApiKey == nil || foundApiKey.IsDelete {
+			log.Debug().Err(err).Msg("Ignoring invalid optional miner API key")
+			c.Next()
+			return
+		}
+
+		c.Set("minerUser", foundApiKey.MinerUser())
+		c.Next()
+	}
+}
+
+// OperatorAuthMiddleware only lets requests through that carry the operator key set in OPERATOR_API_KEY,
+// operator endpoints are disabled when it is not set
+func OperatorAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		operatorKey, ok := os.LookupEnv("OPERATOR_API_KEY")
+		if !ok || operatorKey == "" {
+			log.Error().Msg("OPERATOR_API_KEY is not set, rejecting operator request")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+			return
+		}
+
+		apiKey := c.GetHeader("X-OPERATOR-KEY")
+		if subtle.ConstantTimeCompare([]byte(apiKey), []byte(operatorKey)) != 1 {
+			log.Error().Msg("Invalid operator key")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// MetricsAuthMiddleware only lets scrapes through that carry the bearer token set in METRICS_BEARER_TOKEN,
+// the metrics endpoint is disabled when it is not set
+func MetricsAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		metricsToken, ok := os.LookupEnv("METRICS_BEARER_TOKEN")
+		if !ok || metricsToken == "" {
+			log.Error().Msg("METRICS_BEARER_TOKEN is not set, rejecting metrics scrape")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+			return
+		}
+
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(metricsToken)) != 1 {
+			log.Error().Msg("Invalid metrics token")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
 func generateRandomApiKey() (string, time.Time, error) {
 	b := make([]byte, 32)
 	_, err := rand.Read(b)
@@ -291,54 +383,91 @@ func isTimestampValid(requestTimestamp int64) bool {
 
 func MinerCookieAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		cookie, err := c.Cookie(auth.CookieName)
-		if err != nil {
-			log.Error().Err(err).Msgf("Failed to retrieve named cookie %v", auth.CookieName)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		session, ok := authenticateMinerCookie(c)
+		if !ok {
 			return
 		}
+		c.Set("session", *session)
+		c.Next()
+	}
+}
 
-		cache := cache.GetCacheInstance()
-		result, err := cache.Redis.Get(c.Request.Context(), cookie).Result()
-		if err != nil {
-			if err == redis.Nil {
-				log.Error().Err(err).Msg("Cookie not found in cache")
-				c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
-				return
-			}
-			log.Error().Err(err).Msg("Failed to retrieve cookie from cache")
+// authenticateMinerCookie validates the miner's session cookie, the request is aborted when it is not valid
+func authenticateMinerCookie(c *gin.Context) (*auth.SecureCookieSession, bool) {
+	cookie, err := c.Cookie(auth.CookieName)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to retrieve named cookie %v", auth.CookieName)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return nil, false
+	}
+
+	cache := cache.GetCacheInstance()
+	result, err := cache.Redis.Get(c.Request.Context(), cookie).Result()
+	if err != nil {
+		if err == redis.Nil {
+			log.Error().Err(err).Msg("Cookie not found in cache")
 			c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
-			return
+			return nil, false
 		}
+		log.Error().Err(err).Msg("Failed to retrieve cookie from cache")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return nil, false
+	}
 
-		var session auth.SecureCookieSession
-		if err := json.Unmarshal([]byte(result), &session); err != nil {
-			log.Error().Err(err).Msg("Failed to unmarshal redis data from JSON")
-			c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("Unauthorized"))
+	var session auth.SecureCookieSession
+	if err := json.Unmarshal([]byte(result), &session); err != nil {
+		log.Error().Err(err).Msg("Failed to unmarshal redis data from JSON")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, defaultErrorResponse("Unauthorized"))
+		return nil, false
+	}
+
+	blockKey := session.BlockKey
+	hashKey := session.HashKey
+	// reconstruct secure cookie
+	s := securecookie.New(hashKey, blockKey)
+	var cookieData auth.CookieData
+	if err = s.Decode(auth.CookieName, cookie, &cookieData); err != nil {
+		log.Error().Err(err).Msg("Failed to decode cookie")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return nil, false
+	}
+
+	if session.CookieData.Hotkey != cookieData.Hotkey || session.CookieData.SessionId != cookieData.SessionId {
+		log.Error().Str("expectedHotkey", session.CookieData.Hotkey).Str("actualHotkey", cookieData.Hotkey).
+			Str("expectedSessionId", session.CookieData.SessionId).Str("actualSessionId", cookieData.SessionId).
+			Msg("Cookie data mismatch")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		return nil, false
+	}
+
+	log.Info().Msgf("Cookie validated successfully for hotkey %v, session id %v", session.Hotkey, session.SessionId)
+	return &session, true
+}
+
+// MinerCookieOrApiKeyAuthMiddleware authenticates the miner by API key when the X-API-KEY header is set and by
+// session cookie otherwise, either way the miner user is set on the context like MinerAuthMiddleware does
+func MinerCookieOrApiKeyAuthMiddleware() gin.HandlerFunc {
+	apiKeyAuth := MinerAuthMiddleware()
+	return func(c *gin.Context) {
+		if c.GetHeader("X-API-KEY") != "" {
+			apiKeyAuth(c)
 			return
 		}
 
-		blockKey := session.BlockKey
-		hashKey := session.HashKey
-		// reconstruct secure cookie
-		s := securecookie.New(hashKey, blockKey)
-		var cookieData auth.CookieData
-		if err = s.Decode(auth.CookieName, cookie, &cookieData); err != nil {
-			log.Error().Err(err).Msg("Failed to decode cookie")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
+		session, ok := authenticateMinerCookie(c)
+		if !ok {
 			return
 		}
 
-		if session.CookieData.Hotkey != cookieData.Hotkey || session.CookieData.SessionId != cookieData.SessionId {
-			log.Error().Str("expectedHotkey", session.CookieData.Hotkey).Str("actualHotkey", cookieData.Hotkey).
-				Str("expectedSessionId", session.CookieData.SessionId).Str("actualSessionId", cookieData.SessionId).
-				Msg("Cookie data mismatch")
+		minerUser, err := orm.NewMinerUserORM().GetUserByHotkey(session.Hotkey)
+		if err != nil || minerUser == nil {
+			log.Error().Err(err).Str("hotkey", session.Hotkey).Msg("Failed to retrieve miner user by hotkey")
 			c.AbortWithStatusJSON(http.StatusUnauthorized, defaultErrorResponse("Unauthorized"))
 			return
 		}
 
-		log.Info().Msgf("Cookie validated successfully for hotkey %v, session id %v", session.Hotkey, session.SessionId)
-		c.Set("session", session)
+		c.Set("session", *session)
+		c.Set("minerUser", minerUser)
 		c.Next()
 	}
 }
@@ -17,6 +17,16 @@ func LoginRoutes(router *gin.Engine) {
 			worker.POST("/partner", WorkerAuthMiddleware(), WorkerPartnerCreateController)
 			worker.PUT("/partner/disable", WorkerAuthMiddleware(), DisableMinerByWorkerController)
 			worker.GET("/partner/list", WorkerAuthMiddleware(), GetWorkerPartnerListController)
+			worker.GET("/reputation", WorkerAuthMiddleware(), GetWorkerReputationController)
+			worker.GET("/stake", WorkerAuthMiddleware(), GetWorkerStakeController)
+			worker.GET("/stake/ledger", WorkerAuthMiddleware(), GetWorkerStakeLedgerController)
+			worker.GET("/earnings", WorkerAuthMiddleware(), GetWorkerEarningsController)
+			worker.GET("/earnings/summary", WorkerAuthMiddleware(), GetWorkerEarningsSummaryController)
+			worker.GET("/history", WorkerAuthMiddleware(), GetWorkerHistoryController)
+			worker.PUT("/leaderboard/opt-in", WorkerAuthMiddleware(), SetLeaderboardOptInController)
+			worker.GET("/qualifications", WorkerAuthMiddleware(), GetWorkerQualificationsController)
+			worker.GET("/qualifications/:test-id", WorkerAuthMiddleware(), GetQualificationTestController)
+			worker.POST("/qualifications/:test-id/submit", WorkerAuthMiddleware(), SubmitQualificationTestController)
 		}
 		apiV1.GET("/auth/:address", GeneralRateLimiter(), GenerateNonceController)
 		apiV1.PUT("/partner/edit", GeneralRateLimiter(), WorkerAuthMiddleware(), UpdateWorkerPartnerController)
@@ -25,8 +35,12 @@ func LoginRoutes(router *gin.Engine) {
 			tasks.PUT("/submit-result/:task-id", WorkerAuthMiddleware(), SubmitTaskResultController)
 			// TODO: re-enable InMetagraphOnly(), and rate limiter in future
 			tasks.POST("/create-tasks", MinerAuthMiddleware(), CreateTasksController)
+			tasks.PUT("/close-tasks", MinerAuthMiddleware(), CloseTasksController)
+			tasks.PATCH("/:task-id", MinerAuthMiddleware(), UpdateTaskController)
 			tasks.GET("/task-result/:task-id", ReadTaskRateLimiter(), GetTaskResultsController)
-			tasks.GET("/:task-id", ReadTaskRateLimiter(), GetTaskByIdController)
+			tasks.GET("/task-result/:task-id/aggregate", ReadTaskRateLimiter(), GetTaskResultAggregateController)
+			tasks.GET("/task-result/:task-id/agreement", ReadTaskRateLimiter(), GetTaskAgreementController)
+			tasks.GET("/:task-id", ReadTaskRateLimiter(), OptionalWorkerAuthMiddleware(), OptionalMinerAuthMiddleware(), GetTaskByIdController)
 			tasks.GET("/next-task/:task-id", ReadTaskRateLimiter(), WorkerAuthMiddleware(), GetNextInProgressTaskController)
 			tasks.GET("/", ReadTaskRateLimiter(), WorkerAuthMiddleware(), GetTasksByPageController)
 		}
@@ -34,6 +48,16 @@ func LoginRoutes(router *gin.Engine) {
 		miner := apiV1.Group("/miner")
 		{
 			miner.POST("/session/auth", GeneralRateLimiter(), GenerateCookieAuth)
+			miner.GET("/tasks", ReadTaskRateLimiter(), MinerAuthMiddleware(), GetMinerTasksController)
+			miner.GET("/tasks/results/export", GeneralRateLimiter(), MinerAuthMiddleware(), ExportTaskResultsController)
+			miner.GET("/agreement", GeneralRateLimiter(), MinerAuthMiddleware(), GetMinerAgreementController)
+			miner.GET("/dashboard", GeneralRateLimiter(), MinerCookieOrApiKeyAuthMiddleware(), GetMinerDashboardController)
+			miner.GET("/tasks/:task-id/settlement", GeneralRateLimiter(), MinerAuthMiddleware(), GetTaskSettlementController)
+			miner.GET("/gold/accuracy", GeneralRateLimiter(), MinerAuthMiddleware(), GetWorkerGoldAccuracyController)
+			miner.POST("/qualification-tests", GeneralRateLimiter(), MinerAuthMiddleware(), CreateQualificationTestController)
+			miner.GET("/qualification-tests", GeneralRateLimiter(), MinerAuthMiddleware(), GetMinerQualificationTestsController)
+			miner.PUT("/dwell-time", GeneralRateLimiter(), MinerAuthMiddleware(), SetMinerDwellTimeController)
+			miner.GET("/dwell-time", GeneralRateLimiter(), MinerAuthMiddleware(), GetMinerDwellTimesController)
 
 			apiKeyGroup := miner.Group("/api-key")
 			apiKeyGroup.Use(GeneralRateLimiter())
@@ -51,6 +75,13 @@ func LoginRoutes(router *gin.Engine) {
 				subScriptionKeyGroup.PUT("/disable", MinerCookieAuthMiddleware(), MinerSubscriptionKeyDisableController)
 			}
 		}
+		operator := apiV1.Group("/operator")
+		{
+			operator.Use(GeneralRateLimiter(), OperatorAuthMiddleware())
+			operator.GET("/collusion/report", GetCollusionReportController)
+			operator.PUT("/collusion/flags/:flag-id", UpdateResultFlagController)
+			operator.POST("/workers/:wallet-address/stake/deposit", DepositStakeController)
+		}
 		metrics := apiV1.Group("/metrics")
 		{
 			metrics.Use(MetricsRateLimiter())
@@ -58,6 +89,9 @@ func LoginRoutes(router *gin.Engine) {
 			metrics.GET("/completed-tasks-count", GetTotalCompletedTasksController)
 			metrics.GET("/task-result-count", GetTotalTasksResultsController)
 			metrics.GET("/average-task-completion-time", GetAvgTaskCompletionTimeController)
+			metrics.GET("/task-completion-time", GetTaskCompletionTimeStatsController)
+			metrics.GET("/leaderboard", GetLeaderboardController)
+			metrics.GET("/series", GetMetricSeriesController)
 		}
 	}
 }
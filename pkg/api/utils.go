@@ -4,16 +4,25 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"dojo-api/db"
+	"dojo-api/pkg/agreement"
 	"dojo-api/pkg/auth"
 	"dojo-api/pkg/event"
+	"dojo-api/pkg/export"
 	"dojo-api/pkg/metric"
 	"dojo-api/pkg/miner"
+	"dojo-api/pkg/monitoring"
+	"dojo-api/pkg/orm"
+	"dojo-api/pkg/reputation"
+	"dojo-api/pkg/settlement"
+	"dojo-api/pkg/task"
 	"dojo-api/utils"
 
 	"github.com/gin-gonic/gin"
@@ -71,7 +80,6 @@ func buildSubscriptionKeyResponse(subScriptionKeys []db.SubscriptionKeyModel) mi
 
 func handleMetricData(currentTask *db.TaskModel, updatedTask *db.TaskModel) {
 	metricService := metric.NewMetricService()
-	eventService := event.NewEventService()
 	ctx := context.Background()
 
 	// Always update total task results count
@@ -96,28 +104,199 @@ func handleMetricData(currentTask *db.TaskModel, updatedTask *db.TaskModel) {
 	}
 
 	// Handle task completion events and metrics
-	// TODO: reconsider this logic for task completion events, and avg task completion time
+	// TODO: reconsider this logic for task completion events
 	if (currentTask.Status != db.TaskStatusCompleted) && updatedTask.Status == db.TaskStatusCompleted {
-		go func() {
-			// Update the task completion event
-			if err := eventService.CreateTaskCompletionEvent(ctx, *updatedTask); err != nil {
-				log.Error().Err(err).Msg("Failed to create task completion event")
-			} else {
-				log.Info().Msg("Created task completion event")
-			}
-		}()
+		go recordTaskCompletion(ctx, updatedTask)
+	}
+}
 
-		go func() {
-			// Update the avg task completion
-			if err := metricService.UpdateAvgTaskCompletionTime(ctx); err != nil {
-				log.Error().Err(err).Msg("Failed to update average task completion time")
-			} else {
-				log.Info().Msg("Updated average task completion time")
+// handleCompletedTasks emits the completion event and metrics of tasks a miner completed without a submission, by
+// closing them early or through a task update. The tasks are read back since only their ids are known.
+func handleCompletedTasks(taskIds []string) {
+	go func() {
+		ctx := context.Background()
+		taskORM := orm.NewTaskORM()
+		for _, taskId := range taskIds {
+			completedTask, err := taskORM.GetByIdUncached(ctx, taskId)
+			if err != nil {
+				log.Error().Err(err).Str("taskId", taskId).Msg("Failed to get completed task")
+				continue
 			}
-		}()
+			recordTaskCompletion(ctx, completedTask)
+		}
+	}()
+}
+
+// recordTaskCompletion creates the task completion event, then counts it into the completion time statistics
+func recordTaskCompletion(ctx context.Context, completedTask *db.TaskModel) {
+	eventData, err := event.NewEventService().CreateTaskCompletionEvent(ctx, *completedTask)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create task completion event")
+		return
+	}
+	log.Info().Msg("Created task completion event")
+
+	if err := metric.NewMetricService().RecordTaskCompletionTime(ctx, *eventData); err != nil {
+		log.Error().Err(err).Msg("Failed to record task completion time")
+	} else {
+		log.Info().Msg("Recorded task completion time")
+	}
+}
+
+// handleTaskAgreement recomputes the inter-annotator agreement of the task in the background
+func handleTaskAgreement(updatedTask *db.TaskModel) {
+	go func() {
+		agreementService := agreement.NewAgreementService()
+		if _, err := agreementService.RefreshTaskAgreement(context.Background(), updatedTask); err != nil {
+			log.Error().Err(err).Str("taskId", updatedTask.ID).Msg("Failed to refresh task agreement")
+		}
+	}()
+}
+
+// handleWorkerReputation recomputes the worker's reputation in the background after a submission
+func handleWorkerReputation(worker *db.DojoWorkerModel, updatedTask *db.TaskModel) {
+	go func() {
+		reputationService := reputation.NewReputationService()
+		if _, err := reputationService.UpdateWorkerReputation(context.Background(), worker, updatedTask); err != nil {
+			log.Error().Err(err).Str("workerId", worker.ID).Msg("Failed to update worker reputation")
+		}
+	}()
+}
+
+// handleCollusionDetection compares the worker's submission with the other submissions on the task in the background,
+// a task the submission completed is settled afterwards so flags raised by the submission hold its settlement
+func handleCollusionDetection(worker *db.DojoWorkerModel, updatedTask *db.TaskModel) {
+	go func() {
+		collusionService := collusion.NewCollusionService()
+		if err := collusionService.DetectForWorker(context.Background(), updatedTask.ID, worker.ID); err != nil {
+			log.Error().Err(err).Str("taskId", updatedTask.ID).Str("workerId", worker.ID).Msg("Failed to run collusion detection")
+		}
+		settleCompletedTask(updatedTask)
+	}()
+}
+
+// settleCompletedTask settles the task once the submission completed it
+func settleCompletedTask(updatedTask *db.TaskModel) {
+	if updatedTask.Status != db.TaskStatusCompleted {
+		return
+	}
+	settlementService := settlement.NewSettlementService()
+	if _, err := settlementService.SettleTask(context.Background(), updatedTask); err != nil {
+		if errors.Is(err, settlement.ErrSettlementOnHold) {
+			log.Info().Str("taskId", updatedTask.ID).Msg("Task settlement held until its flags are reviewed")
+			return
+		}
+		log.Error().Err(err).Str("taskId", updatedTask.ID).Msg("Failed to settle task")
 	}
 }
 
+// parseExportParams reads the query params shared by the export endpoints
+func parseExportParams(c *gin.Context) (*export.ExportParams, error) {
+	params := export.ExportParams{
+		Format:     export.ExportFormat(c.DefaultQuery("format", string(export.ExportFormatJSONL))),
+		Mode:       export.ExportMode(c.DefaultQuery("mode", string(export.ExportModeResults))),
+		Checkpoint: c.Query("checkpoint"),
+		Preference: export.PreferenceParams{
+			TieHandling: export.TieHandling(c.DefaultQuery("tieHandling", string(export.TieHandlingSkip))),
+			MinVotes:    1,
+		},
+	}
+
+	if params.Format != export.ExportFormatJSONL && params.Format != export.ExportFormatCSV {
+		return nil, fmt.Errorf("invalid format parameter: '%v', supported formats are %v and %v", params.Format, export.ExportFormatJSONL, export.ExportFormatCSV)
+	}
+
+	if params.Mode != export.ExportModeResults && params.Mode != export.ExportModePreference {
+		return nil, fmt.Errorf("invalid mode parameter: '%v', supported modes are %v and %v", params.Mode, export.ExportModeResults, export.ExportModePreference)
+	}
+
+	if params.Preference.TieHandling != export.TieHandlingSkip && params.Preference.TieHandling != export.TieHandlingSplit {
+		return nil, fmt.Errorf("invalid tieHandling parameter: '%v', supported values are %v and %v", params.Preference.TieHandling, export.TieHandlingSkip, export.TieHandlingSplit)
+	}
+
+	if minAgreement := c.Query("minAgreement"); minAgreement != "" {
+		value, err := strconv.ParseFloat(minAgreement, 64)
+		if err != nil || value < 0 || value > 1 {
+			return nil, errors.New("invalid minAgreement parameter, must be between 0 and 1")
+		}
+		params.Preference.MinAgreement = value
+	}
+
+	if minVotes := c.Query("minVotes"); minVotes != "" {
+		value, err := strconv.Atoi(minVotes)
+		if err != nil || value < 1 {
+			return nil, errors.New("invalid minVotes parameter, must be a positive integer")
+		}
+		params.Preference.MinVotes = value
+	}
+
+	if calibration := c.Query("calibration"); calibration != "" {
+		method, err := task.ParseCalibrationMethod(calibration)
+		if err != nil {
+			return nil, err
+		}
+		params.Calibration = method
+	}
+
+	if from := c.Query("from"); from != "" {
+		params.From = utils.ParseDate(from)
+		if params.From == nil {
+			return nil, errors.New("invalid from parameter")
+		}
+	}
+
+	if to := c.Query("to"); to != "" {
+		params.To = utils.ParseDate(to)
+		if params.To == nil {
+			return nil, errors.New("invalid to parameter")
+		}
+	}
+
+	if taskParam := c.Query("task"); taskParam != "" && taskParam != "All" {
+		taskTypes, err := task.ParseTaskTypes(strings.Split(taskParam, ","))
+		if err != nil {
+			return nil, err
+		}
+		params.Types = taskTypes
+	}
+
+	if statusParam := c.Query("status"); statusParam != "" {
+		statuses, err := task.ParseTaskStatuses(strings.Split(statusParam, ","))
+		if err != nil {
+			return nil, err
+		}
+		params.Statuses = statuses
+	}
+
+	return &params, nil
+}
+
+// parseDateRange reads the from and to query params, the range ends now and covers the last 30 days unless given
+func parseDateRange(c *gin.Context) (time.Time, time.Time, error) {
+	to := time.Now().UTC()
+	if value := c.Query("to"); value != "" {
+		parsedTo := utils.ParseDate(value)
+		if parsedTo == nil {
+			return time.Time{}, time.Time{}, errors.New("invalid to parameter")
+		}
+		to = *parsedTo
+	}
+
+	from := to.AddDate(0, 0, -30)
+	if value := c.Query("from"); value != "" {
+		parsedFrom := utils.ParseDate(value)
+		if parsedFrom == nil {
+			return time.Time{}, time.Time{}, errors.New("invalid from parameter")
+		}
+		from = *parsedFrom
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+	return from, to, nil
+}
+
 // Get the user's IP address from the gin request headers
 func getCallerIP(c *gin.Context) string {
 	if runtimeEnv := utils.LoadDotEnv("RUNTIME_ENV"); runtimeEnv == "aws" {
@@ -138,6 +317,31 @@ func getCallerIP(c *gin.Context) string {
 	return callerIp
 }
 
+// MetricsRoute is where Prometheus scrapes the metrics
+const MetricsRoute = "/metrics"
+
+// PrometheusMiddleware records the count and latency of every request, labelled by the route pattern rather than
+// the path so IDs in the path don't create a series each. Scrapes are left out so they don't count themselves.
+func PrometheusMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.URL.Path == MetricsRoute {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		monitoring.HTTPRequestsTotal.WithLabelValues(route, c.Request.Method, status).Inc()
+		monitoring.HTTPRequestDuration.WithLabelValues(route, c.Request.Method, status).Observe(time.Since(start).Seconds())
+	}
+}
+
 func CustomGinLogger(logger *zerolog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now() // Start timer
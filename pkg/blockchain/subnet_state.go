@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"dojo-api/db"
+	"dojo-api/pkg/monitoring"
 	"dojo-api/pkg/orm"
 	"dojo-api/utils"
 
@@ -218,6 +219,7 @@ func (s *SubnetStateSubscriber) GetSubnetState(subnetId int) *SubnetState {
 	subnetState.ActiveValidatorHotkeys = activeValidatorHotkeys
 	subnetState.ActiveMinerHotkeys = activeMinerHotkeys
 
+	monitoring.SubnetStateRefreshed()
 	return &subnetState
 }
 
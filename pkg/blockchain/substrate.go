@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"dojo-api/pkg/cache"
+	"dojo-api/pkg/monitoring"
 	"dojo-api/utils"
 
 	"github.com/joho/godotenv"
@@ -153,6 +154,7 @@ func (s *SubstrateService) GetStorageRequest(path string, params url.Values) (*S
 
 		// Don't sleep on the last attempt
 		if attempt < maxRetries {
+			monitoring.SubstrateRequestRetriesTotal.WithLabelValues(storageItem(path)).Inc()
 			log.Warn().
 				Err(err).
 				Int("attempt", attempt+1).
@@ -164,9 +166,15 @@ func (s *SubstrateService) GetStorageRequest(path string, params url.Values) (*S
 		}
 	}
 
+	monitoring.SubstrateRequestFailuresTotal.WithLabelValues(storageItem(path)).Inc()
 	return nil, fmt.Errorf("all retry attempts failed after %d attempts: %w", maxRetries, lastErr)
 }
 
+// storageItem returns the storage item a request path queries, e.g. Keys for .../subtensorModule/storage/Keys
+func storageItem(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
 func (s *SubstrateService) executeStorageRequest(path string, params url.Values) (*StorageResponse, error) {
 	req, err := http.NewRequest("GET", path, nil)
 	if err != nil {
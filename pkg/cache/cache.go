@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"dojo-api/pkg/monitoring"
 	"dojo-api/utils"
 
 	"github.com/redis/go-redis/v9"
@@ -43,9 +44,9 @@ type CacheKeys struct {
 
 	// Task Result cache keys
 	TaskResultByTaskAndWorker CacheKey
-	TaskResultByWorker        CacheKey
 	TaskResultsTotal          CacheKey
 	CompletedTasksTotal       CacheKey
+	TaskResultAggregate       CacheKey
 	// Worker cache keys
 	WorkerByWallet CacheKey
 	WorkerCount    CacheKey
@@ -53,6 +54,12 @@ type CacheKeys struct {
 	// Subscription cache keys
 	SubByHotkey CacheKey
 	SubByKey    CacheKey
+
+	// Leaderboard keys, refreshed periodically instead of expiring
+	Leaderboard CacheKey
+
+	// Locks of the periodic jobs, shared by every replica
+	Lock CacheKey
 }
 
 // Default cache keys
@@ -63,9 +70,9 @@ var cacheKeys = CacheKeys{
 
 	// Task Result cache keys
 	TaskResultByTaskAndWorker: "tr:task:worker",
-	TaskResultByWorker:        "tr:worker",
 	TaskResultsTotal:          "metrics:tr:total",
 	CompletedTasksTotal:       "metrics:completed_tasks:total",
+	TaskResultAggregate:       "tr:aggregate",
 
 	// Worker cache keys
 	WorkerByWallet: "worker:wallet",
@@ -74,13 +81,19 @@ var cacheKeys = CacheKeys{
 	// Subscription cache keys
 	SubByHotkey: "sub:hotkey",
 	SubByKey:    "sub:key",
+
+	// Leaderboard keys
+	Leaderboard: "leaderboard",
+
+	// Lock keys
+	Lock: "lock",
 }
 
 var cacheExpirations = map[CacheKey]time.Duration{
 	cacheKeys.TaskById:                  5 * time.Minute,
 	cacheKeys.TasksByWorker:             2 * time.Minute,
 	cacheKeys.TaskResultByTaskAndWorker: 10 * time.Minute,
-	cacheKeys.TaskResultByWorker:        10 * time.Minute,
+	cacheKeys.TaskResultAggregate:       1 * time.Hour,
 	cacheKeys.WorkerByWallet:            5 * time.Minute,
 	cacheKeys.WorkerCount:               1 * time.Minute,
 	cacheKeys.SubByHotkey:               5 * time.Minute,
@@ -190,9 +203,11 @@ func (c *Cache) Shutdown() {
 func (c *Cache) GetCacheValue(key string, value interface{}) error {
 	cachedData, err := c.Get(key)
 	if err != nil || cachedData == "" {
+		monitoring.CacheRequestsTotal.WithLabelValues(monitoring.CacheMiss).Inc()
 		return fmt.Errorf("cache miss for key: %s", key)
 	}
 
+	monitoring.CacheRequestsTotal.WithLabelValues(monitoring.CacheHit).Inc()
 	log.Info().Msgf("Cache hit for key: %s", key)
 	return msgpack.Unmarshal([]byte(cachedData), value)
 }
@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// InstanceID identifies this replica in keys and locks shared with the other replicas
+var InstanceID = uuid.NewString()
+
+// TryPeriodicLock takes the lock of a job that every replica runs periodically with SET NX PX, false when another
+// replica holds it or Redis is unavailable. The lock is never released early, it expires shortly before the job's
+// next round so that only one replica runs each round.
+func (c *Cache) TryPeriodicLock(ctx context.Context, job string, interval time.Duration) bool {
+	key := c.BuildCacheKey(c.Keys.Lock, job)
+	locked, err := c.Redis.SetNX(ctx, key, InstanceID, interval*9/10).Result()
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Failed to take periodic job lock")
+		return false
+	}
+	if !locked {
+		log.Debug().Str("job", job).Msg("Periodic job is running on another replica")
+	}
+	return locked
+}
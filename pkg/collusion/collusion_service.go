@@ -0,0 +1,301 @@
+package collusion
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"dojo-api/db"
+	"dojo-api/pkg/orm"
+	"dojo-api/pkg/task"
+
+	"github.com/rs/zerolog/log"
+)
+
+// normalised score difference up to which two submissions count as near identical, about half a point on the 1-10 scale
+const nearDuplicateTolerance = 0.05
+
+// duplicate submissions made within this window of each other look scripted
+const submissionWindow = 60 * time.Second
+
+// wallets created this recently before submitting are more likely to be throwaway accounts
+const youngWalletAge = 7 * 24 * time.Hour
+
+// weights of the signals that accompany a duplicate submission, a pair is flagged once they reach flagThreshold
+const (
+	sameIPWeight      = 0.5
+	timingWeight      = 0.3
+	youngWalletWeight = 0.2
+	flagThreshold     = 0.5
+)
+
+type CollusionService struct {
+	taskResultORM *orm.TaskResultORM
+	resultFlagORM *orm.ResultFlagORM
+}
+
+func NewCollusionService() *CollusionService {
+	return &CollusionService{
+		taskResultORM: orm.NewTaskResultORM(),
+		resultFlagORM: orm.NewResultFlagORM(),
+	}
+}
+
+// match is another submission on the same task that duplicates the checked one
+type match struct {
+	taskResult *db.TaskResultModel
+	reasons    []FlagReason
+	score      float64
+}
+
+// DetectForWorker compares the worker's submission on the task with every other submission on it,
+// the submission and the duplicates it is suspected of colluding with are flagged as one cluster
+func (s *CollusionService) DetectForWorker(ctx context.Context, taskId string, workerId string) error {
+	taskResults, err := s.taskResultORM.GetCompletedTaskResultsWithWorker(ctx, taskId)
+	if err != nil {
+		log.Error().Err(err).Str("taskId", taskId).Msg("Error getting task results for collusion detection")
+		return err
+	}
+
+	var submitted *db.TaskResultModel
+	for i := range taskResults {
+		if taskResults[i].WorkerID == workerId {
+			submitted = &taskResults[i]
+			break
+		}
+	}
+	if submitted == nil {
+		return nil
+	}
+
+	submittedVector, err := scoreVector(submitted)
+	if err != nil {
+		return err
+	}
+
+	matches := make([]match, 0)
+	for i := range taskResults {
+		other := &taskResults[i]
+		if other.WorkerID == workerId {
+			continue
+		}
+
+		otherVector, err := scoreVector(other)
+		if err != nil {
+			return err
+		}
+
+		reasons, score := compareSubmissions(submitted, submittedVector, other, otherVector)
+		if score >= flagThreshold {
+			matches = append(matches, match{taskResult: other, reasons: reasons, score: score})
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+
+	flags := buildClusterFlags(submitted, matches)
+	if err := s.resultFlagORM.CreateFlags(ctx, flags); err != nil {
+		log.Error().Err(err).Str("taskId", taskId).Msg("Error creating collusion flags")
+		return err
+	}
+
+	if len(flags) > 0 {
+		log.Warn().Str("taskId", taskId).Str("workerId", workerId).Str("clusterId", flags[0].ClusterID).Int("numFlagged", len(flags)).Msg("Flagged suspected colluding task results")
+	}
+	return nil
+}
+
+// compareSubmissions returns the signals linking two submissions and their combined weight,
+// submissions that are not duplicates of each other are never linked
+func compareSubmissions(a *db.TaskResultModel, aVector map[string]float64, b *db.TaskResultModel, bVector map[string]float64) ([]FlagReason, float64) {
+	aFingerprint, aOk := a.Fingerprint()
+	bFingerprint, bOk := b.Fingerprint()
+
+	reasons := make([]FlagReason, 0)
+	switch {
+	case aOk && bOk && aFingerprint == bFingerprint:
+		reasons = append(reasons, ReasonIdenticalResult)
+	case isNearIdentical(aVector, bVector):
+		reasons = append(reasons, ReasonNearIdenticalResult)
+	default:
+		return nil, 0
+	}
+
+	score := 0.0
+	aIP, aOk := a.ClientIP()
+	bIP, bOk := b.ClientIP()
+	if aOk && bOk && aIP != "" && aIP == bIP {
+		reasons = append(reasons, ReasonSameIP)
+		score += sameIPWeight
+	}
+
+	if math.Abs(a.CreatedAt.Sub(b.CreatedAt).Seconds()) <= submissionWindow.Seconds() {
+		reasons = append(reasons, ReasonSubmissionTiming)
+		score += timingWeight
+	}
+
+	if isYoungWallet(a) || isYoungWallet(b) {
+		reasons = append(reasons, ReasonYoungWallet)
+		score += youngWalletWeight
+	}
+	return reasons, score
+}
+
+func isNearIdentical(a, b map[string]float64) bool {
+	if len(a) == 0 || len(a) != len(b) {
+		return false
+	}
+	for key, aValue := range a {
+		bValue, ok := b[key]
+		if !ok || math.Abs(aValue-bValue) > nearDuplicateTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+func isYoungWallet(taskResult *db.TaskResultModel) bool {
+	return taskResult.CreatedAt.Sub(taskResult.DojoWorker().CreatedAt) < youngWalletAge
+}
+
+func scoreVector(taskResult *db.TaskResultModel) (map[string]float64, error) {
+	var resultData []task.Result
+	if err := json.Unmarshal(taskResult.ResultData, &resultData); err != nil {
+		log.Error().Err(err).Str("taskResultId", taskResult.ID).Msg("Error unmarshaling result data")
+		return nil, err
+	}
+	return task.ResultScoreVector(resultData), nil
+}
+
+// buildClusterFlags flags every result of the cluster, results that are already flagged get the new reasons merged
+// into their flag. The cluster joins an existing one when any of its results was flagged as part of a cluster
+// before and is otherwise named after its earliest result.
+func buildClusterFlags(submitted *db.TaskResultModel, matches []match) []db.InnerResultFlag {
+	members := []*db.TaskResultModel{submitted}
+	for _, m := range matches {
+		members = append(members, m.taskResult)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].CreatedAt.Before(members[j].CreatedAt)
+	})
+
+	clusterId := members[0].ID
+	for _, member := range members {
+		if flag, ok := member.ResultFlag(); ok && IsClusterFlag(flag) {
+			clusterId = flag.ClusterID
+			break
+		}
+	}
+
+	// the submitted result carries every signal found, each duplicate only the signals linking it to the submission
+	reasonsByResult := make(map[string][]FlagReason)
+	scoreByResult := make(map[string]float64)
+	for _, m := range matches {
+		reasonsByResult[m.taskResult.ID] = m.reasons
+		scoreByResult[m.taskResult.ID] = m.score
+		reasonsByResult[submitted.ID] = mergeReasons(reasonsByResult[submitted.ID], m.reasons)
+		scoreByResult[submitted.ID] = math.Max(scoreByResult[submitted.ID], m.score)
+	}
+
+	flags := make([]db.InnerResultFlag, 0, len(members))
+	for _, member := range members {
+		reasons := make([]string, 0, len(reasonsByResult[member.ID]))
+		for _, reason := range reasonsByResult[member.ID] {
+			reasons = append(reasons, string(reason))
+		}
+		flags = append(flags, db.InnerResultFlag{
+			TaskResultID: member.ID,
+			ClusterID:    clusterId,
+			Reasons:      reasons,
+			Score:        scoreByResult[member.ID],
+		})
+	}
+	return flags
+}
+
+// IsClusterFlag tells whether the flag links its result to other results, a result flagged only for being
+// submitted too fast is a cluster of its own
+func IsClusterFlag(flag *db.ResultFlagModel) bool {
+	for _, reason := range flag.Reasons {
+		if FlagReason(reason) != ReasonTooFast {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeReasons(existing []FlagReason, reasons []FlagReason) []FlagReason {
+	for _, reason := range reasons {
+		found := false
+		for _, e := range existing {
+			if e == reason {
+				found = true
+				break
+			}
+		}
+		if !found {
+			existing = append(existing, reason)
+		}
+	}
+	return existing
+}
+
+// GetReport returns a page of flagged results grouped into their clusters, newest flags first
+func (s *CollusionService) GetReport(ctx context.Context, params ReportParams) (*CollusionReportResponse, error) {
+	offset := (params.Page - 1) * params.Limit
+	flags, total, err := s.resultFlagORM.GetFlags(ctx, params.Status, params.From, params.To, offset, params.Limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Error getting collusion flags")
+		return nil, err
+	}
+
+	clusters := make([]Cluster, 0)
+	clusterIndex := make(map[string]int)
+	for _, flag := range flags {
+		taskResult := flag.TaskResult()
+		worker := taskResult.DojoWorker()
+
+		flaggedResult := FlaggedResult{
+			FlagId:          flag.ID,
+			TaskResultId:    taskResult.ID,
+			WorkerId:        worker.ID,
+			WalletAddress:   worker.WalletAddress,
+			WalletCreatedAt: worker.CreatedAt,
+			SubmittedAt:     taskResult.CreatedAt,
+			Reasons:         flag.Reasons,
+			Score:           flag.Score,
+			Status:          flag.Status,
+			FlaggedAt:       flag.CreatedAt,
+		}
+		if clientIP, ok := taskResult.ClientIP(); ok {
+			flaggedResult.ClientIp = &clientIP
+		}
+
+		index, ok := clusterIndex[flag.ClusterID]
+		if !ok {
+			index = len(clusters)
+			clusterIndex[flag.ClusterID] = index
+			clusters = append(clusters, Cluster{
+				ClusterId: flag.ClusterID,
+				TaskId:    taskResult.TaskID,
+				Results:   make([]FlaggedResult, 0),
+			})
+		}
+		clusters[index].Results = append(clusters[index].Results, flaggedResult)
+	}
+
+	return &CollusionReportResponse{
+		Clusters:   clusters,
+		TotalFlags: total,
+		Page:       params.Page,
+		Limit:      params.Limit,
+	}, nil
+}
+
+// UpdateFlagStatus records the operator's review of a flagged result
+func (s *CollusionService) UpdateFlagStatus(ctx context.Context, flagId string, status db.ResultFlagStatus) (*db.ResultFlagModel, error) {
+	return s.resultFlagORM.UpdateFlagStatus(ctx, flagId, status)
+}
@@ -0,0 +1,59 @@
+package collusion
+
+import (
+	"time"
+
+	"dojo-api/db"
+)
+
+type FlagReason string
+
+const (
+	ReasonIdenticalResult     FlagReason = "identical_result"
+	ReasonNearIdenticalResult FlagReason = "near_identical_result"
+	ReasonSameIP              FlagReason = "same_ip"
+	ReasonSubmissionTiming    FlagReason = "submission_timing"
+	ReasonYoungWallet         FlagReason = "young_wallet"
+	// submitted before the miner's minimum dwell time for the task type
+	ReasonTooFast FlagReason = "too_fast"
+)
+
+type ReportParams struct {
+	Status *db.ResultFlagStatus
+	From   *time.Time
+	To     *time.Time
+	Page   int
+	Limit  int
+}
+
+type FlaggedResult struct {
+	FlagId          string              `json:"flagId"`
+	TaskResultId    string              `json:"taskResultId"`
+	WorkerId        string              `json:"workerId"`
+	WalletAddress   string              `json:"walletAddress"`
+	WalletCreatedAt time.Time           `json:"walletCreatedAt"`
+	ClientIp        *string             `json:"clientIp"`
+	SubmittedAt     time.Time           `json:"submittedAt"`
+	Reasons         []string            `json:"reasons"`
+	Score           float64             `json:"score"`
+	Status          db.ResultFlagStatus `json:"status"`
+	FlaggedAt       time.Time           `json:"flaggedAt"`
+}
+
+// Cluster groups the results that were flagged together for submitting the same answer
+type Cluster struct {
+	ClusterId string          `json:"clusterId"`
+	TaskId    string          `json:"taskId"`
+	Results   []FlaggedResult `json:"results"`
+}
+
+type CollusionReportResponse struct {
+	Clusters   []Cluster `json:"clusters"`
+	TotalFlags int       `json:"totalFlags"`
+	Page       int       `json:"page"`
+	Limit      int       `json:"limit"`
+}
+
+type UpdateResultFlagRequest struct {
+	Status db.ResultFlagStatus `json:"status" binding:"required"`
+}
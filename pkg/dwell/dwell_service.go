@@ -0,0 +1,122 @@
+package dwell
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"dojo-api/db"
+	"dojo-api/pkg/orm"
+	"dojo-api/pkg/task"
+
+	"github.com/rs/zerolog/log"
+)
+
+type DwellService struct {
+	dwellTimeORM *orm.DwellTimeORM
+}
+
+func NewDwellService() *DwellService {
+	return &DwellService{
+		dwellTimeORM: orm.NewDwellTimeORM(),
+	}
+}
+
+// RecordFetch remembers when the worker first opened the task
+func (s *DwellService) RecordFetch(ctx context.Context, taskId string, workerId string) error {
+	if _, err := s.dwellTimeORM.RecordTaskFetch(ctx, taskId, workerId); err != nil {
+		log.Error().Err(err).Str("taskId", taskId).Str("workerId", workerId).Msg("Error recording task fetch")
+		return err
+	}
+	return nil
+}
+
+// RecordListFetch records a fetch of every task the worker was shown in a task list
+func (s *DwellService) RecordListFetch(ctx context.Context, taskIds []string, workerId string) error {
+	if err := s.dwellTimeORM.RecordTaskFetches(ctx, taskIds, workerId); err != nil {
+		log.Error().Err(err).Str("workerId", workerId).Msg("Error recording task list fetch")
+		return err
+	}
+	return nil
+}
+
+// CheckSubmission measures the worker's time on task and compares it with the minimum the miner set for the
+// task type. A submission without a recorded fetch, e.g. from a client that fetched the task without a token,
+// cannot be timed and counts as too fast when the miner set a minimum.
+func (s *DwellService) CheckSubmission(ctx context.Context, taskModel *db.TaskModel, workerId string) (*SubmissionCheck, error) {
+	check := &SubmissionCheck{}
+
+	fetch, err := s.dwellTimeORM.GetTaskFetch(ctx, taskModel.ID, workerId)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		log.Error().Err(err).Str("taskId", taskModel.ID).Str("workerId", workerId).Msg("Error getting task fetch")
+		return nil, err
+	}
+	if fetch != nil {
+		timeOnTask := time.Since(fetch.FirstFetchedAt).Seconds()
+		check.TimeOnTask = &timeOnTask
+	}
+
+	minerUserId, ok := taskModel.MinerUserID()
+	if !ok {
+		return check, nil
+	}
+
+	dwellTime, err := s.dwellTimeORM.GetMinerDwellTime(ctx, minerUserId, taskModel.Type)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return check, nil
+		}
+		log.Error().Err(err).Str("minerUserId", minerUserId).Msg("Error getting miner dwell time")
+		return nil, err
+	}
+
+	check.Action = dwellTime.Action
+	check.MinSeconds = dwellTime.MinSeconds
+	check.TooFast = dwellTime.MinSeconds > 0 && (check.TimeOnTask == nil || *check.TimeOnTask < float64(dwellTime.MinSeconds))
+	return check, nil
+}
+
+func (s *DwellService) SetMinerDwellTime(ctx context.Context, minerUserId string, request SetDwellTimeRequest) (*DwellTimeResponse, error) {
+	if _, err := task.IsValidTaskType(request.TaskType); err != nil {
+		return nil, &ErrInvalidDwellTime{Reason: err.Error()}
+	}
+	if request.MinSeconds < 0 {
+		return nil, &ErrInvalidDwellTime{Reason: "minSeconds must not be negative"}
+	}
+	if request.Action == "" {
+		request.Action = db.DwellTimeActionReject
+	}
+	if request.Action != db.DwellTimeActionReject && request.Action != db.DwellTimeActionFlag {
+		return nil, &ErrInvalidDwellTime{Reason: "action must be REJECT or FLAG"}
+	}
+
+	dwellTime, err := s.dwellTimeORM.UpsertMinerDwellTime(ctx, minerUserId, request.TaskType, request.MinSeconds, request.Action)
+	if err != nil {
+		log.Error().Err(err).Str("minerUserId", minerUserId).Msg("Error setting miner dwell time")
+		return nil, err
+	}
+	return buildDwellTimeResponse(dwellTime), nil
+}
+
+func (s *DwellService) GetMinerDwellTimes(ctx context.Context, minerUserId string) (*MinerDwellTimeListResponse, error) {
+	dwellTimes, err := s.dwellTimeORM.GetMinerDwellTimes(ctx, minerUserId)
+	if err != nil {
+		log.Error().Err(err).Str("minerUserId", minerUserId).Msg("Error getting miner dwell times")
+		return nil, err
+	}
+
+	responses := make([]DwellTimeResponse, 0, len(dwellTimes))
+	for i := range dwellTimes {
+		responses = append(responses, *buildDwellTimeResponse(&dwellTimes[i]))
+	}
+	return &MinerDwellTimeListResponse{DwellTimes: responses}, nil
+}
+
+func buildDwellTimeResponse(dwellTime *db.MinerDwellTimeModel) *DwellTimeResponse {
+	return &DwellTimeResponse{
+		TaskType:   dwellTime.TaskType,
+		MinSeconds: dwellTime.MinSeconds,
+		Action:     dwellTime.Action,
+		UpdatedAt:  dwellTime.UpdatedAt,
+	}
+}
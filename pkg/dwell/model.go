@@ -0,0 +1,43 @@
+package dwell
+
+import (
+	"fmt"
+	"time"
+
+	"dojo-api/db"
+)
+
+type SetDwellTimeRequest struct {
+	TaskType   db.TaskType `json:"taskType" binding:"required"`
+	MinSeconds int         `json:"minSeconds"`
+	// REJECT refuses too fast submissions, FLAG stores them and flags them for review (default is REJECT)
+	Action db.DwellTimeAction `json:"action"`
+}
+
+type DwellTimeResponse struct {
+	TaskType   db.TaskType        `json:"taskType"`
+	MinSeconds int                `json:"minSeconds"`
+	Action     db.DwellTimeAction `json:"action"`
+	UpdatedAt  time.Time          `json:"updatedAt"`
+}
+
+type MinerDwellTimeListResponse struct {
+	DwellTimes []DwellTimeResponse `json:"dwellTimes"`
+}
+
+// SubmissionCheck is the outcome of comparing a submission's time on task with the miner's minimum
+type SubmissionCheck struct {
+	// nil when the worker never fetched the task with their token, which is too fast whenever the miner set a minimum
+	TimeOnTask *float64
+	TooFast    bool
+	Action     db.DwellTimeAction
+	MinSeconds int
+}
+
+type ErrInvalidDwellTime struct {
+	Reason string
+}
+
+func (e *ErrInvalidDwellTime) Error() string {
+	return fmt.Sprintf("invalid dwell time: %s", e.Reason)
+}
@@ -0,0 +1,254 @@
+package earnings
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dojo-api/db"
+	"dojo-api/pkg/orm"
+
+	"github.com/rs/zerolog/log"
+)
+
+// number of task results read from the database at a time when writing the CSV
+const csvBatchSize = 500
+
+var csvHeader = []string{
+	"task_result_id",
+	"task_id",
+	"task_title",
+	"task_type",
+	"miner_hotkey",
+	"status",
+	"submitted_at",
+	"stake_amount",
+	"potential_reward",
+	"potential_loss",
+	"finalised_reward",
+	"finalised_loss",
+	"settled",
+}
+
+type EarningsService struct {
+	taskResultORM *orm.TaskResultORM
+}
+
+func NewEarningsService() *EarningsService {
+	return &EarningsService{
+		taskResultORM: orm.NewTaskResultORM(),
+	}
+}
+
+func (s *EarningsService) ListEarnings(ctx context.Context, workerId string, params EarningsParams, page int, limit int) (*EarningsListResponse, error) {
+	taskResults, err := s.taskResultORM.GetTaskResultsByWorker(ctx, workerId, params.From, params.To, (page-1)*limit, limit)
+	if err != nil {
+		log.Error().Err(err).Str("workerId", workerId).Msg("Error getting worker task results")
+		return nil, err
+	}
+
+	total, err := s.taskResultORM.CountTaskResultsByWorker(ctx, workerId, params.From, params.To)
+	if err != nil {
+		log.Error().Err(err).Str("workerId", workerId).Msg("Error counting worker task results")
+		return nil, err
+	}
+
+	records := make([]EarningRecord, 0, len(taskResults))
+	for _, taskResult := range taskResults {
+		records = append(records, buildEarningRecord(taskResult))
+	}
+
+	return &EarningsListResponse{
+		Earnings:   records,
+		TotalItems: total,
+		Page:       page,
+		Limit:      limit,
+	}, nil
+}
+
+// WriteEarningsCSV streams every result of the worker within the range to w, one row per result
+func (s *EarningsService) WriteEarningsCSV(ctx context.Context, workerId string, params EarningsParams, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	// the range is cut off at the start of the download, so results submitted while streaming cannot shift the pages
+	if now := time.Now().UTC(); params.To.After(now) {
+		params.To = now
+	}
+	for offset := 0; ; offset += csvBatchSize {
+		taskResults, err := s.taskResultORM.GetTaskResultsByWorker(ctx, workerId, params.From, params.To, offset, csvBatchSize)
+		if err != nil {
+			log.Error().Err(err).Str("workerId", workerId).Int("offset", offset).Msg("Error getting worker task results for CSV")
+			return err
+		}
+
+		for _, taskResult := range taskResults {
+			if err := writer.Write(buildEarningRow(buildEarningRecord(taskResult))); err != nil {
+				return err
+			}
+		}
+
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+
+		if len(taskResults) < csvBatchSize {
+			return nil
+		}
+	}
+}
+
+// GetSummary totals the worker's earnings over the whole range, per miner and per time window
+func (s *EarningsService) GetSummary(ctx context.Context, workerId string, params SummaryParams) (*EarningsSummaryResponse, error) {
+	rows, err := s.taskResultORM.GetWorkerEarnings(ctx, workerId, string(params.Interval), params.From, params.To)
+	if err != nil {
+		log.Error().Err(err).Str("workerId", workerId).Msg("Error getting worker earnings")
+		return nil, err
+	}
+
+	summary := &EarningsSummaryResponse{
+		Interval: params.Interval,
+		From:     params.From,
+		To:       params.To,
+		Miners:   make([]MinerEarnings, 0),
+		Periods:  make([]EarningsPeriod, 0),
+	}
+	minerIndexes := make(map[string]int)
+	for _, row := range rows {
+		totals, err := buildEarningsTotals(row)
+		if err != nil {
+			return nil, err
+		}
+
+		// rows are ordered by window, so a new window starts whenever the window start changes
+		if len(summary.Periods) == 0 || !summary.Periods[len(summary.Periods)-1].PeriodStart.Equal(row.PeriodStart.Time) {
+			summary.Periods = append(summary.Periods, EarningsPeriod{
+				PeriodStart: row.PeriodStart.Time,
+				Miners:      make([]MinerEarnings, 0),
+			})
+		}
+		period := &summary.Periods[len(summary.Periods)-1]
+		period.Miners = append(period.Miners, MinerEarnings{
+			MinerUserId:    row.MinerUserID,
+			MinerHotkey:    row.MinerHotkey,
+			EarningsTotals: totals,
+		})
+		period.EarningsTotals.add(totals)
+
+		minerKey := ""
+		if row.MinerUserID != nil {
+			minerKey = *row.MinerUserID
+		}
+		index, ok := minerIndexes[minerKey]
+		if !ok {
+			index = len(summary.Miners)
+			minerIndexes[minerKey] = index
+			summary.Miners = append(summary.Miners, MinerEarnings{
+				MinerUserId: row.MinerUserID,
+				MinerHotkey: row.MinerHotkey,
+			})
+		}
+		summary.Miners[index].EarningsTotals.add(totals)
+		summary.EarningsTotals.add(totals)
+	}
+
+	return summary, nil
+}
+
+func (t *EarningsTotals) add(other EarningsTotals) {
+	t.NumResults += other.NumResults
+	t.NumSettled += other.NumSettled
+	t.FinalisedReward += other.FinalisedReward
+	t.FinalisedLoss += other.FinalisedLoss
+	t.PendingReward += other.PendingReward
+	t.PendingLoss += other.PendingLoss
+}
+
+func buildEarningsTotals(row orm.WorkerEarningsRow) (EarningsTotals, error) {
+	numResults, err := strconv.Atoi(string(row.NumResults))
+	if err != nil {
+		return EarningsTotals{}, err
+	}
+	numSettled, err := strconv.Atoi(string(row.NumSettled))
+	if err != nil {
+		return EarningsTotals{}, err
+	}
+	return EarningsTotals{
+		NumResults:      numResults,
+		NumSettled:      numSettled,
+		FinalisedReward: float64(row.FinalisedReward),
+		FinalisedLoss:   float64(row.FinalisedLoss),
+		PendingReward:   float64(row.PendingReward),
+		PendingLoss:     float64(row.PendingLoss),
+	}, nil
+}
+
+func buildEarningRecord(taskResult db.TaskResultModel) EarningRecord {
+	taskModel := taskResult.Task()
+	record := EarningRecord{
+		TaskResultId: taskResult.ID,
+		TaskId:       taskModel.ID,
+		TaskTitle:    taskModel.Title,
+		TaskType:     taskModel.Type,
+		Status:       taskResult.Status,
+		SubmittedAt:  taskResult.CreatedAt,
+	}
+	if minerUser, ok := taskModel.MinerUser(); ok {
+		record.MinerUserId = &minerUser.ID
+		record.MinerHotkey = &minerUser.Hotkey
+	}
+	if stakeAmount, ok := taskResult.StakeAmount(); ok {
+		record.StakeAmount = &stakeAmount
+	}
+	if potentialReward, ok := taskResult.PotentialReward(); ok {
+		record.PotentialReward = &potentialReward
+	}
+	if potentialLoss, ok := taskResult.PotentialLoss(); ok {
+		record.PotentialLoss = &potentialLoss
+	}
+	if finalisedReward, ok := taskResult.FinalisedReward(); ok {
+		record.FinalisedReward = &finalisedReward
+		record.Settled = true
+	}
+	if finalisedLoss, ok := taskResult.FinalisedLoss(); ok {
+		record.FinalisedLoss = &finalisedLoss
+	}
+	return record
+}
+
+func buildEarningRow(record EarningRecord) []string {
+	formatOptional := func(value *float64) string {
+		if value == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*value, 'f', -1, 64)
+	}
+	var minerHotkey string
+	if record.MinerHotkey != nil {
+		minerHotkey = *record.MinerHotkey
+	}
+	return []string{
+		record.TaskResultId,
+		record.TaskId,
+		record.TaskTitle,
+		string(record.TaskType),
+		minerHotkey,
+		string(record.Status),
+		record.SubmittedAt.Format(time.RFC3339),
+		formatOptional(record.StakeAmount),
+		formatOptional(record.PotentialReward),
+		formatOptional(record.PotentialLoss),
+		formatOptional(record.FinalisedReward),
+		formatOptional(record.FinalisedLoss),
+		strconv.FormatBool(record.Settled),
+	}
+}
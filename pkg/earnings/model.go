@@ -0,0 +1,83 @@
+package earnings
+
+import (
+	"time"
+
+	"dojo-api/db"
+)
+
+type EarningsInterval string
+
+const (
+	EarningsIntervalDay   EarningsInterval = "day"
+	EarningsIntervalWeek  EarningsInterval = "week"
+	EarningsIntervalMonth EarningsInterval = "month"
+)
+
+type EarningsParams struct {
+	From time.Time
+	To   time.Time
+}
+
+type SummaryParams struct {
+	Interval EarningsInterval
+	From     time.Time
+	To       time.Time
+}
+
+// EarningRecord is one of the worker's task results, the finalised amounts are nil until the task is settled
+type EarningRecord struct {
+	TaskResultId    string              `json:"taskResultId"`
+	TaskId          string              `json:"taskId"`
+	TaskTitle       string              `json:"taskTitle"`
+	TaskType        db.TaskType         `json:"taskType"`
+	MinerUserId     *string             `json:"minerUserId"`
+	MinerHotkey     *string             `json:"minerHotkey"`
+	Status          db.TaskResultStatus `json:"status"`
+	SubmittedAt     time.Time           `json:"submittedAt"`
+	StakeAmount     *float64            `json:"stakeAmount"`
+	PotentialReward *float64            `json:"potentialReward"`
+	PotentialLoss   *float64            `json:"potentialLoss"`
+	FinalisedReward *float64            `json:"finalisedReward"`
+	FinalisedLoss   *float64            `json:"finalisedLoss"`
+	Settled         bool                `json:"settled"`
+}
+
+type EarningsListResponse struct {
+	Earnings   []EarningRecord `json:"earnings"`
+	TotalItems int             `json:"totalItems"`
+	Page       int             `json:"page"`
+	Limit      int             `json:"limit"`
+}
+
+// EarningsTotals splits the worker's results into settled amounts and amounts still pending settlement,
+// pending amounts are the potential reward and loss recorded on COMPLETED submissions
+type EarningsTotals struct {
+	NumResults      int     `json:"numResults"`
+	NumSettled      int     `json:"numSettled"`
+	FinalisedReward float64 `json:"finalisedReward"`
+	FinalisedLoss   float64 `json:"finalisedLoss"`
+	PendingReward   float64 `json:"pendingReward"`
+	PendingLoss     float64 `json:"pendingLoss"`
+}
+
+type MinerEarnings struct {
+	MinerUserId *string `json:"minerUserId"`
+	MinerHotkey *string `json:"minerHotkey"`
+	EarningsTotals
+}
+
+type EarningsPeriod struct {
+	PeriodStart time.Time `json:"periodStart"`
+	EarningsTotals
+	Miners []MinerEarnings `json:"miners"`
+}
+
+type EarningsSummaryResponse struct {
+	Interval EarningsInterval `json:"interval"`
+	From     time.Time        `json:"from"`
+	To       time.Time        `json:"to"`
+	EarningsTotals
+	Miners  []MinerEarnings  `json:"miners"`
+	Periods []EarningsPeriod `json:"periods"`
+}
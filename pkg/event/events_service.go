@@ -17,13 +17,15 @@ func NewEventService() *EventService {
 	}
 }
 
-func (o *EventService) CreateTaskCompletionEvent(ctx context.Context, task db.TaskModel) error {
+func (o *EventService) CreateTaskCompletionEvent(ctx context.Context, task db.TaskModel) (*EventTaskCompletionTime, error) {
 	taskCompletionTime := int(time.Since(task.CreatedAt).Seconds())
 
-	eventData := EventTaskCompletionTime{TaskId: task.ID, TaskCompletionTime: taskCompletionTime}
+	eventData := EventTaskCompletionTime{TaskId: task.ID, TaskType: task.Type, TaskCompletionTime: taskCompletionTime}
 
 	eventsORM := orm.NewEventsORM()
-	err := eventsORM.CreateEventByType(ctx, db.EventsTypeTaskCompletionTime, eventData)
+	if err := eventsORM.CreateEventByType(ctx, db.EventsTypeTaskCompletionTime, eventData); err != nil {
+		return nil, err
+	}
 
-	return err
+	return &eventData, nil
 }
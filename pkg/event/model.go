@@ -1,6 +1,9 @@
 package event
 
+import "dojo-api/db"
+
 type EventTaskCompletionTime struct {
-	TaskId             string `json:"task_id"`
-	TaskCompletionTime int    `json:"task_completion_time"`
+	TaskId             string      `json:"task_id"`
+	TaskType           db.TaskType `json:"task_type,omitempty"`
+	TaskCompletionTime int         `json:"task_completion_time"`
 }
@@ -0,0 +1,262 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dojo-api/db"
+	"dojo-api/pkg/orm"
+	"dojo-api/pkg/task"
+
+	"github.com/rs/zerolog/log"
+)
+
+// number of task results read from the database at a time, keeps memory flat for large exports
+const exportBatchSize = 500
+
+type ExportService struct {
+	taskORM       *orm.TaskORM
+	taskResultORM *orm.TaskResultORM
+}
+
+func NewExportService() *ExportService {
+	return &ExportService{
+		taskORM:       orm.NewTaskORM(),
+		taskResultORM: orm.NewTaskResultORM(),
+	}
+}
+
+type recordWriter interface {
+	Write(record TaskResultRecord) error
+	Flush() error
+}
+
+type jsonlWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *jsonlWriter) Write(record TaskResultRecord) error {
+	return w.encoder.Encode(record)
+}
+
+func (w *jsonlWriter) Flush() error {
+	return nil
+}
+
+// csvWriter flattens each record into one row per model response and criterion
+type csvWriter struct {
+	writer *csv.Writer
+	// adds a calibrated_value column after max
+	calibrated bool
+}
+
+func (w *csvWriter) Write(record TaskResultRecord) error {
+	calibratedValues := make(map[string]*float64)
+	for _, calibratedScore := range record.CalibratedScores {
+		calibratedValues[calibratedScore.Model+":"+string(calibratedScore.Type)] = calibratedScore.CalibratedValue
+	}
+
+	for _, result := range record.ResultData {
+		for _, criteria := range result.Criteria {
+			var value, minValue, maxValue string
+			if scoreCriteria, ok := criteria.(task.ScoreCriteria); ok {
+				value = strconv.FormatFloat(scoreCriteria.MinerScore, 'f', -1, 64)
+				minValue = strconv.FormatFloat(scoreCriteria.Min, 'f', -1, 64)
+				maxValue = strconv.FormatFloat(scoreCriteria.Max, 'f', -1, 64)
+			}
+			row := []string{
+				record.TaskResultId,
+				record.TaskId,
+				record.WorkerId,
+				string(record.Status),
+				record.CreatedAt.Format(time.RFC3339),
+				string(record.TaskType),
+				string(record.TaskStatus),
+				record.TaskData.Prompt,
+				result.Model,
+				string(criteria.GetType()),
+				value,
+				minValue,
+				maxValue,
+			}
+			if w.calibrated {
+				var calibratedValue string
+				if v := calibratedValues[result.Model+":"+string(criteria.GetType())]; v != nil {
+					calibratedValue = strconv.FormatFloat(*v, 'f', -1, 64)
+				}
+				row = append(row, calibratedValue)
+			}
+			if err := w.writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *csvWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func newRecordWriter(format ExportFormat, w io.Writer, calibrated bool) (recordWriter, error) {
+	switch format {
+	case ExportFormatJSONL:
+		return &jsonlWriter{encoder: json.NewEncoder(w)}, nil
+	case ExportFormatCSV:
+		writer := csv.NewWriter(w)
+		header := csvHeader
+		if calibrated {
+			header = append(append([]string{}, csvHeader...), "calibrated_value")
+		}
+		if err := writer.Write(header); err != nil {
+			return nil, err
+		}
+		return &csvWriter{writer: writer, calibrated: calibrated}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %v", format)
+	}
+}
+
+func buildTaskResultFilters(params ExportParams) []db.TaskResultWhereParam {
+	filterParams := make([]db.TaskResultWhereParam, 0)
+	if params.From != nil {
+		filterParams = append(filterParams, db.TaskResult.CreatedAt.Gte(*params.From))
+	}
+	if params.To != nil {
+		filterParams = append(filterParams, db.TaskResult.CreatedAt.Lte(*params.To))
+	}
+
+	// gold tasks only exist to grade workers, their results are not part of the dataset
+	taskFilters := []db.TaskWhereParam{db.Task.IsGold.Equals(false)}
+	if len(params.Types) > 0 {
+		taskFilters = append(taskFilters, db.Task.Type.In(params.Types))
+	}
+	if len(params.Statuses) > 0 {
+		taskFilters = append(taskFilters, db.Task.Status.In(params.Statuses))
+	}
+	filterParams = append(filterParams, db.TaskResult.Task.Where(taskFilters...))
+	return filterParams
+}
+
+// forEachTaskResult walks the miner's task results in batches from the checkpoint onwards,
+// the parsed task data is only kept for the current batch
+func (s *ExportService) forEachTaskResult(ctx context.Context, minerUserId string, params ExportParams, onBatchEnd func() error, fn func(record TaskResultRecord) error) error {
+	filterParams := buildTaskResultFilters(params)
+	cursor := params.Checkpoint
+	numExported := 0
+	for {
+		taskResults, err := s.taskResultORM.GetTaskResultsByMinerUser(ctx, minerUserId, filterParams, cursor, exportBatchSize)
+		if err != nil {
+			log.Error().Err(err).Str("cursor", cursor).Msg("Error fetching task results for export")
+			return err
+		}
+
+		if len(taskResults) == 0 {
+			break
+		}
+
+		var calibrator *task.Calibrator
+		if params.Calibration != "" {
+			calibrator, err = task.NewCalibrator(ctx, params.Calibration, taskResults)
+			if err != nil {
+				return err
+			}
+		}
+
+		taskDataById := make(map[string]task.TaskData)
+		for _, taskResult := range taskResults {
+			record, err := buildTaskResultRecord(taskResult, taskDataById)
+			if err != nil {
+				log.Error().Err(err).Str("taskResultId", taskResult.ID).Msg("Error building export record")
+				return err
+			}
+			if calibrator != nil {
+				record.CalibratedScores = calibrator.Calibrate(taskResult.WorkerID, record.ResultData)
+			}
+
+			if err := fn(*record); err != nil {
+				return err
+			}
+		}
+
+		if onBatchEnd != nil {
+			if err := onBatchEnd(); err != nil {
+				return err
+			}
+		}
+
+		numExported += len(taskResults)
+		cursor = taskResults[len(taskResults)-1].ID
+		if len(taskResults) < exportBatchSize {
+			break
+		}
+	}
+
+	log.Info().Int("numExported", numExported).Str("minerUserId", minerUserId).Msg("Finished exporting task results")
+	return nil
+}
+
+// ExportTaskResults streams all of the miner's task results to w, flushing after every batch
+func (s *ExportService) ExportTaskResults(ctx context.Context, minerUserId string, params ExportParams, w io.Writer) error {
+	writer, err := newRecordWriter(params.Format, w, params.Calibration != "")
+	if err != nil {
+		return err
+	}
+
+	flush := newBatchFlusher(writer, w)
+	if err := s.forEachTaskResult(ctx, minerUserId, params, flush, writer.Write); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// newBatchFlusher flushes the buffered writer and pushes the bytes to the client when streaming over HTTP
+func newBatchFlusher(writer interface{ Flush() error }, w io.Writer) func() error {
+	return func() error {
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		return nil
+	}
+}
+
+func buildTaskResultRecord(taskResult db.TaskResultModel, taskDataById map[string]task.TaskData) (*TaskResultRecord, error) {
+	taskModel := taskResult.Task()
+
+	taskData, ok := taskDataById[taskModel.ID]
+	if !ok {
+		if err := json.Unmarshal(taskModel.TaskData, &taskData); err != nil {
+			return nil, err
+		}
+		taskDataById[taskModel.ID] = taskData
+	}
+
+	var resultData []task.Result
+	if err := json.Unmarshal(taskResult.ResultData, &resultData); err != nil {
+		return nil, err
+	}
+
+	return &TaskResultRecord{
+		TaskResultId:  taskResult.ID,
+		TaskId:        taskModel.ID,
+		WorkerId:      taskResult.WorkerID,
+		Status:        taskResult.Status,
+		CreatedAt:     taskResult.CreatedAt,
+		TaskTitle:     taskModel.Title,
+		TaskType:      taskModel.Type,
+		TaskStatus:    taskModel.Status,
+		TaskData:      taskData,
+		ResultData:    resultData,
+		ResponseOrder: taskResult.ResponseOrder,
+	}, nil
+}
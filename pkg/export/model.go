@@ -0,0 +1,113 @@
+package export
+
+import (
+	"time"
+
+	"dojo-api/db"
+	"dojo-api/pkg/task"
+)
+
+type ExportFormat string
+
+const (
+	ExportFormatJSONL ExportFormat = "jsonl"
+	ExportFormatCSV   ExportFormat = "csv"
+)
+
+type ExportMode string
+
+const (
+	// ExportModeResults emits every task result as submitted by workers
+	ExportModeResults ExportMode = "results"
+	// ExportModePreference aggregates the results of finished tasks into chosen/rejected pairs
+	ExportModePreference ExportMode = "preference"
+)
+
+type TieHandling string
+
+const (
+	// TieHandlingSkip leaves votes where both responses got the same score out of num_votes and agreement
+	TieHandlingSkip TieHandling = "skip"
+	// TieHandlingSplit counts tied votes as half a vote for each response, lowering the agreement
+	TieHandlingSplit TieHandling = "split"
+)
+
+type PreferenceParams struct {
+	TieHandling TieHandling
+	// fraction of counted votes that must prefer the chosen response, between 0 and 1
+	MinAgreement float64
+	MinVotes     int
+}
+
+type ExportParams struct {
+	Format     ExportFormat
+	Mode       ExportMode
+	From       *time.Time
+	To         *time.Time
+	Types      []db.TaskType
+	Statuses   []db.TaskStatus
+	Checkpoint string
+	Preference PreferenceParams
+	// adds calibrated scores to each record when set, results mode only
+	Calibration task.CalibrationMethod
+}
+
+// TaskResultRecord is a single JSONL line, TaskResultId doubles as the checkpoint to resume from
+type TaskResultRecord struct {
+	TaskResultId string              `json:"taskResultId"`
+	TaskId       string              `json:"taskId"`
+	WorkerId     string              `json:"workerId"`
+	Status       db.TaskResultStatus `json:"status"`
+	CreatedAt    time.Time           `json:"createdAt"`
+	TaskTitle    string              `json:"taskTitle"`
+	TaskType     db.TaskType         `json:"taskType"`
+	TaskStatus   db.TaskStatus       `json:"taskStatus"`
+	TaskData     task.TaskData       `json:"taskData"`
+	ResultData   []task.Result       `json:"resultData"`
+	// original index of the response the worker was shown at each position, empty for results stored before shuffling
+	ResponseOrder []int `json:"responseOrder,omitempty"`
+	// only set when calibration is requested
+	CalibratedScores []task.CalibratedScore `json:"calibratedScores,omitempty"`
+}
+
+// PreferencePairRecord is a single preference pair, TaskId doubles as the checkpoint to resume from
+type PreferencePairRecord struct {
+	TaskId        string      `json:"task_id"`
+	Prompt        string      `json:"prompt"`
+	Chosen        interface{} `json:"chosen"`
+	Rejected      interface{} `json:"rejected"`
+	ChosenModel   string      `json:"chosen_model"`
+	RejectedModel string      `json:"rejected_model"`
+	// mean score difference between the chosen and rejected response over the counted votes
+	Margin    float64 `json:"margin"`
+	NumVotes  int     `json:"num_votes"`
+	Agreement float64 `json:"agreement"`
+}
+
+var csvHeader = []string{
+	"task_result_id",
+	"task_id",
+	"worker_id",
+	"status",
+	"created_at",
+	"task_type",
+	"task_status",
+	"prompt",
+	"model",
+	"criteria_type",
+	"value",
+	"min",
+	"max",
+}
+
+var preferenceCsvHeader = []string{
+	"task_id",
+	"prompt",
+	"chosen_model",
+	"chosen",
+	"rejected_model",
+	"rejected",
+	"margin",
+	"num_votes",
+	"agreement",
+}
@@ -0,0 +1,283 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"dojo-api/db"
+	"dojo-api/pkg/task"
+
+	"github.com/rs/zerolog/log"
+)
+
+// only tasks that stopped collecting results have a stable set of votes
+var finishedTaskStatuses = []db.TaskStatus{db.TaskStatusCompleted, db.TaskStatusExpired}
+
+type preferencePairWriter interface {
+	Write(record PreferencePairRecord) error
+	Flush() error
+}
+
+type preferenceJsonlWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *preferenceJsonlWriter) Write(record PreferencePairRecord) error {
+	return w.encoder.Encode(record)
+}
+
+func (w *preferenceJsonlWriter) Flush() error {
+	return nil
+}
+
+type preferenceCsvWriter struct {
+	writer *csv.Writer
+}
+
+func (w *preferenceCsvWriter) Write(record PreferencePairRecord) error {
+	chosen, err := completionToString(record.Chosen)
+	if err != nil {
+		return err
+	}
+	rejected, err := completionToString(record.Rejected)
+	if err != nil {
+		return err
+	}
+	return w.writer.Write([]string{
+		record.TaskId,
+		record.Prompt,
+		record.ChosenModel,
+		chosen,
+		record.RejectedModel,
+		rejected,
+		strconv.FormatFloat(record.Margin, 'f', -1, 64),
+		strconv.Itoa(record.NumVotes),
+		strconv.FormatFloat(record.Agreement, 'f', -1, 64),
+	})
+}
+
+func (w *preferenceCsvWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// completionToString keeps plain text completions as is and JSON encodes structured ones, e.g. dialogues
+func completionToString(completion interface{}) (string, error) {
+	if text, ok := completion.(string); ok {
+		return text, nil
+	}
+	encoded, err := json.Marshal(completion)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+func newPreferencePairWriter(format ExportFormat, w io.Writer) (preferencePairWriter, error) {
+	switch format {
+	case ExportFormatJSONL:
+		return &preferenceJsonlWriter{encoder: json.NewEncoder(w)}, nil
+	case ExportFormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(preferenceCsvHeader); err != nil {
+			return nil, err
+		}
+		return &preferenceCsvWriter{writer: writer}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %v", format)
+	}
+}
+
+func buildFinishedTaskFilters(params ExportParams) []db.TaskWhereParam {
+	statuses := finishedTaskStatuses
+	if len(params.Statuses) > 0 {
+		statuses = make([]db.TaskStatus, 0)
+		for _, status := range params.Statuses {
+			if status == db.TaskStatusCompleted || status == db.TaskStatusExpired {
+				statuses = append(statuses, status)
+			}
+		}
+	}
+
+	filterParams := []db.TaskWhereParam{
+		db.Task.Status.In(statuses),
+		db.Task.IsGold.Equals(false),
+	}
+	if len(params.Types) > 0 {
+		filterParams = append(filterParams, db.Task.Type.In(params.Types))
+	}
+	if params.From != nil {
+		filterParams = append(filterParams, db.Task.CreatedAt.Gte(*params.From))
+	}
+	if params.To != nil {
+		filterParams = append(filterParams, db.Task.CreatedAt.Lte(*params.To))
+	}
+	return filterParams
+}
+
+// ExportPreferencePairs streams chosen/rejected pairs built from the miner's finished tasks to w,
+// tasks are read in batches and the checkpoint is the last exported task ID
+func (s *ExportService) ExportPreferencePairs(ctx context.Context, minerUserId string, params ExportParams, w io.Writer) error {
+	writer, err := newPreferencePairWriter(params.Format, w)
+	if err != nil {
+		return err
+	}
+	flush := newBatchFlusher(writer, w)
+
+	filterParams := buildFinishedTaskFilters(params)
+	cursor := params.Checkpoint
+	numPairs := 0
+	for {
+		tasks, err := s.taskORM.GetTasksByMinerUser(ctx, minerUserId, filterParams, cursor, exportBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, taskModel := range tasks {
+			pairs, err := s.buildPreferencePairs(ctx, taskModel, params.Preference)
+			if err != nil {
+				log.Error().Err(err).Str("taskId", taskModel.ID).Msg("Error building preference pairs")
+				return err
+			}
+
+			for _, pair := range pairs {
+				if err := writer.Write(pair); err != nil {
+					return err
+				}
+			}
+			numPairs += len(pairs)
+		}
+
+		if err := flush(); err != nil {
+			return err
+		}
+
+		if len(tasks) < exportBatchSize {
+			break
+		}
+		cursor = tasks[len(tasks)-1].ID
+	}
+
+	log.Info().Int("numPairs", numPairs).Str("minerUserId", minerUserId).Msg("Finished exporting preference pairs")
+	return nil
+}
+
+func (s *ExportService) buildPreferencePairs(ctx context.Context, taskModel db.TaskModel, params PreferenceParams) ([]PreferencePairRecord, error) {
+	var taskData task.TaskData
+	if err := json.Unmarshal(taskModel.TaskData, &taskData); err != nil {
+		return nil, err
+	}
+
+	taskResults, err := s.taskResultORM.GetTaskResultsByTaskId(ctx, taskModel.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// one model to score map per worker, invalid results are not votes
+	votes := make([]map[string]float64, 0, len(taskResults))
+	for _, taskResult := range taskResults {
+		if taskResult.Status != db.TaskResultStatusCompleted {
+			continue
+		}
+
+		var resultData []task.Result
+		if err := json.Unmarshal(taskResult.ResultData, &resultData); err != nil {
+			return nil, err
+		}
+		votes = append(votes, scoresByModel(resultData))
+	}
+
+	pairs := make([]PreferencePairRecord, 0)
+	responses := taskData.Responses
+	for i := 0; i < len(responses); i++ {
+		for j := i + 1; j < len(responses); j++ {
+			pair, ok := comparePreference(votes, responses[i], responses[j], params)
+			if !ok {
+				continue
+			}
+			pair.TaskId = taskModel.ID
+			pair.Prompt = taskData.Prompt
+			pairs = append(pairs, *pair)
+		}
+	}
+	return pairs, nil
+}
+
+func scoresByModel(resultData []task.Result) map[string]float64 {
+	scores := make(map[string]float64)
+	for _, result := range resultData {
+		for _, criteria := range result.Criteria {
+			if scoreCriteria, ok := criteria.(task.ScoreCriteria); ok {
+				scores[result.Model] = scoreCriteria.MinerScore
+				break
+			}
+		}
+	}
+	return scores
+}
+
+// comparePreference tallies the votes of every worker that scored both responses, returns false when there is
+// no clear winner or the pair does not meet the vote and agreement thresholds
+func comparePreference(votes []map[string]float64, a, b task.ModelResponse, params PreferenceParams) (*PreferencePairRecord, bool) {
+	var winsA, winsB, ties int
+	var sumDiff float64
+	for _, scores := range votes {
+		scoreA, okA := scores[a.Model]
+		scoreB, okB := scores[b.Model]
+		if !okA || !okB {
+			continue
+		}
+
+		diff := scoreA - scoreB
+		sumDiff += diff
+		switch {
+		case diff > 0:
+			winsA++
+		case diff < 0:
+			winsB++
+		default:
+			ties++
+		}
+	}
+
+	if winsA == winsB {
+		return nil, false
+	}
+
+	chosen, rejected := a, b
+	winnerVotes := winsA
+	if winsB > winsA {
+		chosen, rejected = b, a
+		winnerVotes = winsB
+		sumDiff = -sumDiff
+	}
+
+	var numVotes int
+	var agreement float64
+	switch params.TieHandling {
+	case TieHandlingSplit:
+		numVotes = winsA + winsB + ties
+		agreement = (float64(winnerVotes) + float64(ties)/2) / float64(numVotes)
+	default:
+		numVotes = winsA + winsB
+		agreement = float64(winnerVotes) / float64(numVotes)
+	}
+
+	if numVotes < params.MinVotes || agreement < params.MinAgreement {
+		return nil, false
+	}
+
+	return &PreferencePairRecord{
+		Chosen:        chosen.Completion,
+		Rejected:      rejected.Completion,
+		ChosenModel:   chosen.Model,
+		RejectedModel: rejected.Model,
+		Margin:        sumDiff / float64(numVotes),
+		NumVotes:      numVotes,
+		Agreement:     agreement,
+	}, true
+}
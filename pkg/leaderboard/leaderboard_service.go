@@ -0,0 +1,211 @@
+package leaderboard
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"dojo-api/pkg/cache"
+	"dojo-api/pkg/orm"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// how often the leaderboards are recomputed from the database
+const refreshInterval = 15 * time.Minute
+
+type LeaderboardService struct {
+	leaderboardORM *orm.LeaderboardORM
+}
+
+func NewLeaderboardService() *LeaderboardService {
+	return &LeaderboardService{
+		leaderboardORM: orm.NewLeaderboardORM(),
+	}
+}
+
+// Pseudonym is the stable name shown for a worker that did not opt in, derived from the internal worker ID. The
+// secret keeps anyone who learns worker IDs from matching them to their pseudonyms.
+func Pseudonym(workerId string) string {
+	mac := hmac.New(sha256.New, []byte(os.Getenv("LEADERBOARD_PSEUDONYM_SECRET")))
+	mac.Write([]byte(workerId))
+	return "worker-" + hex.EncodeToString(mac.Sum(nil))[:10]
+}
+
+func windowStart(window LeaderboardWindow, now time.Time) time.Time {
+	switch window {
+	case LeaderboardWindowDaily:
+		return now.Add(-24 * time.Hour)
+	case LeaderboardWindowWeekly:
+		return now.AddDate(0, 0, -7)
+	default:
+		return time.Unix(0, 0).UTC()
+	}
+}
+
+// leaderboardKeys returns the sorted set of scores, the hash of display names and the last update time of a leaderboard
+func leaderboardKeys(metric LeaderboardMetric, window LeaderboardWindow) (string, string, string) {
+	cache := cache.GetCacheInstance()
+	scoresKey := cache.BuildCacheKey(cache.Keys.Leaderboard, string(metric), string(window))
+	return scoresKey, scoresKey + ":names", scoresKey + ":updated_at"
+}
+
+// RefreshLeaderboards recomputes every leaderboard now and then periodically
+func (s *LeaderboardService) RefreshLeaderboards(ctx context.Context) {
+	s.refreshAll(ctx)
+	for range time.Tick(refreshInterval) {
+		s.refreshAll(ctx)
+	}
+}
+
+func (s *LeaderboardService) refreshAll(ctx context.Context) {
+	// every replica ticks, only the one holding the lock recomputes the boards
+	if !cache.GetCacheInstance().TryPeriodicLock(ctx, "leaderboard", refreshInterval) {
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, metric := range LeaderboardMetrics {
+		for _, window := range LeaderboardWindows {
+			if err := s.refresh(ctx, metric, window, now); err != nil {
+				log.Error().Err(err).Str("metric", string(metric)).Str("window", string(window)).Msg("Error refreshing leaderboard")
+			}
+		}
+	}
+	log.Info().Msg("Refreshed leaderboards")
+}
+
+func (s *LeaderboardService) getScores(ctx context.Context, metric LeaderboardMetric, since time.Time) ([]orm.LeaderboardRow, error) {
+	switch metric {
+	case LeaderboardMetricCompleted:
+		return s.leaderboardORM.GetCompletedScores(ctx, since)
+	case LeaderboardMetricQuality:
+		return s.leaderboardORM.GetQualityScores(ctx, since)
+	case LeaderboardMetricEarnings:
+		return s.leaderboardORM.GetEarningsScores(ctx, since)
+	default:
+		return nil, fmt.Errorf("unsupported leaderboard metric: %v", metric)
+	}
+}
+
+// refresh writes the leaderboard into temporary keys of this replica and swaps them in at once, so readers never see
+// a half written board
+func (s *LeaderboardService) refresh(ctx context.Context, metric LeaderboardMetric, window LeaderboardWindow, now time.Time) error {
+	rows, err := s.getScores(ctx, metric, windowStart(window, now))
+	if err != nil {
+		return err
+	}
+
+	scoresKey, namesKey, updatedAtKey := leaderboardKeys(metric, window)
+	tmpScoresKey, tmpNamesKey := scoresKey+":tmp:"+cache.InstanceID, namesKey+":tmp:"+cache.InstanceID
+	redisClient := cache.GetCacheInstance().Redis
+
+	if len(rows) > 0 {
+		members := make([]redis.Z, 0, len(rows))
+		names := make(map[string]interface{}, len(rows))
+		for _, row := range rows {
+			members = append(members, redis.Z{Score: float64(row.Score), Member: row.WorkerID})
+			name := Pseudonym(row.WorkerID)
+			if row.WalletAddress != nil {
+				name = *row.WalletAddress
+			}
+			names[row.WorkerID] = name
+		}
+
+		if _, err := redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, tmpScoresKey, tmpNamesKey)
+			pipe.ZAdd(ctx, tmpScoresKey, members...)
+			pipe.HSet(ctx, tmpNamesKey, names)
+			// left behind if the swap never happens
+			pipe.Expire(ctx, tmpScoresKey, refreshInterval)
+			pipe.Expire(ctx, tmpNamesKey, refreshInterval)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	_, err = redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if len(rows) > 0 {
+			pipe.Rename(ctx, tmpScoresKey, scoresKey)
+			pipe.Rename(ctx, tmpNamesKey, namesKey)
+			// the expiry moves with the rename
+			pipe.Persist(ctx, scoresKey)
+			pipe.Persist(ctx, namesKey)
+		} else {
+			pipe.Del(ctx, scoresKey, namesKey)
+		}
+		pipe.Set(ctx, updatedAtKey, now.Format(time.RFC3339), 0)
+		return nil
+	})
+	return err
+}
+
+// GetLeaderboard reads a page of the precomputed leaderboard, highest score first
+func (s *LeaderboardService) GetLeaderboard(ctx context.Context, metric LeaderboardMetric, window LeaderboardWindow, offset int, limit int) (*LeaderboardResponse, error) {
+	scoresKey, namesKey, updatedAtKey := leaderboardKeys(metric, window)
+	redisClient := cache.GetCacheInstance().Redis
+
+	response := &LeaderboardResponse{
+		Metric:  metric,
+		Window:  window,
+		Entries: make([]LeaderboardEntry, 0),
+	}
+
+	updatedAt, err := redisClient.Get(ctx, updatedAtKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return response, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Str("key", updatedAtKey).Msg("Error getting leaderboard update time")
+		return nil, err
+	}
+	if parsed, err := time.Parse(time.RFC3339, updatedAt); err == nil {
+		response.UpdatedAt = &parsed
+	}
+
+	response.TotalWorkers, err = redisClient.ZCard(ctx, scoresKey).Result()
+	if err != nil {
+		log.Error().Err(err).Str("key", scoresKey).Msg("Error getting leaderboard size")
+		return nil, err
+	}
+
+	members, err := redisClient.ZRevRangeWithScores(ctx, scoresKey, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		log.Error().Err(err).Str("key", scoresKey).Msg("Error getting leaderboard")
+		return nil, err
+	}
+	if len(members) == 0 {
+		return response, nil
+	}
+
+	workerIds := make([]string, 0, len(members))
+	for _, member := range members {
+		workerIds = append(workerIds, fmt.Sprint(member.Member))
+	}
+	names, err := redisClient.HMGet(ctx, namesKey, workerIds...).Result()
+	if err != nil {
+		log.Error().Err(err).Str("key", namesKey).Msg("Error getting leaderboard names")
+		return nil, err
+	}
+
+	for i, member := range members {
+		// the board could have been swapped between the two reads, fall back to the pseudonym
+		name, ok := names[i].(string)
+		if !ok {
+			name = Pseudonym(workerIds[i])
+		}
+		response.Entries = append(response.Entries, LeaderboardEntry{
+			Rank:   offset + i + 1,
+			Worker: name,
+			Score:  member.Score,
+		})
+	}
+	return response, nil
+}
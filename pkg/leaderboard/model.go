@@ -0,0 +1,47 @@
+package leaderboard
+
+import "time"
+
+type LeaderboardMetric string
+
+const (
+	LeaderboardMetricCompleted LeaderboardMetric = "completed"
+	LeaderboardMetricQuality   LeaderboardMetric = "quality"
+	LeaderboardMetricEarnings  LeaderboardMetric = "earnings"
+)
+
+var LeaderboardMetrics = []LeaderboardMetric{LeaderboardMetricCompleted, LeaderboardMetricQuality, LeaderboardMetricEarnings}
+
+type LeaderboardWindow string
+
+const (
+	LeaderboardWindowDaily   LeaderboardWindow = "daily"
+	LeaderboardWindowWeekly  LeaderboardWindow = "weekly"
+	LeaderboardWindowAllTime LeaderboardWindow = "all_time"
+)
+
+var LeaderboardWindows = []LeaderboardWindow{LeaderboardWindowDaily, LeaderboardWindowWeekly, LeaderboardWindowAllTime}
+
+type LeaderboardEntry struct {
+	Rank int `json:"rank"`
+	// the wallet address of workers that opted in, a stable pseudonym otherwise
+	Worker string  `json:"worker"`
+	Score  float64 `json:"score"`
+}
+
+type LeaderboardResponse struct {
+	Metric LeaderboardMetric `json:"metric"`
+	Window LeaderboardWindow `json:"window"`
+	// nil until the leaderboard was computed for the first time
+	UpdatedAt    *time.Time         `json:"updatedAt"`
+	TotalWorkers int64              `json:"totalWorkers"`
+	Entries      []LeaderboardEntry `json:"entries"`
+}
+
+type LeaderboardOptInRequest struct {
+	OptIn bool `json:"optIn"`
+}
+
+type LeaderboardOptInResponse struct {
+	OptIn bool `json:"optIn"`
+}
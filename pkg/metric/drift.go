@@ -0,0 +1,219 @@
+package metric
+
+import (
+	"context"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"dojo-api/db"
+	"dojo-api/pkg/cache"
+	"dojo-api/pkg/orm"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const driftCheckInterval = time.Hour
+
+// driftCheck is a metric recomputed from the source tables, cacheKey is set for the metrics counted in Redis
+type driftCheck struct {
+	metricType db.MetricsType
+	actual     int
+	cacheKey   string
+	data       MetricData
+}
+
+// CheckMetricDriftPeriodically recomputes the metrics from the source tables once an hour on one replica and logs any
+// drift, the drift is also fixed when METRICS_DRIFT_AUTOFIX is true
+func (metricService *MetricService) CheckMetricDriftPeriodically(ctx context.Context) {
+	fix, _ := strconv.ParseBool(os.Getenv("METRICS_DRIFT_AUTOFIX"))
+	for range time.Tick(driftCheckInterval) {
+		if !cache.GetCacheInstance().TryPeriodicLock(ctx, "metrics_drift", driftCheckInterval) {
+			continue
+		}
+		if _, err := metricService.CheckMetricDrift(ctx, fix); err != nil {
+			log.Error().Err(err).Msg("Failed to check metric drift")
+		}
+	}
+}
+
+// CheckMetricDrift compares every metric recomputed from the source tables against the Metrics table and the Redis
+// counters, and the completion time statistics against the completion events. With fix the Metrics rows and Redis
+// counters that drifted are overwritten with the recomputed values and drifted completion time statistics are
+// rebuilt from the events. Submissions landing while the check runs may be counted twice or not at all by a fix,
+// they are picked up by the next check.
+func (metricService *MetricService) CheckMetricDrift(ctx context.Context, fix bool) (*DriftReport, error) {
+	counts, err := metricService.metricORM.GetSourceCounts(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to recompute metrics from source tables")
+		return nil, err
+	}
+
+	numDojoWorkers, err := strconv.Atoi(string(counts.NumDojoWorkers))
+	if err != nil {
+		return nil, err
+	}
+	numCompletedTasks, err := strconv.Atoi(string(counts.NumCompletedTasks))
+	if err != nil {
+		return nil, err
+	}
+	numTaskResults, err := strconv.Atoi(string(counts.NumTaskResults))
+	if err != nil {
+		return nil, err
+	}
+	numCompletionEvents, err := strconv.Atoi(string(counts.NumCompletionEvents))
+	if err != nil {
+		return nil, err
+	}
+
+	redisCache := cache.GetCacheInstance()
+	checks := []driftCheck{
+		{db.MetricsTypeTotalNumDojoWorkers, numDojoWorkers, "", MetricWorkerCount{TotalNumDojoWorkers: numDojoWorkers}},
+		{db.MetricsTypeTotalNumCompletedTasks, numCompletedTasks, string(redisCache.Keys.CompletedTasksTotal), MetricCompletedTasksCount{TotalNumCompletedTasks: numCompletedTasks}},
+		{db.MetricsTypeTotalNumTaskResults, numTaskResults, string(redisCache.Keys.TaskResultsTotal), MetricTaskResultsCount{TotalNumTasksResults: numTaskResults}},
+	}
+	// without completion events there is no average to compare against
+	if numCompletionEvents > 0 {
+		avg := int(math.Round(float64(counts.AvgTaskCompletionTime)))
+		checks = append(checks, driftCheck{db.MetricsTypeAverageTaskCompletionTime, avg, "", MetricAvgTaskCompletionTime{AverageTaskCompletionTime: avg}})
+	}
+
+	report := &DriftReport{CheckedAt: time.Now().UTC(), Fixed: fix}
+
+	// the average completion time metric is rebuilt from these statistics on every completion, so they are
+	// repaired first
+	eventStats, err := metricService.statsORM.GetEventStats(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to recompute completion time statistics from events")
+		return nil, err
+	}
+	storedStats, err := metricService.statsORM.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.CompletionStats, err = compareCompletionStats(eventStats, storedStats)
+	if err != nil {
+		return nil, err
+	}
+	if fix && completionStatsDrifted(report.CompletionStats) {
+		if err := metricService.statsORM.RebuildFromEvents(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to rebuild completion time statistics")
+			return nil, err
+		}
+	}
+
+	for _, check := range checks {
+		drift := MetricDrift{Type: check.metricType, Actual: check.actual}
+
+		stored, err := metricService.metricORM.GetMetricsDataByMetricType(ctx, check.metricType)
+		if err != nil && !db.IsErrNotFound(err) {
+			return nil, err
+		}
+		if stored != nil {
+			value, err := snapshotValue(check.metricType, stored.MetricsData)
+			if err != nil {
+				return nil, err
+			}
+			storedValue := int(value)
+			drift.Stored = &storedValue
+		}
+
+		if check.cacheKey != "" {
+			cachedValue, err := redisCache.Redis.Get(ctx, check.cacheKey).Int()
+			if err != nil && err != redis.Nil {
+				return nil, err
+			}
+			if err == nil {
+				drift.Cached = &cachedValue
+			}
+		}
+		report.Metrics = append(report.Metrics, drift)
+
+		if !fix || !drift.HasDrift() {
+			continue
+		}
+		if err := metricService.metricORM.CreateNewMetric(ctx, check.metricType, check.data); err != nil {
+			log.Error().Err(err).Str("metricType", string(check.metricType)).Msg("Failed to fix metric")
+			return nil, err
+		}
+		if check.cacheKey != "" {
+			if err := redisCache.Redis.Set(ctx, check.cacheKey, check.actual, 0).Err(); err != nil {
+				log.Error().Err(err).Str("key", check.cacheKey).Msg("Failed to fix metric counter")
+				return nil, err
+			}
+		}
+	}
+
+	logDriftReport(report)
+	return report, nil
+}
+
+// compareCompletionStats pairs the statistics recomputed from the events with the stored ones by task type
+func compareCompletionStats(eventStats []orm.CompletionEventStatsRow, storedStats []db.TaskCompletionStatsModel) ([]CompletionStatsDrift, error) {
+	drifts := make(map[db.TaskType]*CompletionStatsDrift)
+	for _, row := range eventStats {
+		count, err := strconv.Atoi(string(row.Count))
+		if err != nil {
+			return nil, err
+		}
+		drifts[row.TaskType] = &CompletionStatsDrift{TaskType: row.TaskType, ActualCount: count, ActualSum: float64(row.Sum)}
+	}
+	for _, row := range storedStats {
+		drift, ok := drifts[row.TaskType]
+		if !ok {
+			drift = &CompletionStatsDrift{TaskType: row.TaskType}
+			drifts[row.TaskType] = drift
+		}
+		storedCount, storedSum := row.Count, row.Sum
+		drift.StoredCount = &storedCount
+		drift.StoredSum = &storedSum
+	}
+
+	result := make([]CompletionStatsDrift, 0, len(drifts))
+	for _, drift := range drifts {
+		result = append(result, *drift)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].TaskType < result[j].TaskType
+	})
+	return result, nil
+}
+
+func completionStatsDrifted(drifts []CompletionStatsDrift) bool {
+	for _, drift := range drifts {
+		if drift.HasDrift() {
+			return true
+		}
+	}
+	return false
+}
+
+func logDriftReport(report *DriftReport) {
+	var event *zerolog.Event
+	if report.HasDrift() {
+		event = log.Warn()
+	} else {
+		event = log.Info()
+	}
+
+	drifted := zerolog.Arr()
+	for _, drift := range report.Metrics {
+		if drift.HasDrift() {
+			drifted = drifted.Interface(drift)
+		}
+	}
+	for _, drift := range report.CompletionStats {
+		if drift.HasDrift() {
+			drifted = drifted.Interface(drift)
+		}
+	}
+	event.Str("event", "metrics_drift").
+		Bool("drift", report.HasDrift()).
+		Bool("fixed", report.Fixed && report.HasDrift()).
+		Array("metrics", drifted).
+		Msg("Checked metric drift")
+}
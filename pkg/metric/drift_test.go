@@ -0,0 +1,79 @@
+package metric
+
+import (
+	"testing"
+
+	"dojo-api/db"
+	"dojo-api/pkg/orm"
+)
+
+func storedCompletionStats(taskType db.TaskType, count int, sum float64) db.TaskCompletionStatsModel {
+	return db.TaskCompletionStatsModel{
+		InnerTaskCompletionStats: db.InnerTaskCompletionStats{TaskType: taskType, Count: count, Sum: sum},
+	}
+}
+
+func TestCompareCompletionStats(t *testing.T) {
+	tests := []struct {
+		name        string
+		eventStats  []orm.CompletionEventStatsRow
+		storedStats []db.TaskCompletionStatsModel
+		// whether the statistics of each task type drifted
+		want map[db.TaskType]bool
+	}{
+		{
+			name: "no events and no statistics",
+			want: map[db.TaskType]bool{},
+		},
+		{
+			name:        "matching statistics",
+			eventStats:  []orm.CompletionEventStatsRow{{TaskType: db.TaskTypeCodeGeneration, Count: "3", Sum: 90}},
+			storedStats: []db.TaskCompletionStatsModel{storedCompletionStats(db.TaskTypeCodeGeneration, 3, 90)},
+			want:        map[db.TaskType]bool{db.TaskTypeCodeGeneration: false},
+		},
+		{
+			name:        "missed completion",
+			eventStats:  []orm.CompletionEventStatsRow{{TaskType: db.TaskTypeCodeGeneration, Count: "4", Sum: 120}},
+			storedStats: []db.TaskCompletionStatsModel{storedCompletionStats(db.TaskTypeCodeGeneration, 3, 90)},
+			want:        map[db.TaskType]bool{db.TaskTypeCodeGeneration: true},
+		},
+		{
+			name:        "same count with another sum",
+			eventStats:  []orm.CompletionEventStatsRow{{TaskType: db.TaskTypeDialogue, Count: "2", Sum: 50}},
+			storedStats: []db.TaskCompletionStatsModel{storedCompletionStats(db.TaskTypeDialogue, 2, 40)},
+			want:        map[db.TaskType]bool{db.TaskTypeDialogue: true},
+		},
+		{
+			name:       "events without statistics",
+			eventStats: []orm.CompletionEventStatsRow{{TaskType: db.TaskTypeTextToImage, Count: "1", Sum: 10}},
+			want:       map[db.TaskType]bool{db.TaskTypeTextToImage: true},
+		},
+		{
+			name:        "statistics without events",
+			storedStats: []db.TaskCompletionStatsModel{storedCompletionStats(db.TaskTypeDialogue, 1, 10)},
+			want:        map[db.TaskType]bool{db.TaskTypeDialogue: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drifts, err := compareCompletionStats(tt.eventStats, tt.storedStats)
+			if err != nil {
+				t.Fatalf("compareCompletionStats() error = %v", err)
+			}
+			if len(drifts) != len(tt.want) {
+				t.Fatalf("compareCompletionStats() returned %d task types, want %d", len(drifts), len(tt.want))
+			}
+			for _, drift := range drifts {
+				want, ok := tt.want[drift.TaskType]
+				if !ok {
+					t.Errorf("unexpected task type %s", drift.TaskType)
+					continue
+				}
+				if drift.HasDrift() != want {
+					t.Errorf("drift of %s = %v, want %v (%+v)", drift.TaskType, drift.HasDrift(), want, drift)
+				}
+			}
+		})
+	}
+}
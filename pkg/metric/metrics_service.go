@@ -3,6 +3,7 @@ package metric
 import (
 	"context"
 	"encoding/json"
+	"math"
 
 	"dojo-api/db"
 	"dojo-api/pkg/cache"
@@ -15,11 +16,13 @@ import (
 
 type MetricService struct {
 	metricORM *orm.MetricsORM
+	statsORM  *orm.TaskCompletionStatsORM
 }
 
 func NewMetricService() *MetricService {
 	return &MetricService{
 		metricORM: orm.NewMetricsORM(),
+		statsORM:  orm.NewTaskCompletionStatsORM(),
 	}
 }
 
@@ -136,46 +139,67 @@ func (metricService *MetricService) UpdateTotalTaskResultsCount(ctx context.Cont
 	return metricORM.CreateNewMetric(ctx, db.MetricsTypeTotalNumTaskResults, newMetricData)
 }
 
-func (metricService *MetricService) UpdateAvgTaskCompletionTime(ctx context.Context) error {
-	eventORM := orm.NewEventsORM()
-	metricORM := orm.NewMetricsORM()
-
-	events, err := eventORM.GetEventsByType(ctx, db.EventsTypeTaskCompletionTime)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to get task completion time events")
+// RecordTaskCompletionTime adds a completed task to the running completion time statistics of its task type and
+// refreshes the average task completion time metric from them
+func (metricService *MetricService) RecordTaskCompletionTime(ctx context.Context, eventData event.EventTaskCompletionTime) error {
+	seconds := float64(eventData.TaskCompletionTime)
+	if err := metricService.statsORM.RecordCompletionTime(ctx, eventData.TaskType, seconds, SketchBucket(seconds)); err != nil {
+		log.Error().Err(err).Str("taskId", eventData.TaskId).Msg("Failed to record task completion time")
 		return err
 	}
 
-	totalCompletionTime, err := CalculateTotalTaskCompletionTime(events)
+	stats, err := metricService.GetTaskCompletionTimeStats(ctx)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to calculate total task completion time")
 		return err
 	}
+	if stats.Overall.Mean == nil {
+		return nil
+	}
 
-	avgCompletionTime := *totalCompletionTime / len(events)
-	newMetricData := MetricAvgTaskCompletionTime{AverageTaskCompletionTime: avgCompletionTime}
+	newMetricData := MetricAvgTaskCompletionTime{AverageTaskCompletionTime: int(math.Round(*stats.Overall.Mean))}
 	log.Info().Interface("AvgTaskCompletionTime", newMetricData).Msg("Updating average task completion time metric")
 
-	err = metricORM.CreateNewMetric(ctx, db.MetricsTypeAverageTaskCompletionTime, newMetricData)
-	return err
+	return metricService.metricORM.CreateNewMetric(ctx, db.MetricsTypeAverageTaskCompletionTime, newMetricData)
 }
 
-func CalculateTotalTaskCompletionTime(events []db.EventsModel) (*int, error) {
-	var totalCompletionTime int
-
-	for _, e := range events {
-
-		if e.Type != db.EventsTypeTaskCompletionTime {
-			continue
-		}
+// GetTaskCompletionTimeStats returns the completion time statistics per task type and over all task types,
+// the overall statistics are the per type sketches merged
+func (metricService *MetricService) GetTaskCompletionTimeStats(ctx context.Context) (*TaskCompletionTimeResponse, error) {
+	rows, err := metricService.statsORM.GetAll(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get task completion time statistics")
+		return nil, err
+	}
 
-		eventData := event.EventTaskCompletionTime{}
-		err := json.Unmarshal(e.EventsData, &eventData)
-		if err != nil {
+	overall := QuantileSketch{}
+	byTaskType := make(map[db.TaskType]CompletionTimeStats, len(rows))
+	for _, row := range rows {
+		sketch := QuantileSketch{Count: row.Count, Sum: row.Sum, Min: row.Min, Max: row.Max}
+		if err := json.Unmarshal(row.Buckets, &sketch.Buckets); err != nil {
+			log.Error().Err(err).Str("taskType", string(row.TaskType)).Msg("Failed to unmarshal completion time sketch")
 			return nil, err
 		}
+		byTaskType[row.TaskType] = buildCompletionTimeStats(&sketch)
+		overall.Merge(sketch)
+	}
 
-		totalCompletionTime += eventData.TaskCompletionTime
+	return &TaskCompletionTimeResponse{
+		Overall:    buildCompletionTimeStats(&overall),
+		ByTaskType: byTaskType,
+	}, nil
+}
+
+func buildCompletionTimeStats(sketch *QuantileSketch) CompletionTimeStats {
+	stats := CompletionTimeStats{
+		Count: sketch.Count,
+		Mean:  sketch.Mean(),
+		P50:   sketch.Quantile(0.5),
+		P90:   sketch.Quantile(0.9),
+		P99:   sketch.Quantile(0.99),
+	}
+	if sketch.Count > 0 {
+		stats.Min = &sketch.Min
+		stats.Max = &sketch.Max
 	}
-	return &totalCompletionTime, nil
+	return stats
 }
@@ -1,5 +1,12 @@
 package metric
 
+import (
+	"math"
+	"time"
+
+	"dojo-api/db"
+)
+
 type DojoWorkerCountResponse struct {
 	NumDojoWorkers int `json:"numDojoWorkers"`
 }
@@ -16,6 +23,23 @@ type AvgTaskCompletionTimeResponse struct {
 	AvgTaskCompletionTime int `json:"averageTaskCompletionTime"`
 }
 
+// CompletionTimeStats are in seconds, every value is nil when no task completed yet. Percentiles are estimates
+// within 1% of the true value.
+type CompletionTimeStats struct {
+	Count int      `json:"count"`
+	Mean  *float64 `json:"mean"`
+	P50   *float64 `json:"p50"`
+	P90   *float64 `json:"p90"`
+	P99   *float64 `json:"p99"`
+	Min   *float64 `json:"min"`
+	Max   *float64 `json:"max"`
+}
+
+type TaskCompletionTimeResponse struct {
+	Overall    CompletionTimeStats                 `json:"overall"`
+	ByTaskType map[db.TaskType]CompletionTimeStats `json:"byTaskType"`
+}
+
 type MetricData interface{}
 
 type MetricWorkerCount struct {
@@ -33,3 +57,84 @@ type MetricTaskResultsCount struct {
 type MetricAvgTaskCompletionTime struct {
 	AverageTaskCompletionTime int `json:"average_task_completion_time"`
 }
+
+type SeriesInterval string
+
+const (
+	SeriesIntervalHour  SeriesInterval = "hour"
+	SeriesIntervalDay   SeriesInterval = "day"
+	SeriesIntervalWeek  SeriesInterval = "week"
+	SeriesIntervalMonth SeriesInterval = "month"
+)
+
+type SeriesParams struct {
+	Interval SeriesInterval
+	From     time.Time
+	To       time.Time
+}
+
+// SeriesPoint is the value of a metric at the end of a bucket, nil when the metric had no value yet
+type SeriesPoint struct {
+	BucketStart time.Time `json:"bucketStart"`
+	Value       *float64  `json:"value"`
+}
+
+type MetricSeriesResponse struct {
+	Interval              SeriesInterval `json:"interval"`
+	From                  time.Time      `json:"from"`
+	To                    time.Time      `json:"to"`
+	NumDojoWorkers        []SeriesPoint  `json:"numDojoWorkers"`
+	NumCompletedTasks     []SeriesPoint  `json:"numCompletedTasks"`
+	NumTaskResults        []SeriesPoint  `json:"numTaskResults"`
+	AvgTaskCompletionTime []SeriesPoint  `json:"averageTaskCompletionTime"`
+}
+
+// MetricDrift is a metric recomputed from the source tables next to the value in the Metrics table and, for the
+// counters kept in Redis, the cached value. Stored and Cached are nil when there is no value.
+type MetricDrift struct {
+	Type   db.MetricsType `json:"type"`
+	Actual int            `json:"actual"`
+	Stored *int           `json:"stored"`
+	Cached *int           `json:"cached"`
+}
+
+func (d MetricDrift) HasDrift() bool {
+	return d.Stored == nil || *d.Stored != d.Actual || (d.Cached != nil && *d.Cached != d.Actual)
+}
+
+// CompletionStatsDrift is the number and sum of a task type's completion times recomputed from the completion
+// events next to the running statistics in TaskCompletionStats, Stored values are nil when the task type has none
+type CompletionStatsDrift struct {
+	TaskType    db.TaskType `json:"taskType"`
+	ActualCount int         `json:"actualCount"`
+	StoredCount *int        `json:"storedCount"`
+	ActualSum   float64     `json:"actualSum"`
+	StoredSum   *float64    `json:"storedSum"`
+}
+
+// sums are compared with a tolerance since the database adds the completion times up in another order
+func (d CompletionStatsDrift) HasDrift() bool {
+	return d.StoredCount == nil || *d.StoredCount != d.ActualCount ||
+		d.StoredSum == nil || math.Abs(*d.StoredSum-d.ActualSum) > 1e-6*math.Max(1, math.Abs(d.ActualSum))
+}
+
+type DriftReport struct {
+	CheckedAt       time.Time              `json:"checkedAt"`
+	Fixed           bool                   `json:"fixed"`
+	Metrics         []MetricDrift          `json:"metrics"`
+	CompletionStats []CompletionStatsDrift `json:"completionStats"`
+}
+
+func (r DriftReport) HasDrift() bool {
+	for _, drift := range r.Metrics {
+		if drift.HasDrift() {
+			return true
+		}
+	}
+	for _, drift := range r.CompletionStats {
+		if drift.HasDrift() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package metric
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"dojo-api/db"
+	"dojo-api/pkg/cache"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxSeriesBuckets keeps a single request from asking for e.g. years of hourly points
+const maxSeriesBuckets = 2000
+
+var ErrTooManyBuckets = fmt.Errorf("range covers more than %d buckets, use a larger interval or a shorter range", maxSeriesBuckets)
+
+// SnapshotMetricsHourly records every metric into its history once an hour on one replica, metrics are also recorded
+// whenever they change so this only fills the hours without activity
+func (metricService *MetricService) SnapshotMetricsHourly(ctx context.Context) {
+	for range time.Tick(time.Hour) {
+		if !cache.GetCacheInstance().TryPeriodicLock(ctx, "metrics_snapshot", time.Hour) {
+			continue
+		}
+		numSnapshots, err := metricService.metricORM.SnapshotAllMetrics(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to snapshot metrics")
+			continue
+		}
+		log.Info().Int("numSnapshots", numSnapshots).Msg("Snapshotted metrics")
+	}
+}
+
+// GetMetricSeries returns the value of every public metric at the end of each bucket in the range, buckets without
+// a snapshot carry the previous value forward since the metrics only change when there is activity
+func (metricService *MetricService) GetMetricSeries(ctx context.Context, params SeriesParams) (*MetricSeriesResponse, error) {
+	bucketStarts, err := seriesBuckets(params)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := metricService.metricORM.GetLatestSnapshotsBefore(ctx, bucketStarts[0])
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get metric snapshots before the range")
+		return nil, err
+	}
+	rows, err := metricService.metricORM.GetSnapshotSeries(ctx, string(params.Interval), bucketStarts[0], params.To)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get metric snapshots")
+		return nil, err
+	}
+
+	lastValues := make(map[db.MetricsType]*float64)
+	for _, row := range previous {
+		value, err := snapshotValue(row.Type, row.MetricsData)
+		if err != nil {
+			return nil, err
+		}
+		lastValues[row.Type] = &value
+	}
+
+	valuesByBucket := make(map[db.MetricsType]map[time.Time]float64)
+	for _, row := range rows {
+		value, err := snapshotValue(row.Type, row.MetricsData)
+		if err != nil {
+			return nil, err
+		}
+		if valuesByBucket[row.Type] == nil {
+			valuesByBucket[row.Type] = make(map[time.Time]float64)
+		}
+		valuesByBucket[row.Type][row.BucketStart.Time.UTC()] = value
+	}
+
+	buildSeries := func(metricType db.MetricsType) []SeriesPoint {
+		points := make([]SeriesPoint, 0, len(bucketStarts))
+		last := lastValues[metricType]
+		for _, bucketStart := range bucketStarts {
+			if value, ok := valuesByBucket[metricType][bucketStart]; ok {
+				last = &value
+			}
+			points = append(points, SeriesPoint{BucketStart: bucketStart, Value: last})
+		}
+		return points
+	}
+
+	return &MetricSeriesResponse{
+		Interval:              params.Interval,
+		From:                  params.From,
+		To:                    params.To,
+		NumDojoWorkers:        buildSeries(db.MetricsTypeTotalNumDojoWorkers),
+		NumCompletedTasks:     buildSeries(db.MetricsTypeTotalNumCompletedTasks),
+		NumTaskResults:        buildSeries(db.MetricsTypeTotalNumTaskResults),
+		AvgTaskCompletionTime: buildSeries(db.MetricsTypeAverageTaskCompletionTime),
+	}, nil
+}
+
+// seriesBuckets lists the start of every bucket overlapping [from, to), truncated the way postgres date_trunc does
+func seriesBuckets(params SeriesParams) ([]time.Time, error) {
+	bucketStarts := make([]time.Time, 0)
+	for bucketStart := truncateToInterval(params.From.UTC(), params.Interval); bucketStart.Before(params.To); bucketStart = nextBucket(bucketStart, params.Interval) {
+		if len(bucketStarts) == maxSeriesBuckets {
+			return nil, ErrTooManyBuckets
+		}
+		bucketStarts = append(bucketStarts, bucketStart)
+	}
+	if len(bucketStarts) == 0 {
+		return nil, errors.New("from must be before to")
+	}
+	return bucketStarts, nil
+}
+
+func truncateToInterval(t time.Time, interval SeriesInterval) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch interval {
+	case SeriesIntervalHour:
+		return t.Truncate(time.Hour)
+	case SeriesIntervalWeek:
+		// weeks start on monday
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case SeriesIntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+func nextBucket(t time.Time, interval SeriesInterval) time.Time {
+	switch interval {
+	case SeriesIntervalHour:
+		return t.Add(time.Hour)
+	case SeriesIntervalWeek:
+		return t.AddDate(0, 0, 7)
+	case SeriesIntervalMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// snapshotValue reads the number out of a metric's data, every metric stores a single number under its own key
+func snapshotValue(metricType db.MetricsType, data json.RawMessage) (float64, error) {
+	switch metricType {
+	case db.MetricsTypeTotalNumDojoWorkers:
+		var metricData MetricWorkerCount
+		err := json.Unmarshal(data, &metricData)
+		return float64(metricData.TotalNumDojoWorkers), err
+	case db.MetricsTypeTotalNumCompletedTasks:
+		var metricData MetricCompletedTasksCount
+		err := json.Unmarshal(data, &metricData)
+		return float64(metricData.TotalNumCompletedTasks), err
+	case db.MetricsTypeTotalNumTaskResults:
+		var metricData MetricTaskResultsCount
+		err := json.Unmarshal(data, &metricData)
+		return float64(metricData.TotalNumTasksResults), err
+	case db.MetricsTypeAverageTaskCompletionTime:
+		var metricData MetricAvgTaskCompletionTime
+		err := json.Unmarshal(data, &metricData)
+		return float64(metricData.AverageTaskCompletionTime), err
+	default:
+		return 0, fmt.Errorf("unsupported metric type: %v", metricType)
+	}
+}
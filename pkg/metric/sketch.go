@@ -0,0 +1,103 @@
+package metric
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// sketchRelativeAccuracy bounds the error of every quantile to 1% of the true value
+const sketchRelativeAccuracy = 0.01
+
+// sketchZeroBucket counts values that are 0, they have no logarithmic bucket
+const sketchZeroBucket = "zero"
+
+var sketchGamma = (1 + sketchRelativeAccuracy) / (1 - sketchRelativeAccuracy)
+
+// QuantileSketch is a logarithmic histogram, a value x > 0 is counted in bucket ceil(log_gamma(x)). Buckets only
+// hold counts, so two sketches merge by adding them up and a value is added by incrementing one bucket, which the
+// database can do in place. Buckets are keyed by their index as a string to store them as a JSON object.
+type QuantileSketch struct {
+	Count   int
+	Sum     float64
+	Min     float64
+	Max     float64
+	Buckets map[string]int
+}
+
+// SketchBucket returns the key of the bucket a value is counted in
+func SketchBucket(value float64) string {
+	if value <= 0 {
+		return sketchZeroBucket
+	}
+	return strconv.Itoa(int(math.Ceil(math.Log(value) / math.Log(sketchGamma))))
+}
+
+func (s *QuantileSketch) Merge(other QuantileSketch) {
+	if other.Count == 0 {
+		return
+	}
+	if s.Count == 0 || other.Min < s.Min {
+		s.Min = other.Min
+	}
+	if s.Count == 0 || other.Max > s.Max {
+		s.Max = other.Max
+	}
+	s.Count += other.Count
+	s.Sum += other.Sum
+	if s.Buckets == nil {
+		s.Buckets = make(map[string]int, len(other.Buckets))
+	}
+	for bucket, count := range other.Buckets {
+		s.Buckets[bucket] += count
+	}
+}
+
+func (s *QuantileSketch) Mean() *float64 {
+	if s.Count == 0 {
+		return nil
+	}
+	mean := s.Sum / float64(s.Count)
+	return &mean
+}
+
+// Quantile estimates the q-quantile, q in [0, 1], nil when the sketch is empty
+func (s *QuantileSketch) Quantile(q float64) *float64 {
+	if s.Count == 0 {
+		return nil
+	}
+
+	indexes := make([]int, 0, len(s.Buckets))
+	zeroCount := 0
+	for bucket, count := range s.Buckets {
+		if bucket == sketchZeroBucket {
+			zeroCount += count
+			continue
+		}
+		index, err := strconv.Atoi(bucket)
+		if err != nil {
+			continue
+		}
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	rank := q * float64(s.Count-1)
+	estimate := s.Max
+	cumulative := float64(zeroCount)
+	if cumulative > rank {
+		estimate = 0
+	} else {
+		for _, index := range indexes {
+			cumulative += float64(s.Buckets[strconv.Itoa(index)])
+			if cumulative > rank {
+				// midpoint of the bucket in relative terms, within the relative accuracy of any value in it
+				estimate = 2 * math.Pow(sketchGamma, float64(index)) / (sketchGamma + 1)
+				break
+			}
+		}
+	}
+
+	estimate = math.Max(s.Min, math.Min(s.Max, estimate))
+	return &estimate
+}
@@ -0,0 +1,141 @@
+package metric
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// newSketch counts the values the way the database does, one bucket increment per value
+func newSketch(values ...float64) QuantileSketch {
+	sketch := QuantileSketch{Buckets: make(map[string]int)}
+	for _, value := range values {
+		if sketch.Count == 0 || value < sketch.Min {
+			sketch.Min = value
+		}
+		if sketch.Count == 0 || value > sketch.Max {
+			sketch.Max = value
+		}
+		sketch.Count++
+		sketch.Sum += value
+		sketch.Buckets[SketchBucket(value)]++
+	}
+	return sketch
+}
+
+func rangeValues(from, to int) []float64 {
+	values := make([]float64, 0, to-from+1)
+	for i := from; i <= to; i++ {
+		values = append(values, float64(i))
+	}
+	return values
+}
+
+// exactQuantile is the value the sketch estimates, the value at rank q * (n - 1) rounded down
+func exactQuantile(values []float64, q float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return sorted[int(q*float64(len(sorted)-1))]
+}
+
+func TestSketchBucket(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  string
+	}{
+		{0, sketchZeroBucket},
+		{-2.5, sketchZeroBucket},
+		{1, "0"},
+	}
+	for _, tt := range tests {
+		if got := SketchBucket(tt.value); got != tt.want {
+			t.Errorf("SketchBucket(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+
+	// every positive value falls in (gamma^(i-1), gamma^i]
+	for _, value := range []float64{0.001, 0.5, 1.5, 2, 10, 59.9, 1234.5, 1e9} {
+		index, err := strconv.Atoi(SketchBucket(value))
+		if err != nil {
+			t.Fatalf("SketchBucket(%v) is not an index: %v", value, err)
+		}
+		lower, upper := math.Pow(sketchGamma, float64(index-1)), math.Pow(sketchGamma, float64(index))
+		if value <= lower*(1-1e-12) || value > upper*(1+1e-12) {
+			t.Errorf("SketchBucket(%v) = %d, bucket covers (%v, %v]", value, index, lower, upper)
+		}
+	}
+}
+
+func TestQuantileSketchQuantile(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		qs     []float64
+	}{
+		{"single value", []float64{42}, []float64{0, 0.5, 1}},
+		{"uniform range", rangeValues(1, 100), []float64{0, 0.1, 0.5, 0.9, 0.99, 1}},
+		{"wide range", []float64{0.01, 0.5, 3, 80, 2500, 90000}, []float64{0, 0.2, 0.5, 0.8, 1}},
+		{"repeated values", []float64{5, 5, 5, 5, 7}, []float64{0, 0.5, 0.75, 1}},
+		{"zeros", []float64{0, 0, 0, 4, 8}, []float64{0, 0.5, 0.75, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sketch := newSketch(tt.values...)
+			for _, q := range tt.qs {
+				got := sketch.Quantile(q)
+				if got == nil {
+					t.Fatalf("Quantile(%v) = nil", q)
+				}
+				want := exactQuantile(tt.values, q)
+				if math.Abs(*got-want) > sketchRelativeAccuracy*want+1e-9 {
+					t.Errorf("Quantile(%v) = %v, want %v within %v%%", q, *got, want, sketchRelativeAccuracy*100)
+				}
+			}
+		})
+	}
+}
+
+func TestQuantileSketchEmpty(t *testing.T) {
+	sketch := QuantileSketch{}
+	if got := sketch.Quantile(0.5); got != nil {
+		t.Errorf("Quantile(0.5) of an empty sketch = %v, want nil", *got)
+	}
+	if got := sketch.Mean(); got != nil {
+		t.Errorf("Mean() of an empty sketch = %v, want nil", *got)
+	}
+}
+
+func TestQuantileSketchMerge(t *testing.T) {
+	tests := []struct {
+		name        string
+		left, right []float64
+	}{
+		{"both filled", rangeValues(1, 50), rangeValues(40, 120)},
+		{"into empty", nil, []float64{3, 1, 2}},
+		{"empty into filled", []float64{3, 1, 2}, nil},
+		{"lower and higher", []float64{100, 200}, []float64{0, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := newSketch(tt.left...)
+			merged.Merge(newSketch(tt.right...))
+			want := newSketch(append(append([]float64(nil), tt.left...), tt.right...)...)
+
+			if merged.Count != want.Count || merged.Sum != want.Sum || merged.Min != want.Min || merged.Max != want.Max {
+				t.Errorf("Merge() = count %d sum %v min %v max %v, want count %d sum %v min %v max %v",
+					merged.Count, merged.Sum, merged.Min, merged.Max, want.Count, want.Sum, want.Min, want.Max)
+			}
+			if len(merged.Buckets) != len(want.Buckets) {
+				t.Fatalf("Merge() has %d buckets, want %d", len(merged.Buckets), len(want.Buckets))
+			}
+			for bucket, count := range want.Buckets {
+				if merged.Buckets[bucket] != count {
+					t.Errorf("bucket %s = %d, want %d", bucket, merged.Buckets[bucket], count)
+				}
+			}
+		})
+	}
+}
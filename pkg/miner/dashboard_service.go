@@ -0,0 +1,148 @@
+package miner
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"dojo-api/db"
+	"dojo-api/pkg/orm"
+
+	"github.com/rs/zerolog/log"
+)
+
+type DashboardService struct {
+	dashboardORM *orm.MinerDashboardORM
+}
+
+func NewDashboardService() *DashboardService {
+	return &DashboardService{
+		dashboardORM: orm.NewMinerDashboardORM(),
+	}
+}
+
+// GetDashboard computes the miner's dashboard over the range, in total and per time bucket
+func (s *DashboardService) GetDashboard(ctx context.Context, minerUserId string, params DashboardParams) (*MinerDashboardResponse, error) {
+	interval := string(params.Interval)
+	logger := log.With().Str("minerUserId", minerUserId).Logger()
+
+	taskRows, err := s.dashboardORM.GetTaskBuckets(ctx, minerUserId, interval, params.From, params.To)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error getting dashboard task buckets")
+		return nil, err
+	}
+	completionRows, err := s.dashboardORM.GetCompletionBuckets(ctx, minerUserId, interval, params.From, params.To)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error getting dashboard completion buckets")
+		return nil, err
+	}
+	resultRows, err := s.dashboardORM.GetResultBuckets(ctx, minerUserId, interval, params.From, params.To)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error getting dashboard result buckets")
+		return nil, err
+	}
+	partnerRows, err := s.dashboardORM.GetActivePartnerBuckets(ctx, minerUserId, interval, params.From, params.To)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error getting dashboard partner buckets")
+		return nil, err
+	}
+	totalsRow, err := s.dashboardORM.GetTotals(ctx, minerUserId, params.From, params.To)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error getting dashboard totals")
+		return nil, err
+	}
+
+	// the queries only return buckets that have data, merge them by bucket start
+	buckets := make(map[time.Time]*DashboardBucket)
+	bucketAt := func(start time.Time) *DashboardBucket {
+		bucket, ok := buckets[start]
+		if !ok {
+			bucket = &DashboardBucket{
+				BucketStart:    start,
+				DashboardStats: DashboardStats{ResultsByType: make(map[db.TaskType]int)},
+			}
+			buckets[start] = bucket
+		}
+		return bucket
+	}
+
+	totals := DashboardStats{ResultsByType: make(map[db.TaskType]int)}
+	for _, row := range taskRows {
+		bucket := bucketAt(row.BucketStart.Time)
+		if bucket.TasksCreated, err = strconv.Atoi(string(row.TasksCreated)); err != nil {
+			return nil, err
+		}
+		if bucket.TasksCompleted, err = strconv.Atoi(string(row.TasksCompleted)); err != nil {
+			return nil, err
+		}
+		if bucket.ExpiredWithoutResults, err = strconv.Atoi(string(row.ExpiredWithoutResults)); err != nil {
+			return nil, err
+		}
+		bucket.CompletionRate = completionRate(bucket.TasksCompleted, bucket.TasksCreated)
+
+		totals.TasksCreated += bucket.TasksCreated
+		totals.TasksCompleted += bucket.TasksCompleted
+		totals.ExpiredWithoutResults += bucket.ExpiredWithoutResults
+	}
+	totals.CompletionRate = completionRate(totals.TasksCompleted, totals.TasksCreated)
+
+	for _, row := range completionRows {
+		if row.MedianCompletionTime != nil {
+			median := float64(*row.MedianCompletionTime)
+			bucketAt(row.BucketStart.Time).MedianCompletionSeconds = &median
+		}
+	}
+	if totalsRow.MedianCompletionTime != nil {
+		median := float64(*totalsRow.MedianCompletionTime)
+		totals.MedianCompletionSeconds = &median
+	}
+
+	for _, row := range resultRows {
+		numResults, err := strconv.Atoi(string(row.NumResults))
+		if err != nil {
+			return nil, err
+		}
+		bucketAt(row.BucketStart.Time).ResultsByType[row.TaskType] += numResults
+		totals.ResultsByType[row.TaskType] += numResults
+	}
+
+	for _, row := range partnerRows {
+		bucket := bucketAt(row.BucketStart.Time)
+		if bucket.ActivePartneredWorkers, err = strconv.Atoi(string(row.ActivePartneredWorkers)); err != nil {
+			return nil, err
+		}
+	}
+	if totals.ActivePartneredWorkers, err = strconv.Atoi(string(totalsRow.ActivePartneredWorkers)); err != nil {
+		return nil, err
+	}
+	partneredWorkers, err := strconv.Atoi(string(totalsRow.PartneredWorkers))
+	if err != nil {
+		return nil, err
+	}
+
+	series := make([]DashboardBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		series = append(series, *bucket)
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].BucketStart.Before(series[j].BucketStart)
+	})
+
+	return &MinerDashboardResponse{
+		Interval:         params.Interval,
+		From:             params.From,
+		To:               params.To,
+		Totals:           totals,
+		PartneredWorkers: partneredWorkers,
+		Series:           series,
+	}, nil
+}
+
+func completionRate(completed int, created int) *float64 {
+	if created == 0 {
+		return nil
+	}
+	rate := float64(completed) / float64(created)
+	return &rate
+}
@@ -1,5 +1,11 @@
 package miner
 
+import (
+	"time"
+
+	"dojo-api/db"
+)
+
 type MinerApplicationRequest struct {
 	Hotkey           string `json:"hotkey"`
 	Email            string `json:"email"`
@@ -26,3 +32,44 @@ type MinerSubscriptionKeysResponse struct {
 type MinerSubscriptionDisableRequest struct {
 	SubscriptionKey string `json:"subscriptionKey"`
 }
+
+type DashboardInterval string
+
+const (
+	DashboardIntervalDay   DashboardInterval = "day"
+	DashboardIntervalWeek  DashboardInterval = "week"
+	DashboardIntervalMonth DashboardInterval = "month"
+)
+
+type DashboardParams struct {
+	Interval DashboardInterval
+	From     time.Time
+	To       time.Time
+}
+
+// DashboardStats are the miner's figures over a bucket or the whole range, task figures count tasks created in it
+// and result figures count results submitted in it. Rates and medians are nil when there is nothing to compute them from.
+type DashboardStats struct {
+	TasksCreated            int                 `json:"tasksCreated"`
+	TasksCompleted          int                 `json:"tasksCompleted"`
+	CompletionRate          *float64            `json:"completionRate"`
+	MedianCompletionSeconds *float64            `json:"medianCompletionSeconds"`
+	ExpiredWithoutResults   int                 `json:"expiredWithoutResults"`
+	ResultsByType           map[db.TaskType]int `json:"resultsByType"`
+	ActivePartneredWorkers  int                 `json:"activePartneredWorkers"`
+}
+
+type DashboardBucket struct {
+	BucketStart time.Time `json:"bucketStart"`
+	DashboardStats
+}
+
+type MinerDashboardResponse struct {
+	Interval DashboardInterval `json:"interval"`
+	From     time.Time         `json:"from"`
+	To       time.Time         `json:"to"`
+	Totals   DashboardStats    `json:"totals"`
+	// workers currently partnered with any of the miner's subscription keys, active or not
+	PartneredWorkers int               `json:"partneredWorkers"`
+	Series           []DashboardBucket `json:"series"`
+}
@@ -0,0 +1,83 @@
+package monitoring
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// All collectors are registered on the default registry, which promhttp.Handler() serves together with the Go
+// runtime and process metrics.
+var (
+	HTTPRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dojo_http_requests_total",
+		Help: "Number of HTTP requests by route, method and status.",
+	}, []string{"route", "method", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dojo_http_request_duration_seconds",
+		Help:    "Latency of HTTP requests by route, method and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	DBQueriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dojo_db_queries_total",
+		Help: "Number of database queries by ORM operation.",
+	}, []string{"operation"})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dojo_db_query_duration_seconds",
+		Help:    "Duration of database queries by ORM operation.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	DBActiveQueries = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "dojo_db_active_queries",
+		Help: "Number of database queries in flight.",
+	})
+
+	// the hit ratio is rate(dojo_cache_requests_total{result="hit"}) / rate(dojo_cache_requests_total)
+	CacheRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dojo_cache_requests_total",
+		Help: "Number of cache lookups by result, hit or miss.",
+	}, []string{"result"})
+
+	SubstrateRequestRetriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dojo_substrate_request_retries_total",
+		Help: "Number of retried substrate storage requests by storage item.",
+	}, []string{"item"})
+
+	SubstrateRequestFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dojo_substrate_request_failures_total",
+		Help: "Number of substrate storage requests that failed after all retries by storage item.",
+	}, []string{"item"})
+
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "dojo_subnet_state_refresh_age_seconds",
+		Help: "Seconds since the subnet state was last refreshed successfully, NaN before the first refresh.",
+	}, subnetStateRefreshAge)
+)
+
+const (
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+// unix nanoseconds of the last successful subnet state refresh, 0 before the first one
+var subnetStateRefreshedAt atomic.Int64
+
+// SubnetStateRefreshed records a successful refresh of the subnet state
+func SubnetStateRefreshed() {
+	subnetStateRefreshedAt.Store(time.Now().UnixNano())
+}
+
+func subnetStateRefreshAge() float64 {
+	refreshedAt := subnetStateRefreshedAt.Load()
+	if refreshedAt == 0 {
+		return math.NaN()
+	}
+	return time.Since(time.Unix(0, refreshedAt)).Seconds()
+}
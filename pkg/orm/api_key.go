@@ -20,8 +20,8 @@ func NewApiKeyORM() *ApiKeyORM {
 }
 
 func (a *ApiKeyORM) GetApiKeysByMinerHotkey(hotkey string) ([]db.APIKeyModel, error) {
-	a.clientWrapper.BeforeQuery()
-	defer a.clientWrapper.AfterQuery()
+	queryTimer := a.clientWrapper.BeforeQuery()
+	defer a.clientWrapper.AfterQuery(queryTimer)
 
 	ctx := context.Background()
 
@@ -44,8 +44,8 @@ func (a *ApiKeyORM) GetApiKeysByMinerHotkey(hotkey string) ([]db.APIKeyModel, er
 }
 
 func (a *ApiKeyORM) CreateApiKeyByHotkey(hotkey string, apiKey string) (*db.APIKeyModel, error) {
-	a.clientWrapper.BeforeQuery()
-	defer a.clientWrapper.AfterQuery()
+	queryTimer := a.clientWrapper.BeforeQuery()
+	defer a.clientWrapper.AfterQuery(queryTimer)
 
 	ctx := context.Background()
 
@@ -70,8 +70,8 @@ func (a *ApiKeyORM) CreateApiKeyByHotkey(hotkey string, apiKey string) (*db.APIK
 }
 
 func (a *ApiKeyORM) DisableApiKeyByHotkey(hotkey string, apiKey string) (*db.APIKeyModel, error) {
-	a.clientWrapper.BeforeQuery()
-	defer a.clientWrapper.AfterQuery()
+	queryTimer := a.clientWrapper.BeforeQuery()
+	defer a.clientWrapper.AfterQuery(queryTimer)
 
 	ctx := context.Background()
 	disabledApiKey, err := a.dbClient.APIKey.FindUnique(
@@ -87,8 +87,8 @@ func (a *ApiKeyORM) DisableApiKeyByHotkey(hotkey string, apiKey string) (*db.API
 }
 
 func (a *ApiKeyORM) GetByApiKey(apiKey string) (*db.APIKeyModel, error) {
-	a.clientWrapper.BeforeQuery()
-	defer a.clientWrapper.AfterQuery()
+	queryTimer := a.clientWrapper.BeforeQuery()
+	defer a.clientWrapper.AfterQuery(queryTimer)
 
 	ctx := context.Background()
 
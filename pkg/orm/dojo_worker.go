@@ -24,8 +24,8 @@ func NewDojoWorkerORM() *DojoWorkerORM {
 }
 
 func (s *DojoWorkerORM) CreateDojoWorker(walletAddress string, chainId string) (*db.DojoWorkerModel, error) {
-	s.clientWrapper.BeforeQuery()
-	defer s.clientWrapper.AfterQuery()
+	queryTimer := s.clientWrapper.BeforeQuery()
+	defer s.clientWrapper.AfterQuery(queryTimer)
 
 	ctx := context.Background()
 	worker, err := s.dbClient.DojoWorker.CreateOne(
@@ -46,8 +46,8 @@ func (s *DojoWorkerORM) GetDojoWorkerByWalletAddress(walletAddress string) (*db.
 	}
 
 	// Cache miss, fetch from database
-	s.clientWrapper.BeforeQuery()
-	defer s.clientWrapper.AfterQuery()
+	queryTimer := s.clientWrapper.BeforeQuery()
+	defer s.clientWrapper.AfterQuery(queryTimer)
 
 	ctx := context.Background()
 	worker, err := s.dbClient.DojoWorker.FindFirst(
@@ -69,6 +69,28 @@ func (s *DojoWorkerORM) GetDojoWorkerByWalletAddress(walletAddress string) (*db.
 	return worker, nil
 }
 
+// SetLeaderboardOptIn stores whether the worker's wallet address is shown on the public leaderboard
+func (s *DojoWorkerORM) SetLeaderboardOptIn(ctx context.Context, worker *db.DojoWorkerModel, optIn bool) (*db.DojoWorkerModel, error) {
+	queryTimer := s.clientWrapper.BeforeQuery()
+	defer s.clientWrapper.AfterQuery(queryTimer)
+
+	updated, err := s.dbClient.DojoWorker.FindUnique(
+		db.DojoWorker.ID.Equals(worker.ID),
+	).Update(
+		db.DojoWorker.LeaderboardOptIn.Set(optIn),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the worker is cached by wallet address
+	cache := cache.GetCacheInstance()
+	if err := cache.Delete(cache.BuildCacheKey(cache.Keys.WorkerByWallet, worker.WalletAddress)); err != nil {
+		log.Warn().Err(err).Msg("Failed to delete worker cache")
+	}
+	return updated, nil
+}
+
 func (s *DojoWorkerORM) GetDojoWorkers() (int, error) {
 	var count int
 	cache := cache.GetCacheInstance()
@@ -80,8 +102,8 @@ func (s *DojoWorkerORM) GetDojoWorkers() (int, error) {
 	}
 
 	// Cache miss, fetch from database
-	s.clientWrapper.BeforeQuery()
-	defer s.clientWrapper.AfterQuery()
+	queryTimer := s.clientWrapper.BeforeQuery()
+	defer s.clientWrapper.AfterQuery(queryTimer)
 
 	ctx := context.Background()
 	var result []struct {
@@ -0,0 +1,122 @@
+package orm
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"dojo-api/db"
+)
+
+// records a fetch of every listed task at once, tasks the worker already fetched keep their first_fetched_at
+const recordTaskFetchesQuery = `INSERT INTO "TaskFetch" (id, updated_at, task_id, worker_id)
+	SELECT gen_random_uuid()::text, now(), task_id, $2 FROM jsonb_array_elements_text($1::jsonb) AS task_id
+	ON CONFLICT (task_id, worker_id) DO UPDATE SET last_fetched_at = now(), updated_at = now();`
+
+type DwellTimeORM struct {
+	dbClient      *db.PrismaClient
+	clientWrapper *PrismaClientWrapper
+}
+
+func NewDwellTimeORM() *DwellTimeORM {
+	clientWrapper := GetPrismaClient()
+	return &DwellTimeORM{
+		dbClient:      clientWrapper.Client,
+		clientWrapper: clientWrapper,
+	}
+}
+
+// RecordTaskFetch keeps the time the worker first fetched the task, later fetches only move last_fetched_at
+func (o *DwellTimeORM) RecordTaskFetch(ctx context.Context, taskId string, workerId string) (*db.TaskFetchModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.TaskFetch.UpsertOne(
+		db.TaskFetch.TaskIDWorkerID(
+			db.TaskFetch.TaskID.Equals(taskId),
+			db.TaskFetch.WorkerID.Equals(workerId),
+		),
+	).Create(
+		db.TaskFetch.Task.Link(
+			db.Task.ID.Equals(taskId),
+		),
+		db.TaskFetch.DojoWorker.Link(
+			db.DojoWorker.ID.Equals(workerId),
+		),
+	).Update(
+		db.TaskFetch.LastFetchedAt.Set(time.Now()),
+	).Exec(ctx)
+}
+
+// RecordTaskFetches is RecordTaskFetch for every task of a task list
+func (o *DwellTimeORM) RecordTaskFetches(ctx context.Context, taskIds []string, workerId string) error {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	if len(taskIds) == 0 {
+		return nil
+	}
+	taskIdsJSON, err := json.Marshal(taskIds)
+	if err != nil {
+		return err
+	}
+	_, err = o.dbClient.Prisma.ExecuteRaw(recordTaskFetchesQuery, string(taskIdsJSON), workerId).Exec(ctx)
+	return err
+}
+
+func (o *DwellTimeORM) GetTaskFetch(ctx context.Context, taskId string, workerId string) (*db.TaskFetchModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.TaskFetch.FindUnique(
+		db.TaskFetch.TaskIDWorkerID(
+			db.TaskFetch.TaskID.Equals(taskId),
+			db.TaskFetch.WorkerID.Equals(workerId),
+		),
+	).Exec(ctx)
+}
+
+func (o *DwellTimeORM) GetMinerDwellTime(ctx context.Context, minerUserId string, taskType db.TaskType) (*db.MinerDwellTimeModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.MinerDwellTime.FindUnique(
+		db.MinerDwellTime.MinerUserIDTaskType(
+			db.MinerDwellTime.MinerUserID.Equals(minerUserId),
+			db.MinerDwellTime.TaskType.Equals(taskType),
+		),
+	).Exec(ctx)
+}
+
+func (o *DwellTimeORM) GetMinerDwellTimes(ctx context.Context, minerUserId string) ([]db.MinerDwellTimeModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.MinerDwellTime.FindMany(
+		db.MinerDwellTime.MinerUserID.Equals(minerUserId),
+	).OrderBy(
+		db.MinerDwellTime.TaskType.Order(db.SortOrderAsc),
+	).Exec(ctx)
+}
+
+func (o *DwellTimeORM) UpsertMinerDwellTime(ctx context.Context, minerUserId string, taskType db.TaskType, minSeconds int, action db.DwellTimeAction) (*db.MinerDwellTimeModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.MinerDwellTime.UpsertOne(
+		db.MinerDwellTime.MinerUserIDTaskType(
+			db.MinerDwellTime.MinerUserID.Equals(minerUserId),
+			db.MinerDwellTime.TaskType.Equals(taskType),
+		),
+	).Create(
+		db.MinerDwellTime.MinerUser.Link(
+			db.MinerUser.ID.Equals(minerUserId),
+		),
+		db.MinerDwellTime.TaskType.Set(taskType),
+		db.MinerDwellTime.MinSeconds.Set(minSeconds),
+		db.MinerDwellTime.Action.Set(action),
+	).Update(
+		db.MinerDwellTime.MinSeconds.Set(minSeconds),
+		db.MinerDwellTime.Action.Set(action),
+	).Exec(ctx)
+}
@@ -21,8 +21,8 @@ func NewEventsORM() *EventsORM {
 }
 
 func (o *EventsORM) CreateEventByType(ctx context.Context, eventType db.EventsType, data interface{}) error {
-	o.clientWrapper.BeforeQuery()
-	defer o.clientWrapper.AfterQuery()
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
 
 	eventData, err := json.Marshal(data)
 	if err != nil {
@@ -38,8 +38,8 @@ func (o *EventsORM) CreateEventByType(ctx context.Context, eventType db.EventsTy
 }
 
 func (o *EventsORM) GetEventsByType(ctx context.Context, eventType db.EventsType) ([]db.EventsModel, error) {
-	o.clientWrapper.BeforeQuery()
-	defer o.clientWrapper.AfterQuery()
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
 
 	events, err := o.dbClient.Events.FindMany(db.Events.Type.Equals(eventType)).Exec(ctx)
 	if err != nil {
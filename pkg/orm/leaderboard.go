@@ -0,0 +1,84 @@
+package orm
+
+import (
+	"context"
+	"time"
+
+	"dojo-api/db"
+)
+
+// the wallet address is only selected for workers that opted in, everybody else is shown under a pseudonym
+const (
+	completedLeaderboardQuery = `SELECT r.worker_id,
+		CASE WHEN w.leaderboard_opt_in THEN w.wallet_address END AS wallet_address,
+		COUNT(*)::float AS score
+		FROM "TaskResult" r
+		JOIN "DojoWorker" w ON w.id = r.worker_id
+		WHERE r.status = 'COMPLETED' AND r.created_at >= $1
+		GROUP BY r.worker_id, w.leaderboard_opt_in, w.wallet_address;`
+
+	// reputation is not windowed, the window only decides which workers were active enough to be ranked
+	qualityLeaderboardQuery = `SELECT rep.worker_id,
+		CASE WHEN w.leaderboard_opt_in THEN w.wallet_address END AS wallet_address,
+		rep.score
+		FROM "WorkerReputation" rep
+		JOIN "DojoWorker" w ON w.id = rep.worker_id
+		WHERE EXISTS (
+			SELECT 1 FROM "TaskResult" r
+			WHERE r.worker_id = rep.worker_id AND r.status = 'COMPLETED' AND r.created_at >= $1
+		);`
+
+	earningsLeaderboardQuery = `SELECT r.worker_id,
+		CASE WHEN w.leaderboard_opt_in THEN w.wallet_address END AS wallet_address,
+		SUM(r.finalised_reward) AS score
+		FROM "TaskResult" r
+		JOIN "DojoWorker" w ON w.id = r.worker_id
+		WHERE r.finalised_reward > 0 AND r.created_at >= $1
+		GROUP BY r.worker_id, w.leaderboard_opt_in, w.wallet_address;`
+)
+
+type LeaderboardORM struct {
+	dbClient      *db.PrismaClient
+	clientWrapper *PrismaClientWrapper
+}
+
+func NewLeaderboardORM() *LeaderboardORM {
+	clientWrapper := GetPrismaClient()
+	return &LeaderboardORM{
+		dbClient:      clientWrapper.Client,
+		clientWrapper: clientWrapper,
+	}
+}
+
+// LeaderboardRow is a worker's score on one leaderboard, WalletAddress is nil unless the worker opted in
+type LeaderboardRow struct {
+	WorkerID      string      `json:"worker_id"`
+	WalletAddress *string     `json:"wallet_address"`
+	Score         db.RawFloat `json:"score"`
+}
+
+func (o *LeaderboardORM) getScores(ctx context.Context, query string, since time.Time) ([]LeaderboardRow, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	var rows []LeaderboardRow
+	if err := o.dbClient.Prisma.QueryRaw(query, since).Exec(ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetCompletedScores counts every worker's COMPLETED results created since the given time
+func (o *LeaderboardORM) GetCompletedScores(ctx context.Context, since time.Time) ([]LeaderboardRow, error) {
+	return o.getScores(ctx, completedLeaderboardQuery, since)
+}
+
+// GetQualityScores returns the reputation score of every worker with a COMPLETED result since the given time
+func (o *LeaderboardORM) GetQualityScores(ctx context.Context, since time.Time) ([]LeaderboardRow, error) {
+	return o.getScores(ctx, qualityLeaderboardQuery, since)
+}
+
+// GetEarningsScores sums every worker's finalised reward of results created since the given time
+func (o *LeaderboardORM) GetEarningsScores(ctx context.Context, since time.Time) ([]LeaderboardRow, error) {
+	return o.getScores(ctx, earningsLeaderboardQuery, since)
+}
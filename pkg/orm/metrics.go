@@ -3,13 +3,31 @@ package orm
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"dojo-api/db"
 
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 )
 
+// a metric written several times within an hour keeps the last value of the hour
+const upsertMetricSnapshotQuery = `INSERT INTO "MetricSnapshot" (id, updated_at, type, bucket_start, metrics_data)
+	VALUES ($1, now(), $2::"MetricsType", date_trunc('hour', now()), $3::jsonb)
+	ON CONFLICT (type, bucket_start) DO UPDATE SET metrics_data = EXCLUDED.metrics_data, updated_at = now();`
+
+const snapshotAllMetricsQuery = `INSERT INTO "MetricSnapshot" (id, updated_at, type, bucket_start, metrics_data)
+	SELECT gen_random_uuid()::text, now(), type, date_trunc('hour', now()), metrics_data FROM "Metrics"
+	ON CONFLICT (type, bucket_start) DO UPDATE SET metrics_data = EXCLUDED.metrics_data, updated_at = now();`
+
+// MetricSnapshotRow is the last value of a metric within a bucket
+type MetricSnapshotRow struct {
+	Type        db.MetricsType  `json:"type"`
+	BucketStart db.RawDateTime  `json:"bucket_start"`
+	MetricsData json.RawMessage `json:"metrics_data"`
+}
+
 type MetricsORM struct {
 	dbClient      *db.PrismaClient
 	clientWrapper *PrismaClientWrapper
@@ -24,8 +42,8 @@ func NewMetricsORM() *MetricsORM {
 }
 
 func (orm *MetricsORM) GetMetricsDataByMetricType(ctx context.Context, metricType db.MetricsType) (*db.MetricsModel, error) {
-	orm.clientWrapper.BeforeQuery()
-	defer orm.clientWrapper.AfterQuery()
+	queryTimer := orm.clientWrapper.BeforeQuery()
+	defer orm.clientWrapper.AfterQuery(queryTimer)
 
 	metrics, err := orm.dbClient.Metrics.FindUnique(
 		db.Metrics.Type.Equals(metricType),
@@ -38,20 +56,85 @@ func (orm *MetricsORM) GetMetricsDataByMetricType(ctx context.Context, metricTyp
 }
 
 func (orm *MetricsORM) CreateNewMetric(ctx context.Context, metricType db.MetricsType, data interface{}) error {
-	orm.clientWrapper.BeforeQuery()
-	defer orm.clientWrapper.AfterQuery()
+	queryTimer := orm.clientWrapper.BeforeQuery()
+	defer orm.clientWrapper.AfterQuery(queryTimer)
 
 	metrics, err := orm.dbClient.Metrics.FindUnique(
 		db.Metrics.Type.Equals(metricType),
 	).Exec(ctx)
 	if err != nil {
-		if db.IsErrNotFound(err) {
-			return orm.createMetric(ctx, metricType, data)
+		if !db.IsErrNotFound(err) {
+			return err
 		}
+		err = orm.createMetric(ctx, metricType, data)
+	} else {
+		err = orm.updateMetric(ctx, metrics, data)
+	}
+	if err != nil {
 		return err
 	}
 
-	return orm.updateMetric(ctx, metrics, data)
+	return orm.snapshotMetric(ctx, metricType, data)
+}
+
+// snapshotMetric records the value in the metric's history for the current hour
+func (orm *MetricsORM) snapshotMetric(ctx context.Context, metricType db.MetricsType, data interface{}) error {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = orm.dbClient.Prisma.ExecuteRaw(upsertMetricSnapshotQuery, uuid.New().String(), string(metricType), string(dataJSON)).Exec(ctx)
+	return err
+}
+
+// SnapshotAllMetrics records the current value of every metric for the current hour, so the history has a point
+// every hour even for metrics that did not change
+func (orm *MetricsORM) SnapshotAllMetrics(ctx context.Context) (int, error) {
+	queryTimer := orm.clientWrapper.BeforeQuery()
+	defer orm.clientWrapper.AfterQuery(queryTimer)
+
+	result, err := orm.dbClient.Prisma.ExecuteRaw(snapshotAllMetricsQuery).Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return result.Count, nil
+}
+
+// GetSnapshotSeries returns the last snapshot of every metric within each bucket in [from, to), interval must be
+// a postgres date_trunc field, e.g. hour, day, week or month
+func (orm *MetricsORM) GetSnapshotSeries(ctx context.Context, interval string, from time.Time, to time.Time) ([]MetricSnapshotRow, error) {
+	queryTimer := orm.clientWrapper.BeforeQuery()
+	defer orm.clientWrapper.AfterQuery(queryTimer)
+
+	query := `SELECT DISTINCT ON (type, date_trunc($1, bucket_start))
+		type, date_trunc($1, bucket_start) AS bucket_start, metrics_data
+		FROM "MetricSnapshot"
+		WHERE bucket_start >= $2 AND bucket_start < $3
+		ORDER BY type, date_trunc($1, bucket_start), "MetricSnapshot".bucket_start DESC;`
+
+	var rows []MetricSnapshotRow
+	if err := orm.dbClient.Prisma.QueryRaw(query, interval, from, to).Exec(ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetLatestSnapshotsBefore returns the last snapshot of every metric taken before the given time
+func (orm *MetricsORM) GetLatestSnapshotsBefore(ctx context.Context, before time.Time) ([]MetricSnapshotRow, error) {
+	queryTimer := orm.clientWrapper.BeforeQuery()
+	defer orm.clientWrapper.AfterQuery(queryTimer)
+
+	query := `SELECT DISTINCT ON (type) type, bucket_start, metrics_data
+		FROM "MetricSnapshot"
+		WHERE bucket_start < $1
+		ORDER BY type, bucket_start DESC;`
+
+	var rows []MetricSnapshotRow
+	if err := orm.dbClient.Prisma.QueryRaw(query, before).Exec(ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
 }
 
 func (orm *MetricsORM) createMetric(ctx context.Context, metricType db.MetricsType, data interface{}) error {
@@ -91,3 +174,36 @@ func (orm *MetricsORM) updateMetric(ctx context.Context, metrics *db.MetricsMode
 
 	return nil
 }
+
+// MetricSourceCounts are the metrics recomputed from the tables they are derived from
+type MetricSourceCounts struct {
+	NumDojoWorkers        db.RawString `json:"num_dojo_workers"`
+	NumCompletedTasks     db.RawString `json:"num_completed_tasks"`
+	NumTaskResults        db.RawString `json:"num_task_results"`
+	NumCompletionEvents   db.RawString `json:"num_completion_events"`
+	AvgTaskCompletionTime db.RawFloat  `json:"avg_task_completion_time"`
+}
+
+// GetSourceCounts recomputes every metric with SQL counts over the source tables, a task is completed once it
+// has its first result
+func (orm *MetricsORM) GetSourceCounts(ctx context.Context) (*MetricSourceCounts, error) {
+	queryTimer := orm.clientWrapper.BeforeQuery()
+	defer orm.clientWrapper.AfterQuery(queryTimer)
+
+	query := `SELECT
+		(SELECT COUNT(*) FROM "DojoWorker") AS num_dojo_workers,
+		(SELECT COUNT(DISTINCT task_id) FROM "TaskResult") AS num_completed_tasks,
+		(SELECT COUNT(*) FROM "TaskResult") AS num_task_results,
+		(SELECT COUNT(*) FROM "Events" WHERE type = 'TASK_COMPLETION_TIME') AS num_completion_events,
+		(SELECT COALESCE(AVG((events_data->>'task_completion_time')::float), 0) FROM "Events"
+			WHERE type = 'TASK_COMPLETION_TIME') AS avg_task_completion_time;`
+
+	var rows []MetricSourceCounts
+	if err := orm.dbClient.Prisma.QueryRaw(query).Exec(ctx, &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no results found from recomputing metrics")
+	}
+	return &rows[0], nil
+}
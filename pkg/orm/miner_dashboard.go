@@ -0,0 +1,175 @@
+package orm
+
+import (
+	"context"
+	"time"
+
+	"dojo-api/db"
+)
+
+// every query buckets by a postgres date_trunc field, e.g. day, week or month, and covers [from, to).
+// Task figures are bucketed by task creation time, result figures by submission time.
+const (
+	dashboardTaskBucketsQuery = `SELECT date_trunc($1, t.created_at) AS bucket_start,
+		COUNT(*) AS tasks_created,
+		COUNT(*) FILTER (WHERE t.status = 'COMPLETED') AS tasks_completed,
+		COUNT(*) FILTER (WHERE t.status = 'EXPIRED' AND t.num_results = 0) AS expired_without_results
+		FROM "Task" t
+		WHERE t.miner_user_id = $2 AND t.created_at >= $3 AND t.created_at < $4
+		GROUP BY bucket_start
+		ORDER BY bucket_start ASC;`
+
+	// completion times come from the TASK_COMPLETION_TIME events written when a task completes
+	dashboardCompletionBucketsQuery = `SELECT date_trunc($1, t.created_at) AS bucket_start,
+		percentile_cont(0.5) WITHIN GROUP (ORDER BY (e.events_data->>'task_completion_time')::float) AS median_completion_time
+		FROM "Events" e
+		JOIN "Task" t ON t.id = e.events_data->>'task_id'
+		WHERE e.type = 'TASK_COMPLETION_TIME' AND t.miner_user_id = $2 AND t.created_at >= $3 AND t.created_at < $4
+		GROUP BY bucket_start
+		ORDER BY bucket_start ASC;`
+
+	dashboardResultBucketsQuery = `SELECT date_trunc($1, r.created_at) AS bucket_start,
+		t.type AS task_type,
+		COUNT(*) AS num_results
+		FROM "TaskResult" r
+		JOIN "Task" t ON t.id = r.task_id
+		WHERE t.miner_user_id = $2 AND r.created_at >= $3 AND r.created_at < $4
+		GROUP BY bucket_start, t.type
+		ORDER BY bucket_start ASC;`
+
+	// a partnered worker is active when it submitted a result to one of the miner's tasks
+	dashboardActivePartnersFilter = `EXISTS (
+			SELECT 1 FROM "WorkerPartner" wp
+			JOIN "SubscriptionKey" sk ON sk.key = wp.miner_subscription_key
+			WHERE wp.worker_id = r.worker_id AND sk.miner_user_id = t.miner_user_id
+				AND NOT sk.is_delete AND NOT wp.is_delete_by_miner AND NOT wp.is_delete_by_worker
+		)`
+
+	dashboardActivePartnerBucketsQuery = `SELECT date_trunc($1, r.created_at) AS bucket_start,
+		COUNT(DISTINCT r.worker_id) AS active_partnered_workers
+		FROM "TaskResult" r
+		JOIN "Task" t ON t.id = r.task_id
+		WHERE t.miner_user_id = $2 AND r.created_at >= $3 AND r.created_at < $4 AND ` + dashboardActivePartnersFilter + `
+		GROUP BY bucket_start
+		ORDER BY bucket_start ASC;`
+
+	// medians and distinct counts cannot be added up from the buckets, so the whole range is computed separately
+	dashboardTotalsQuery = `SELECT
+		(SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY (e.events_data->>'task_completion_time')::float)
+			FROM "Events" e
+			JOIN "Task" t ON t.id = e.events_data->>'task_id'
+			WHERE e.type = 'TASK_COMPLETION_TIME' AND t.miner_user_id = $1 AND t.created_at >= $2 AND t.created_at < $3
+		) AS median_completion_time,
+		(SELECT COUNT(DISTINCT r.worker_id)
+			FROM "TaskResult" r
+			JOIN "Task" t ON t.id = r.task_id
+			WHERE t.miner_user_id = $1 AND r.created_at >= $2 AND r.created_at < $3 AND ` + dashboardActivePartnersFilter + `
+		) AS active_partnered_workers,
+		(SELECT COUNT(DISTINCT wp.worker_id)
+			FROM "WorkerPartner" wp
+			JOIN "SubscriptionKey" sk ON sk.key = wp.miner_subscription_key
+			WHERE sk.miner_user_id = $1 AND NOT sk.is_delete AND NOT wp.is_delete_by_miner AND NOT wp.is_delete_by_worker
+		) AS partnered_workers;`
+)
+
+type MinerDashboardORM struct {
+	dbClient      *db.PrismaClient
+	clientWrapper *PrismaClientWrapper
+}
+
+func NewMinerDashboardORM() *MinerDashboardORM {
+	clientWrapper := GetPrismaClient()
+	return &MinerDashboardORM{
+		dbClient:      clientWrapper.Client,
+		clientWrapper: clientWrapper,
+	}
+}
+
+type DashboardTaskBucketRow struct {
+	BucketStart           db.RawDateTime `json:"bucket_start"`
+	TasksCreated          db.RawString   `json:"tasks_created"`
+	TasksCompleted        db.RawString   `json:"tasks_completed"`
+	ExpiredWithoutResults db.RawString   `json:"expired_without_results"`
+}
+
+type DashboardCompletionBucketRow struct {
+	BucketStart          db.RawDateTime `json:"bucket_start"`
+	MedianCompletionTime *db.RawFloat   `json:"median_completion_time"`
+}
+
+type DashboardResultBucketRow struct {
+	BucketStart db.RawDateTime `json:"bucket_start"`
+	TaskType    db.TaskType    `json:"task_type"`
+	NumResults  db.RawString   `json:"num_results"`
+}
+
+type DashboardActivePartnerBucketRow struct {
+	BucketStart            db.RawDateTime `json:"bucket_start"`
+	ActivePartneredWorkers db.RawString   `json:"active_partnered_workers"`
+}
+
+// DashboardTotalsRow holds the figures over the whole range that cannot be summed from the buckets,
+// the median is nil when no task in the range completed
+type DashboardTotalsRow struct {
+	MedianCompletionTime   *db.RawFloat `json:"median_completion_time"`
+	ActivePartneredWorkers db.RawString `json:"active_partnered_workers"`
+	PartneredWorkers       db.RawString `json:"partnered_workers"`
+}
+
+func (o *MinerDashboardORM) GetTaskBuckets(ctx context.Context, minerUserId string, interval string, from time.Time, to time.Time) ([]DashboardTaskBucketRow, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	var rows []DashboardTaskBucketRow
+	if err := o.dbClient.Prisma.QueryRaw(dashboardTaskBucketsQuery, interval, minerUserId, from, to).Exec(ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (o *MinerDashboardORM) GetCompletionBuckets(ctx context.Context, minerUserId string, interval string, from time.Time, to time.Time) ([]DashboardCompletionBucketRow, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	var rows []DashboardCompletionBucketRow
+	if err := o.dbClient.Prisma.QueryRaw(dashboardCompletionBucketsQuery, interval, minerUserId, from, to).Exec(ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (o *MinerDashboardORM) GetResultBuckets(ctx context.Context, minerUserId string, interval string, from time.Time, to time.Time) ([]DashboardResultBucketRow, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	var rows []DashboardResultBucketRow
+	if err := o.dbClient.Prisma.QueryRaw(dashboardResultBucketsQuery, interval, minerUserId, from, to).Exec(ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (o *MinerDashboardORM) GetActivePartnerBuckets(ctx context.Context, minerUserId string, interval string, from time.Time, to time.Time) ([]DashboardActivePartnerBucketRow, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	var rows []DashboardActivePartnerBucketRow
+	if err := o.dbClient.Prisma.QueryRaw(dashboardActivePartnerBucketsQuery, interval, minerUserId, from, to).Exec(ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (o *MinerDashboardORM) GetTotals(ctx context.Context, minerUserId string, from time.Time, to time.Time) (*DashboardTotalsRow, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	var rows []DashboardTotalsRow
+	if err := o.dbClient.Prisma.QueryRaw(dashboardTotalsQuery, minerUserId, from, to).Exec(ctx, &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return &DashboardTotalsRow{ActivePartneredWorkers: "0", PartneredWorkers: "0"}, nil
+	}
+	return &rows[0], nil
+}
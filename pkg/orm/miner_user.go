@@ -23,8 +23,8 @@ func NewMinerUserORM() *MinerUserORM {
 }
 
 func (s *MinerUserORM) GetUserByHotkey(hotkey string) (*db.MinerUserModel, error) {
-	s.clientWrapper.BeforeQuery()
-	defer s.clientWrapper.AfterQuery()
+	queryTimer := s.clientWrapper.BeforeQuery()
+	defer s.clientWrapper.AfterQuery(queryTimer)
 	if hotkey == "" {
 		return nil, fmt.Errorf("hotkey cannot be an empty string")
 	}
@@ -40,8 +40,8 @@ func (s *MinerUserORM) GetUserByHotkey(hotkey string) (*db.MinerUserModel, error
 }
 
 func (s *MinerUserORM) DeregisterMiner(hotkey string) error {
-	s.clientWrapper.BeforeQuery()
-	defer s.clientWrapper.AfterQuery()
+	queryTimer := s.clientWrapper.BeforeQuery()
+	defer s.clientWrapper.AfterQuery(queryTimer)
 
 	ctx := context.Background()
 	_, err := s.dbClient.MinerUser.FindUnique(
@@ -63,8 +63,8 @@ func (s *MinerUserORM) DeregisterMiner(hotkey string) error {
 }
 
 func (s *MinerUserORM) CreateNewMiner(hotkey string) (*db.MinerUserModel, error) {
-	s.clientWrapper.BeforeQuery()
-	defer s.clientWrapper.AfterQuery()
+	queryTimer := s.clientWrapper.BeforeQuery()
+	defer s.clientWrapper.AfterQuery(queryTimer)
 
 	ctx := context.Background()
 	createdMiner, err := s.dbClient.MinerUser.CreateOne(db.MinerUser.Hotkey.Set(hotkey)).Exec(ctx)
@@ -0,0 +1,156 @@
+package orm
+
+import (
+	"context"
+
+	"dojo-api/db"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// an attempt only overwrites a failed attempt whose cooldown is over, so concurrent submissions of the same test
+// record a single attempt
+const upsertWorkerQualificationQuery = `INSERT INTO "WorkerQualification" (id, updated_at, test_id, worker_id, status, score, attempts, last_attempt_at)
+	VALUES ($1, now(), $2, $3, $4::"QualificationStatus", $5, 1, now())
+	ON CONFLICT (test_id, worker_id) DO UPDATE SET
+		status = EXCLUDED.status,
+		score = EXCLUDED.score,
+		attempts = "WorkerQualification".attempts + 1,
+		last_attempt_at = now(),
+		updated_at = now()
+	WHERE "WorkerQualification".status = 'FAILED'
+		AND "WorkerQualification".last_attempt_at <= now() - make_interval(hours => $6::int);`
+
+// MinerTaskType identifies the tasks of one task type created by one miner, the scope a qualification test applies to
+type MinerTaskType struct {
+	MinerUserID string
+	Type        db.TaskType
+}
+
+type QualificationORM struct {
+	dbClient      *db.PrismaClient
+	clientWrapper *PrismaClientWrapper
+}
+
+func NewQualificationORM() *QualificationORM {
+	clientWrapper := GetPrismaClient()
+	return &QualificationORM{
+		dbClient:      clientWrapper.Client,
+		clientWrapper: clientWrapper,
+	}
+}
+
+func (o *QualificationORM) CreateTest(ctx context.Context, test db.InnerQualificationTest, minerUserId string) (*db.QualificationTestModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.QualificationTest.CreateOne(
+		db.QualificationTest.MinerUser.Link(
+			db.MinerUser.ID.Equals(minerUserId),
+		),
+		db.QualificationTest.TaskType.Set(test.TaskType),
+		db.QualificationTest.Title.Set(test.Title),
+		db.QualificationTest.Questions.Set(test.Questions),
+		db.QualificationTest.PassThreshold.Set(test.PassThreshold),
+		db.QualificationTest.CooldownHours.Set(test.CooldownHours),
+		db.QualificationTest.SubscriptionKey.SetIfPresent(test.SubscriptionKey),
+	).Exec(ctx)
+}
+
+func (o *QualificationORM) GetTestById(ctx context.Context, testId string) (*db.QualificationTestModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.QualificationTest.FindUnique(
+		db.QualificationTest.ID.Equals(testId),
+	).Exec(ctx)
+}
+
+func (o *QualificationORM) GetTestsByMinerUser(ctx context.Context, minerUserId string) ([]db.QualificationTestModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.QualificationTest.FindMany(
+		db.QualificationTest.MinerUserID.Equals(minerUserId),
+	).OrderBy(
+		db.QualificationTest.CreatedAt.Order(db.SortOrderDesc),
+	).Exec(ctx)
+}
+
+// GetActiveTestsForWorker returns the active tests of every miner the worker is partnered with,
+// tests scoped to a subscription key only apply when the worker partnered through that key
+func (o *QualificationORM) GetActiveTestsForWorker(ctx context.Context, workerId string) ([]db.QualificationTestModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	partners, err := o.dbClient.WorkerPartner.FindMany(
+		db.WorkerPartner.WorkerID.Equals(workerId),
+		db.WorkerPartner.IsDeleteByMiner.Equals(false),
+		db.WorkerPartner.IsDeleteByWorker.Equals(false),
+	).Exec(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("workerId", workerId).Msg("Error fetching worker partners")
+		return nil, err
+	}
+
+	subscriptionKeys := make([]string, 0, len(partners))
+	for _, partner := range partners {
+		subscriptionKeys = append(subscriptionKeys, partner.MinerSubscriptionKey)
+	}
+	if len(subscriptionKeys) == 0 {
+		return []db.QualificationTestModel{}, nil
+	}
+
+	return o.dbClient.QualificationTest.FindMany(
+		db.QualificationTest.IsActive.Equals(true),
+		db.QualificationTest.MinerUser.Where(
+			db.MinerUser.SubscriptionKeys.Some(
+				db.SubscriptionKey.Key.In(subscriptionKeys),
+			),
+		),
+		db.QualificationTest.Or(
+			db.QualificationTest.SubscriptionKey.IsNull(),
+			db.QualificationTest.SubscriptionKey.In(subscriptionKeys),
+		),
+	).OrderBy(
+		db.QualificationTest.CreatedAt.Order(db.SortOrderAsc),
+	).Exec(ctx)
+}
+
+func (o *QualificationORM) GetWorkerQualifications(ctx context.Context, workerId string) ([]db.WorkerQualificationModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.WorkerQualification.FindMany(
+		db.WorkerQualification.WorkerID.Equals(workerId),
+	).Exec(ctx)
+}
+
+func (o *QualificationORM) GetWorkerQualification(ctx context.Context, testId string, workerId string) (*db.WorkerQualificationModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.WorkerQualification.FindUnique(
+		db.WorkerQualification.TestIDWorkerID(
+			db.WorkerQualification.TestID.Equals(testId),
+			db.WorkerQualification.WorkerID.Equals(workerId),
+		),
+	).Exec(ctx)
+}
+
+// UpsertWorkerQualification records the outcome of an attempt, overwriting the previous status and score. Returns
+// false without recording anything when the test was already passed or the last failed attempt is still within
+// cooldownHours.
+func (o *QualificationORM) UpsertWorkerQualification(ctx context.Context, testId string, workerId string, status db.QualificationStatus, score float64, cooldownHours int) (bool, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	result, err := o.dbClient.Prisma.ExecuteRaw(
+		upsertWorkerQualificationQuery, uuid.New().String(), testId, workerId, string(status), score, cooldownHours,
+	).Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	return result.Count > 0, nil
+}
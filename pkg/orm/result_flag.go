@@ -0,0 +1,147 @@
+package orm
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"dojo-api/db"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+
+	"github.com/rs/zerolog/log"
+)
+
+// new reasons reopen a reviewed flag, a flag that gains nothing new is left untouched so its review and
+// updated_at stay as they are. A result flagged only as too_fast is its own cluster and joins the new one.
+const upsertResultFlagQuery = `INSERT INTO "ResultFlag" (id, updated_at, task_result_id, cluster_id, reasons, score)
+	VALUES ($1, now(), $2, $3, ARRAY(SELECT jsonb_array_elements_text($4::jsonb)), $5)
+	ON CONFLICT (task_result_id) DO UPDATE SET
+		reasons = ARRAY(SELECT DISTINCT r FROM unnest("ResultFlag".reasons || EXCLUDED.reasons) r ORDER BY r),
+		score = GREATEST("ResultFlag".score, EXCLUDED.score),
+		cluster_id = CASE WHEN "ResultFlag".reasons <@ ARRAY['too_fast'] THEN EXCLUDED.cluster_id ELSE "ResultFlag".cluster_id END,
+		status = CASE WHEN EXCLUDED.reasons <@ "ResultFlag".reasons THEN "ResultFlag".status ELSE 'PENDING' END,
+		updated_at = now()
+	WHERE NOT (EXCLUDED.reasons <@ "ResultFlag".reasons AND EXCLUDED.score <= "ResultFlag".score);`
+
+type ResultFlagORM struct {
+	dbClient      *db.PrismaClient
+	clientWrapper *PrismaClientWrapper
+}
+
+func NewResultFlagORM() *ResultFlagORM {
+	clientWrapper := GetPrismaClient()
+	return &ResultFlagORM{
+		dbClient:      clientWrapper.Client,
+		clientWrapper: clientWrapper,
+	}
+}
+
+// CreateFlags stores all flags of a cluster in a single transaction, a result that is already flagged, e.g. by a
+// detection running at the same time, keeps its flag with the new reasons merged in
+func (o *ResultFlagORM) CreateFlags(ctx context.Context, flags []db.InnerResultFlag) error {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	if len(flags) == 0 {
+		return nil
+	}
+
+	txs := make([]db.PrismaTransaction, 0, len(flags))
+	for _, flag := range flags {
+		reasonsJSON, err := json.Marshal(flag.Reasons)
+		if err != nil {
+			return err
+		}
+		upsertTx := o.dbClient.Prisma.ExecuteRaw(
+			upsertResultFlagQuery, uuid.New().String(), flag.TaskResultID, flag.ClusterID, string(reasonsJSON), flag.Score,
+		).Tx()
+		txs = append(txs, upsertTx)
+	}
+	return o.dbClient.Prisma.Transaction(txs...).Exec(ctx)
+}
+
+func (o *ResultFlagORM) buildFilters(status *db.ResultFlagStatus, from, to *time.Time) []db.ResultFlagWhereParam {
+	filterParams := make([]db.ResultFlagWhereParam, 0)
+	if status != nil {
+		filterParams = append(filterParams, db.ResultFlag.Status.Equals(*status))
+	}
+	if from != nil {
+		filterParams = append(filterParams, db.ResultFlag.CreatedAt.Gte(*from))
+	}
+	if to != nil {
+		filterParams = append(filterParams, db.ResultFlag.CreatedAt.Lte(*to))
+	}
+	return filterParams
+}
+
+// GetFlags returns a page of flags, newest first, with the flagged result and its worker
+func (o *ResultFlagORM) GetFlags(ctx context.Context, status *db.ResultFlagStatus, from, to *time.Time, offset, limit int) ([]db.ResultFlagModel, int, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	flags, err := o.dbClient.ResultFlag.FindMany(
+		o.buildFilters(status, from, to)...,
+	).With(
+		db.ResultFlag.TaskResult.Fetch().With(
+			db.TaskResult.DojoWorker.Fetch(),
+		),
+	).OrderBy(
+		db.ResultFlag.CreatedAt.Order(db.SortOrderDesc),
+	).Skip(offset).Take(limit).Exec(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := o.countFlags(ctx, status, from, to)
+	if err != nil {
+		return nil, 0, err
+	}
+	return flags, total, nil
+}
+
+func (o *ResultFlagORM) countFlags(ctx context.Context, status *db.ResultFlagStatus, from, to *time.Time) (int, error) {
+	query := sq.Select("count(*) as total").
+		From("\"ResultFlag\"").
+		PlaceholderFormat(sq.Dollar)
+	if status != nil {
+		// need to cast since ResultFlagStatus is a custom prisma enum type
+		query = query.Where(sq.Expr("status = ?::\"ResultFlagStatus\"", string(*status)))
+	}
+	if from != nil {
+		query = query.Where(sq.GtOrEq{"created_at": *from})
+	}
+	if to != nil {
+		query = query.Where(sq.LtOrEq{"created_at": *to})
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		log.Error().Err(err).Msg("Error building result flag count query")
+		return 0, err
+	}
+
+	var res []struct {
+		Total db.RawString `json:"total"`
+	}
+	if err := o.dbClient.Prisma.QueryRaw(sql, args...).Exec(ctx, &res); err != nil {
+		return 0, err
+	}
+	if len(res) == 0 {
+		return 0, nil
+	}
+	return strconv.Atoi(string(res[0].Total))
+}
+
+func (o *ResultFlagORM) UpdateFlagStatus(ctx context.Context, flagId string, status db.ResultFlagStatus) (*db.ResultFlagModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.ResultFlag.FindUnique(
+		db.ResultFlag.ID.Equals(flagId),
+	).Update(
+		db.ResultFlag.Status.Set(status),
+	).Exec(ctx)
+}
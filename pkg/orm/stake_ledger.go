@@ -0,0 +1,215 @@
+package orm
+
+import (
+	"context"
+	"strconv"
+
+	"dojo-api/db"
+
+	"github.com/google/uuid"
+)
+
+// every statement below appends to the ledger and moves current_stake_amount in a single statement,
+// so the cached balance never drifts from the ledger
+
+const depositStakeQuery = `WITH entry AS (
+		INSERT INTO "StakeLedgerEntry" (id, worker_id, type, amount, reference)
+		VALUES ($1, $2, 'DEPOSIT', $3, NULLIF($4, ''))
+		RETURNING worker_id, amount
+	)
+	UPDATE "DojoWorker" w SET current_stake_amount = COALESCE(w.current_stake_amount, 0) + entry.amount, updated_at = now()
+	FROM entry WHERE w.id = entry.worker_id;`
+
+// nothing is locked unless the available stake covers the amount
+const lockStakeQuery = `WITH locked AS (
+		UPDATE "DojoWorker" SET current_stake_amount = current_stake_amount - $3, updated_at = now()
+		WHERE id = $2 AND current_stake_amount >= $3
+		RETURNING id
+	)
+	INSERT INTO "StakeLedgerEntry" (id, worker_id, type, amount, task_id, task_result_id)
+	SELECT $1, locked.id, 'LOCK', $3, $4, $5 FROM locked;`
+
+// a submission's stake is released at most once, repeated releases are ignored
+const releaseStakeQuery = `WITH entry AS (
+		INSERT INTO "StakeLedgerEntry" (id, worker_id, type, amount, task_id, task_result_id)
+		VALUES ($1, $2, 'RELEASE', $3, $4, $5)
+		ON CONFLICT (task_result_id, type) DO NOTHING
+		RETURNING worker_id, amount
+	)
+	UPDATE "DojoWorker" w SET current_stake_amount = COALESCE(w.current_stake_amount, 0) + entry.amount, updated_at = now()
+	FROM entry WHERE w.id = entry.worker_id;`
+
+// slashed stake was already taken out of the available stake when it was locked
+const slashStakeQuery = `INSERT INTO "StakeLedgerEntry" (id, worker_id, type, amount, task_id, task_result_id)
+	VALUES ($1, $2, 'SLASH', $3, $4, $5)
+	ON CONFLICT (task_result_id, type) DO NOTHING;`
+
+// a clawback comes out of the available stake since the submission's stake was already released, the available
+// stake may go below zero and new submissions cannot lock stake until it is topped up again
+const clawbackStakeQuery = `WITH entry AS (
+		INSERT INTO "StakeLedgerEntry" (id, worker_id, type, amount, task_id, task_result_id)
+		VALUES ($1, $2, 'CLAWBACK', $3, $4, $5)
+		ON CONFLICT (task_result_id, type) DO NOTHING
+		RETURNING worker_id, amount
+	)
+	UPDATE "DojoWorker" w SET current_stake_amount = COALESCE(w.current_stake_amount, 0) - entry.amount, updated_at = now()
+	FROM entry WHERE w.id = entry.worker_id;`
+
+type StakeLedgerORM struct {
+	dbClient      *db.PrismaClient
+	clientWrapper *PrismaClientWrapper
+}
+
+func NewStakeLedgerORM() *StakeLedgerORM {
+	clientWrapper := GetPrismaClient()
+	return &StakeLedgerORM{
+		dbClient:      clientWrapper.Client,
+		clientWrapper: clientWrapper,
+	}
+}
+
+// SettledStake is how much of a settled submission's stake was released and how much was taken as loss
+type SettledStake struct {
+	Released float64
+	Slashed  float64
+}
+
+// StakeTotalRow is the sum of a worker's ledger entries of one type
+type StakeTotalRow struct {
+	Type  db.StakeEntryType `json:"type"`
+	Total db.RawFloat       `json:"total"`
+}
+
+func (o *StakeLedgerORM) Deposit(ctx context.Context, workerId string, amount float64, reference string) error {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	_, err := o.dbClient.Prisma.ExecuteRaw(depositStakeQuery, uuid.New().String(), workerId, amount, reference).Exec(ctx)
+	return err
+}
+
+// Lock moves amount of the worker's available stake into a lock for the submission, returns false when
+// the available stake does not cover it
+func (o *StakeLedgerORM) Lock(ctx context.Context, workerId string, amount float64, taskId string, taskResultId string) (bool, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	result, err := o.dbClient.Prisma.ExecuteRaw(lockStakeQuery, uuid.New().String(), workerId, amount, taskId, taskResultId).Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	return result.Count > 0, nil
+}
+
+func (o *StakeLedgerORM) Release(ctx context.Context, workerId string, amount float64, taskId string, taskResultId string) error {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	_, err := o.dbClient.Prisma.ExecuteRaw(releaseStakeQuery, uuid.New().String(), workerId, amount, taskId, taskResultId).Exec(ctx)
+	return err
+}
+
+// Settle slashes and releases the stake of a settled submission in one transaction, so a submission never ends
+// up with only one of the two entries and the rest of its stake locked
+func (o *StakeLedgerORM) Settle(ctx context.Context, workerId string, slashAmount float64, releaseAmount float64, taskId string, taskResultId string) error {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	txs := make([]db.PrismaTransaction, 0, 2)
+	if slashAmount > 0 {
+		txs = append(txs, o.dbClient.Prisma.ExecuteRaw(slashStakeQuery, uuid.New().String(), workerId, slashAmount, taskId, taskResultId).Tx())
+	}
+	if releaseAmount > 0 {
+		txs = append(txs, o.dbClient.Prisma.ExecuteRaw(releaseStakeQuery, uuid.New().String(), workerId, releaseAmount, taskId, taskResultId).Tx())
+	}
+	if len(txs) == 0 {
+		return nil
+	}
+	return o.dbClient.Prisma.Transaction(txs...).Exec(ctx)
+}
+
+// Clawback takes a loss found after the submission's stake was released out of the worker's available stake,
+// a submission is clawed back at most once
+func (o *StakeLedgerORM) Clawback(ctx context.Context, workerId string, amount float64, taskId string, taskResultId string) error {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	_, err := o.dbClient.Prisma.ExecuteRaw(clawbackStakeQuery, uuid.New().String(), workerId, amount, taskId, taskResultId).Exec(ctx)
+	return err
+}
+
+// GetSettledStakes returns how the stake of those of the given results that were already settled was split,
+// results without a RELEASE, SLASH or CLAWBACK entry are left out
+func (o *StakeLedgerORM) GetSettledStakes(ctx context.Context, taskResultIds []string) (map[string]SettledStake, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	entries, err := o.dbClient.StakeLedgerEntry.FindMany(
+		db.StakeLedgerEntry.TaskResultID.In(taskResultIds),
+		db.StakeLedgerEntry.Type.In([]db.StakeEntryType{db.StakeEntryTypeRelease, db.StakeEntryTypeSlash, db.StakeEntryTypeClawback}),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	settled := make(map[string]SettledStake, len(entries))
+	for _, entry := range entries {
+		taskResultId, ok := entry.TaskResultID()
+		if !ok {
+			continue
+		}
+		stake := settled[taskResultId]
+		if entry.Type == db.StakeEntryTypeRelease {
+			stake.Released += entry.Amount
+		} else {
+			stake.Slashed += entry.Amount
+		}
+		settled[taskResultId] = stake
+	}
+	return settled, nil
+}
+
+// GetTotals sums the worker's whole ledger per entry type, the balances are rebuilt from these
+func (o *StakeLedgerORM) GetTotals(ctx context.Context, workerId string) ([]StakeTotalRow, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	query := `SELECT type, SUM(amount) AS total FROM "StakeLedgerEntry" WHERE worker_id = $1 GROUP BY type;`
+
+	var rows []StakeTotalRow
+	if err := o.dbClient.Prisma.QueryRaw(query, workerId).Exec(ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetEntries returns a page of the worker's ledger, newest first, along with the total number of entries
+func (o *StakeLedgerORM) GetEntries(ctx context.Context, workerId string, offset, limit int) ([]db.StakeLedgerEntryModel, int, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	entries, err := o.dbClient.StakeLedgerEntry.FindMany(
+		db.StakeLedgerEntry.WorkerID.Equals(workerId),
+	).OrderBy(
+		db.StakeLedgerEntry.CreatedAt.Order(db.SortOrderDesc),
+	).Skip(offset).Take(limit).Exec(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT count(*) AS total FROM "StakeLedgerEntry" WHERE worker_id = $1;`
+	var res []struct {
+		Total db.RawString `json:"total"`
+	}
+	if err := o.dbClient.Prisma.QueryRaw(query, workerId).Exec(ctx, &res); err != nil {
+		return nil, 0, err
+	}
+	if len(res) == 0 {
+		return entries, 0, nil
+	}
+	total, err := strconv.Atoi(string(res[0].Total))
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
@@ -58,8 +58,8 @@ func (a *SubscriptionKeyORM) GetSubscriptionKeysByMinerHotkey(hotkey string) ([]
 }
 
 func (a *SubscriptionKeyORM) CreateSubscriptionKeyByHotkey(hotkey string, subscriptionKey string) (*db.SubscriptionKeyModel, error) {
-	a.clientWrapper.BeforeQuery()
-	defer a.clientWrapper.AfterQuery()
+	queryTimer := a.clientWrapper.BeforeQuery()
+	defer a.clientWrapper.AfterQuery(queryTimer)
 
 	ctx := context.Background()
 
@@ -84,8 +84,8 @@ func (a *SubscriptionKeyORM) CreateSubscriptionKeyByHotkey(hotkey string, subscr
 }
 
 func (a *SubscriptionKeyORM) DisableSubscriptionKeyByHotkey(hotkey string, subscriptionKey string) (*db.SubscriptionKeyModel, error) {
-	a.clientWrapper.BeforeQuery()
-	defer a.clientWrapper.AfterQuery()
+	queryTimer := a.clientWrapper.BeforeQuery()
+	defer a.clientWrapper.AfterQuery(queryTimer)
 
 	ctx := context.Background()
 	disabledAPIKey, err := a.dbClient.SubscriptionKey.FindUnique(
@@ -109,8 +109,8 @@ func (a *SubscriptionKeyORM) GetSubscriptionByKey(subScriptionKey string) (*db.S
 	if err := cache.GetCacheValue(cacheKey, &foundSubscriptionKey); err == nil {
 		return foundSubscriptionKey, nil
 	}
-	a.clientWrapper.BeforeQuery()
-	defer a.clientWrapper.AfterQuery()
+	queryTimer := a.clientWrapper.BeforeQuery()
+	defer a.clientWrapper.AfterQuery(queryTimer)
 
 	ctx := context.Background()
 
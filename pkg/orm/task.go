@@ -2,6 +2,7 @@ package orm
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
@@ -30,8 +31,8 @@ func NewTaskORM() *TaskORM {
 // CreateTask creates a new task in the database with the provided details.
 // Ignores `Status` and `NumResults` fields as they are set to default values.
 func (o *TaskORM) CreateTask(ctx context.Context, task db.InnerTask, minerUserId string) (*db.TaskModel, error) {
-	o.clientWrapper.BeforeQuery()
-	defer o.clientWrapper.AfterQuery()
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
 
 	createdTask, err := o.dbClient.Task.CreateOne(
 		db.Task.ExpireAt.Set(task.ExpireAt),
@@ -45,6 +46,9 @@ func (o *TaskORM) CreateTask(ctx context.Context, task db.InnerTask, minerUserId
 		db.Task.MinerUser.Link(
 			db.MinerUser.ID.Equals(minerUserId),
 		),
+		db.Task.IsGold.Set(task.IsGold),
+		db.Task.GoldAnswers.SetIfPresent(task.GoldAnswers),
+		db.Task.MinReputation.SetIfPresent(task.MinReputation),
 	).Exec(ctx)
 	return createdTask, err
 }
@@ -61,8 +65,8 @@ func (o *TaskORM) GetById(ctx context.Context, taskId string) (*db.TaskModel, er
 	}
 
 	// Cache miss, fetch from database
-	o.clientWrapper.BeforeQuery()
-	defer o.clientWrapper.AfterQuery()
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
 
 	task, err := o.dbClient.Task.FindUnique(
 		db.Task.ID.Equals(taskId),
@@ -79,13 +83,68 @@ func (o *TaskORM) GetById(ctx context.Context, taskId string) (*db.TaskModel, er
 	return task, nil
 }
 
+// GetByIdUncached bypasses the cache, for reads that depend on the current status or number of results
+func (o *TaskORM) GetByIdUncached(ctx context.Context, taskId string) (*db.TaskModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.Task.FindUnique(
+		db.Task.ID.Equals(taskId),
+	).Exec(ctx)
+}
+
+// GetByIdAndMinerUser bypasses the cache since the result is used to validate writes
+func (o *TaskORM) GetByIdAndMinerUser(ctx context.Context, taskId string, minerUserId string) (*db.TaskModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.Task.FindFirst(
+		db.Task.ID.Equals(taskId),
+		db.Task.MinerUserID.Equals(minerUserId),
+	).Exec(ctx)
+}
+
+// In a transaction updates the Task and appends the changes to the TaskHistory audit trail
+func (o *TaskORM) UpdateTaskWithHistory(ctx context.Context, taskId string, minerUserId string, changes interface{}, params ...db.TaskSetParam) (*db.TaskModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+
+	params = append(params, db.Task.UpdatedAt.Set(time.Now().UTC()))
+	updateTaskTx := o.dbClient.Task.FindUnique(db.Task.ID.Equals(taskId)).Update(params...).Tx()
+
+	createHistoryTx := o.dbClient.TaskHistory.CreateOne(
+		db.TaskHistory.Task.Link(
+			db.Task.ID.Equals(taskId),
+		),
+		db.TaskHistory.MinerUserID.Set(minerUserId),
+		db.TaskHistory.Changes.Set(changesJSON),
+	).Tx()
+
+	if err := o.dbClient.Prisma.Transaction(updateTaskTx, createHistoryTx).Exec(ctx); err != nil {
+		log.Error().Err(err).Str("taskId", taskId).Msg("Error updating task with history")
+		return nil, err
+	}
+
+	cache := cache.GetCacheInstance()
+	if err := cache.DeleteWithSuffix(cache.Keys.TaskById, taskId); err != nil {
+		log.Warn().Err(err).Str("taskId", taskId).Msg("Failed to invalidate task cache")
+	}
+
+	return updateTaskTx.Result(), nil
+}
+
 // Modified GetTasksByWorkerSubscription with caching
-func (o *TaskORM) GetTasksByWorkerSubscription(ctx context.Context, workerId string, offset, limit int, sortQuery db.TaskOrderByParam, taskTypes []db.TaskType) ([]db.TaskModel, int, error) {
+func (o *TaskORM) GetTasksByWorkerSubscription(ctx context.Context, workerId string, offset, limit int, sortQuery db.TaskOrderByParam, taskTypes []db.TaskType, workerReputation float64, unqualifiedScopes []MinerTaskType) ([]db.TaskModel, int, error) {
 	var tasks []db.TaskModel
 
 	// Cache miss, proceed with database query
-	o.clientWrapper.BeforeQuery()
-	defer o.clientWrapper.AfterQuery()
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
 
 	// Rest of the existing implementation...
 	partners, err := o.dbClient.WorkerPartner.FindMany(
@@ -108,18 +167,37 @@ func (o *TaskORM) GetTasksByWorkerSubscription(ctx context.Context, workerId str
 		return nil, 0, err
 	}
 
+	// gold tasks are deliberately not filtered out, they are mixed into the feed and
+	// served exactly like ordinary tasks so workers cannot tell them apart
 	filterParams := []db.TaskWhereParam{
 		db.Task.MinerUser.Where(
 			db.MinerUser.SubscriptionKeys.Some(
 				db.SubscriptionKey.Key.In(subscriptionKeys),
 			),
 		),
+		// cancelled tasks were withdrawn by the miner and should never be shown to workers
+		db.Task.Status.Not(db.TaskStatusCancelled),
+		// tasks requiring a higher reputation than the worker's are hidden
+		db.Task.Or(
+			db.Task.MinReputation.IsNull(),
+			db.Task.MinReputation.Lte(workerReputation),
+		),
 	}
 
 	if len(taskTypes) > 0 {
 		filterParams = append(filterParams, db.Task.Type.In(taskTypes))
 	}
 
+	// tasks gated behind a qualification test the worker has not passed are hidden
+	for _, scope := range unqualifiedScopes {
+		filterParams = append(filterParams, db.Task.Not(
+			db.Task.And(
+				db.Task.MinerUserID.Equals(scope.MinerUserID),
+				db.Task.Type.Equals(scope.Type),
+			),
+		))
+	}
+
 	tasks, err = o.dbClient.Task.FindMany(
 		filterParams...,
 	).OrderBy(sortQuery).
@@ -131,7 +209,7 @@ func (o *TaskORM) GetTasksByWorkerSubscription(ctx context.Context, workerId str
 		return nil, 0, err
 	}
 
-	totalTasks, err := o.countTasksByWorkerSubscription(ctx, taskTypes, subscriptionKeys)
+	totalTasks, err := o.countTasksByWorkerSubscription(ctx, taskTypes, subscriptionKeys, workerReputation, unqualifiedScopes)
 	if err != nil {
 		log.Error().Err(err).Msgf("Error fetching total tasks for worker ID %v", workerId)
 		return nil, 0, err
@@ -142,9 +220,59 @@ func (o *TaskORM) GetTasksByWorkerSubscription(ctx context.Context, workerId str
 	return tasks, totalTasks, nil
 }
 
+// GetTasksByMinerUser returns up to limit tasks owned by the miner, newest first, starting after the cursor task ID
+func (o *TaskORM) GetTasksByMinerUser(ctx context.Context, minerUserId string, filterParams []db.TaskWhereParam, cursor string, limit int) ([]db.TaskModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	filterParams = append(filterParams, db.Task.MinerUserID.Equals(minerUserId))
+	query := o.dbClient.Task.FindMany(
+		filterParams...,
+	).OrderBy(
+		db.Task.CreatedAt.Order(db.SortOrderDesc),
+		db.Task.ID.Order(db.SortOrderDesc),
+	).Take(limit)
+
+	if cursor != "" {
+		// skip the cursor task itself, it was the last item of the previous page
+		query = query.Cursor(db.Task.ID.Cursor(cursor)).Skip(1)
+	}
+
+	tasks, err := query.Exec(ctx)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error fetching tasks for miner user ID %v", minerUserId)
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// GetTasksWithResults returns up to limit tasks that received at least one result, ordered by ID and starting
+// after the cursor task ID, used to walk historical data in batches
+func (o *TaskORM) GetTasksWithResults(ctx context.Context, createdFrom *time.Time, cursor string, limit int) ([]db.TaskModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	filterParams := []db.TaskWhereParam{db.Task.NumResults.Gt(0)}
+	if createdFrom != nil {
+		filterParams = append(filterParams, db.Task.CreatedAt.Gte(*createdFrom))
+	}
+
+	query := o.dbClient.Task.FindMany(
+		filterParams...,
+	).OrderBy(
+		db.Task.ID.Order(db.SortOrderAsc),
+	).Take(limit)
+
+	if cursor != "" {
+		query = query.Cursor(db.Task.ID.Cursor(cursor)).Skip(1)
+	}
+
+	return query.Exec(ctx)
+}
+
 // This function uses raw queries to calculate count(*) since this functionality is missing from the prisma go client
 // and using findMany with the filter params and then len(tasks) is facing performance issues
-func (o *TaskORM) countTasksByWorkerSubscription(ctx context.Context, taskTypes []db.TaskType, subscriptionKeys []string) (int, error) {
+func (o *TaskORM) countTasksByWorkerSubscription(ctx context.Context, taskTypes []db.TaskType, subscriptionKeys []string, workerReputation float64, unqualifiedScopes []MinerTaskType) (int, error) {
 	var taskTypesParam []string
 	for _, taskType := range taskTypes {
 		taskTypesParam = append(taskTypesParam, string(taskType))
@@ -173,10 +301,19 @@ func (o *TaskORM) countTasksByWorkerSubscription(ctx context.Context, taskTypes
 	mainQuery := sq.Select("count(*) as total_tasks").
 		From("\"Task\"").
 		Where(sq.Expr(fmt.Sprintf("miner_user_id IN (%s)", subQuery), subQueryArgs...)).
-		// need to do this since TaskType is a custom prisma enum type
-		Where(sq.Expr(fmt.Sprintf("type in ('%s')", strings.Join(taskTypesParam, "', '")))).
+		Where(sq.Expr(fmt.Sprintf("status != '%s'", db.TaskStatusCancelled))).
+		Where(sq.Expr("(min_reputation IS NULL OR min_reputation <= ?)", workerReputation)).
 		PlaceholderFormat(sq.Dollar)
 
+	// same filters as GetTasksByWorkerSubscription so the total matches the pages
+	if len(taskTypesParam) > 0 {
+		// need to cast since TaskType is a custom prisma enum type
+		mainQuery = mainQuery.Where(sq.Eq{"type::text": taskTypesParam})
+	}
+	for _, scope := range unqualifiedScopes {
+		mainQuery = mainQuery.Where(sq.Expr("NOT (miner_user_id = ? AND type = ?::\"TaskType\")", scope.MinerUserID, string(scope.Type)))
+	}
+
 	sql, args, err := mainQuery.ToSql()
 	if err != nil {
 		log.Error().Err(err).Msg("Error building full SQL query")
@@ -218,8 +355,8 @@ func (o *TaskORM) countTasksByWorkerSubscription(ctx context.Context, taskTypes
 func (o *TaskORM) UpdateExpiredTasks(ctx context.Context) {
 	for range time.Tick(10 * time.Minute) {
 		log.Info().Msg("Checking for expired tasks")
-		o.clientWrapper.BeforeQuery()
-		defer o.clientWrapper.AfterQuery()
+		// not deferred, the loop never returns
+		queryTimer := o.clientWrapper.BeforeQuery()
 
 		currentTime := time.Now().UTC()
 		batchSize := 100 // Adjust batch size based on database performance
@@ -263,16 +400,67 @@ func (o *TaskORM) UpdateExpiredTasks(ctx context.Context) {
 
 			log.Info().Msgf("Updated %v expired tasks in batch %d", len(taskIDs), batchNumber)
 		}
+		o.clientWrapper.AfterQuery(queryTimer)
 
 		updateDuration := time.Since(startTime)
 		log.Info().Msgf("Total time taken to update expired tasks: %s", updateDuration)
 	}
 }
 
+// UpdateInProgressTasksStatus moves the miner's IN_PROGRESS tasks out of the given IDs to the new status,
+// returns the IDs of the tasks that were actually updated
+func (o *TaskORM) UpdateInProgressTasksStatus(ctx context.Context, minerUserId string, taskIds []string, status db.TaskStatus) ([]string, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	// only tasks owned by the miner that are still collecting results can be updated
+	ownedTasks, err := o.dbClient.Task.FindMany(
+		db.Task.ID.In(taskIds),
+		db.Task.MinerUserID.Equals(minerUserId),
+		db.Task.Status.Equals(db.TaskStatusInProgress),
+	).Exec(ctx)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error finding in progress tasks for miner user ID %v", minerUserId)
+		return nil, err
+	}
+
+	updatedTaskIds := make([]string, 0, len(ownedTasks))
+	for _, task := range ownedTasks {
+		updatedTaskIds = append(updatedTaskIds, task.ID)
+	}
+
+	if len(updatedTaskIds) == 0 {
+		return updatedTaskIds, nil
+	}
+
+	_, err = o.dbClient.Task.FindMany(
+		db.Task.ID.In(updatedTaskIds),
+		db.Task.Status.Equals(db.TaskStatusInProgress),
+	).Update(
+		db.Task.Status.Set(status),
+		db.Task.UpdatedAt.Set(time.Now().UTC()),
+	).Exec(ctx)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error updating tasks to %v status", status)
+		return nil, err
+	}
+
+	// drop stale cached copies so workers see the new status immediately
+	cache := cache.GetCacheInstance()
+	for _, taskId := range updatedTaskIds {
+		if err := cache.DeleteWithSuffix(cache.Keys.TaskById, taskId); err != nil {
+			log.Warn().Err(err).Str("taskId", taskId).Msg("Failed to invalidate task cache")
+		}
+	}
+
+	log.Info().Msgf("Updated %v tasks to %v status for miner user ID %v", len(updatedTaskIds), status, minerUserId)
+	return updatedTaskIds, nil
+}
+
 // Modify GetCompletedTaskCount to use the new pattern
 func (o *TaskORM) GetCompletedTaskCount(ctx context.Context) (int, error) {
-	o.clientWrapper.BeforeQuery()
-	defer o.clientWrapper.AfterQuery()
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
 
 	var result []struct {
 		Count db.RawString `json:"count"`
@@ -298,8 +486,8 @@ func (o *TaskORM) GetCompletedTaskCount(ctx context.Context) (int, error) {
 }
 
 func (o *TaskORM) GetNextInProgressTask(ctx context.Context, taskId string, workerId string) (*db.TaskModel, error) {
-	o.clientWrapper.BeforeQuery()
-	defer o.clientWrapper.AfterQuery()
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
 
 	partners, err := o.dbClient.WorkerPartner.FindMany(
 		db.WorkerPartner.WorkerID.Equals(workerId),
@@ -0,0 +1,84 @@
+package orm
+
+import (
+	"context"
+	"time"
+
+	"dojo-api/db"
+)
+
+type TaskAgreementORM struct {
+	dbClient      *db.PrismaClient
+	clientWrapper *PrismaClientWrapper
+}
+
+func NewTaskAgreementORM() *TaskAgreementORM {
+	clientWrapper := GetPrismaClient()
+	return &TaskAgreementORM{
+		dbClient:      clientWrapper.Client,
+		clientWrapper: clientWrapper,
+	}
+}
+
+// AgreementRollupRow is a single time window of the agreement rollup, averages are nil when no task in the window had a defined value
+type AgreementRollupRow struct {
+	WindowStart           db.RawDateTime `json:"window_start"`
+	NumTasks              db.RawString   `json:"num_tasks"`
+	NumResults            db.RawString   `json:"num_results"`
+	MeanKrippendorffAlpha *db.RawFloat   `json:"mean_krippendorff_alpha"`
+	MeanKendallW          *db.RawFloat   `json:"mean_kendall_w"`
+}
+
+// UpsertTaskAgreement stores the latest agreement of a task, nil values are stored as null since they are undefined
+func (o *TaskAgreementORM) UpsertTaskAgreement(ctx context.Context, taskId string, numResults int, krippendorffAlpha *float64, kendallW *float64) (*db.TaskAgreementModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.TaskAgreement.UpsertOne(
+		db.TaskAgreement.TaskID.Equals(taskId),
+	).Create(
+		db.TaskAgreement.Task.Link(
+			db.Task.ID.Equals(taskId),
+		),
+		db.TaskAgreement.NumResults.Set(numResults),
+		db.TaskAgreement.KrippendorffAlpha.SetOptional(krippendorffAlpha),
+		db.TaskAgreement.KendallW.SetOptional(kendallW),
+	).Update(
+		db.TaskAgreement.NumResults.Set(numResults),
+		db.TaskAgreement.KrippendorffAlpha.SetOptional(krippendorffAlpha),
+		db.TaskAgreement.KendallW.SetOptional(kendallW),
+	).Exec(ctx)
+}
+
+func (o *TaskAgreementORM) GetByTaskId(ctx context.Context, taskId string) (*db.TaskAgreementModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.TaskAgreement.FindUnique(
+		db.TaskAgreement.TaskID.Equals(taskId),
+	).Exec(ctx)
+}
+
+// GetRollupByMinerUser averages the agreement of the miner's tasks per time window, tasks are bucketed by creation time
+// and interval must be a postgres date_trunc field, e.g. day, week or month
+func (o *TaskAgreementORM) GetRollupByMinerUser(ctx context.Context, minerUserId string, interval string, from time.Time, to time.Time) ([]AgreementRollupRow, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	query := `SELECT date_trunc($1, t.created_at) AS window_start,
+		COUNT(*) AS num_tasks,
+		SUM(a.num_results) AS num_results,
+		AVG(a.krippendorff_alpha) AS mean_krippendorff_alpha,
+		AVG(a.kendall_w) AS mean_kendall_w
+		FROM "TaskAgreement" a
+		JOIN "Task" t ON t.id = a.task_id
+		WHERE t.miner_user_id = $2 AND t.created_at >= $3 AND t.created_at < $4
+		GROUP BY window_start
+		ORDER BY window_start ASC;`
+
+	var rows []AgreementRollupRow
+	if err := o.clientWrapper.Client.Prisma.QueryRaw(query, interval, minerUserId, from, to).Exec(ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
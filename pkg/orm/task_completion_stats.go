@@ -0,0 +1,98 @@
+package orm
+
+import (
+	"context"
+
+	"dojo-api/db"
+
+	"github.com/google/uuid"
+)
+
+// adds one completion time to the task type's statistics in place, concurrent completions are serialised on the row
+const recordCompletionTimeQuery = `INSERT INTO "TaskCompletionStats" (id, updated_at, task_type, count, sum, min, max, buckets)
+	VALUES ($1, now(), $2::"TaskType", 1, $3, $3, $3, jsonb_build_object($4::text, 1))
+	ON CONFLICT (task_type) DO UPDATE SET
+		count = "TaskCompletionStats".count + 1,
+		sum = "TaskCompletionStats".sum + EXCLUDED.sum,
+		min = LEAST("TaskCompletionStats".min, EXCLUDED.min),
+		max = GREATEST("TaskCompletionStats".max, EXCLUDED.max),
+		buckets = jsonb_set("TaskCompletionStats".buckets, ARRAY[$4::text], to_jsonb(COALESCE(("TaskCompletionStats".buckets->>$4::text)::int, 0) + 1)),
+		updated_at = now();`
+
+// the completion times of the completion events per task type, an event of a deleted task is left out
+const completionEventTimesQuery = `SELECT t.type AS task_type, (e.events_data->>'task_completion_time')::float AS x
+	FROM "Events" e
+	JOIN "Task" t ON t.id = e.events_data->>'task_id'
+	WHERE e.type = 'TASK_COMPLETION_TIME'`
+
+// buckets use the same gamma of 1.01 / 0.99 as pkg/metric
+const rebuildCompletionStatsQuery = `INSERT INTO "TaskCompletionStats" (id, updated_at, task_type, count, sum, min, max, buckets)
+	SELECT gen_random_uuid()::text, now(), task_type, SUM(n), SUM(total), MIN(min_x), MAX(max_x), jsonb_object_agg(bucket, n)
+	FROM (
+		SELECT task_type, CASE WHEN x <= 0 THEN 'zero' ELSE ceil(ln(x) / ln(1.01 / 0.99))::int::text END AS bucket,
+			COUNT(*) AS n, SUM(x) AS total, MIN(x) AS min_x, MAX(x) AS max_x
+		FROM (` + completionEventTimesQuery + `) times
+		GROUP BY task_type, bucket
+	) buckets
+	GROUP BY task_type;`
+
+// CompletionEventStatsRow is the number and sum of the completion times of a task type's completion events
+type CompletionEventStatsRow struct {
+	TaskType db.TaskType  `json:"task_type"`
+	Count    db.RawString `json:"count"`
+	Sum      db.RawFloat  `json:"sum"`
+}
+
+type TaskCompletionStatsORM struct {
+	dbClient      *db.PrismaClient
+	clientWrapper *PrismaClientWrapper
+}
+
+func NewTaskCompletionStatsORM() *TaskCompletionStatsORM {
+	clientWrapper := GetPrismaClient()
+	return &TaskCompletionStatsORM{
+		dbClient:      clientWrapper.Client,
+		clientWrapper: clientWrapper,
+	}
+}
+
+// RecordCompletionTime counts the completion time into the given sketch bucket of the task type
+func (o *TaskCompletionStatsORM) RecordCompletionTime(ctx context.Context, taskType db.TaskType, seconds float64, bucket string) error {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	_, err := o.dbClient.Prisma.ExecuteRaw(recordCompletionTimeQuery, uuid.New().String(), string(taskType), seconds, bucket).Exec(ctx)
+	return err
+}
+
+func (o *TaskCompletionStatsORM) GetAll(ctx context.Context) ([]db.TaskCompletionStatsModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.TaskCompletionStats.FindMany().Exec(ctx)
+}
+
+// GetEventStats recomputes the number and sum of the completion times of every task type from the completion events
+func (o *TaskCompletionStatsORM) GetEventStats(ctx context.Context) ([]CompletionEventStatsRow, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	query := `SELECT task_type, COUNT(*) AS count, COALESCE(SUM(x), 0) AS sum FROM (` + completionEventTimesQuery + `) times
+		GROUP BY task_type;`
+
+	var rows []CompletionEventStatsRow
+	if err := o.dbClient.Prisma.QueryRaw(query).Exec(ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// RebuildFromEvents replaces the statistics of every task type with the ones recomputed from the completion events
+func (o *TaskCompletionStatsORM) RebuildFromEvents(ctx context.Context) error {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	deleteTx := o.dbClient.Prisma.ExecuteRaw(`DELETE FROM "TaskCompletionStats";`).Tx()
+	rebuildTx := o.dbClient.Prisma.ExecuteRaw(rebuildCompletionStatsQuery).Tx()
+	return o.dbClient.Prisma.Transaction(deleteTx, rebuildTx).Exec(ctx)
+}
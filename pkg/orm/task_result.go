@@ -7,11 +7,21 @@ import (
 	"time"
 
 	"dojo-api/db"
-	"dojo-api/pkg/cache"
 
+	sq "github.com/Masterminds/squirrel"
 	"github.com/rs/zerolog/log"
 )
 
+// a slot is only claimed while the task has fewer than max results, the last slot completes the task
+const claimResultSlotQuery = `UPDATE "Task" SET num_results = num_results + 1, updated_at = now(),
+		status = CASE WHEN num_results + 1 >= max_results THEN 'COMPLETED'::"TaskStatus" ELSE status END
+	WHERE id = $1 AND num_results < max_results;`
+
+// undoes claimResultSlotQuery, a task completed by the claim goes back to IN_PROGRESS
+const releaseResultSlotQuery = `UPDATE "Task" SET num_results = num_results - 1, updated_at = now(),
+		status = CASE WHEN status = 'COMPLETED' THEN 'IN_PROGRESS'::"TaskStatus" ELSE status END
+	WHERE id = $1 AND num_results > 0;`
+
 type TaskResultORM struct {
 	client        *db.PrismaClient
 	clientWrapper *PrismaClientWrapper
@@ -38,15 +48,70 @@ func (t *TaskResultORM) CreateTaskResult(ctx context.Context, taskResult *db.Inn
 }
 
 func (t *TaskResultORM) GetTaskResultsByTaskId(ctx context.Context, taskId string) ([]db.TaskResultModel, error) {
-	t.clientWrapper.BeforeQuery()
-	defer t.clientWrapper.AfterQuery()
+	queryTimer := t.clientWrapper.BeforeQuery()
+	defer t.clientWrapper.AfterQuery(queryTimer)
 
 	return t.client.TaskResult.FindMany(db.TaskResult.TaskID.Equals(taskId)).Exec(ctx)
 }
 
+// GetCompletedTaskResultsWithWorker returns the task's completed results along with the submitting worker and any collusion flag
+func (t *TaskResultORM) GetCompletedTaskResultsWithWorker(ctx context.Context, taskId string) ([]db.TaskResultModel, error) {
+	queryTimer := t.clientWrapper.BeforeQuery()
+	defer t.clientWrapper.AfterQuery(queryTimer)
+
+	return t.client.TaskResult.FindMany(
+		db.TaskResult.TaskID.Equals(taskId),
+		db.TaskResult.Status.Equals(db.TaskResultStatusCompleted),
+	).With(
+		db.TaskResult.DojoWorker.Fetch(),
+		db.TaskResult.ResultFlag.Fetch(),
+	).OrderBy(
+		db.TaskResult.CreatedAt.Order(db.SortOrderAsc),
+	).Exec(ctx)
+}
+
+// GetTaskResultsWithFlag returns every result of the task, whatever its status, along with any collusion flag
+func (t *TaskResultORM) GetTaskResultsWithFlag(ctx context.Context, taskId string) ([]db.TaskResultModel, error) {
+	queryTimer := t.clientWrapper.BeforeQuery()
+	defer t.clientWrapper.AfterQuery(queryTimer)
+
+	return t.client.TaskResult.FindMany(
+		db.TaskResult.TaskID.Equals(taskId),
+	).With(
+		db.TaskResult.ResultFlag.Fetch(),
+	).OrderBy(
+		db.TaskResult.CreatedAt.Order(db.SortOrderAsc),
+	).Exec(ctx)
+}
+
+// GetTaskResultsByMinerUser returns up to limit results of the miner's tasks with their task, oldest first,
+// starting after the cursor task result ID so large exports can be read in batches
+func (t *TaskResultORM) GetTaskResultsByMinerUser(ctx context.Context, minerUserId string, filterParams []db.TaskResultWhereParam, cursor string, limit int) ([]db.TaskResultModel, error) {
+	queryTimer := t.clientWrapper.BeforeQuery()
+	defer t.clientWrapper.AfterQuery(queryTimer)
+
+	filterParams = append(filterParams, db.TaskResult.Task.Where(
+		db.Task.MinerUserID.Equals(minerUserId),
+	))
+	query := t.client.TaskResult.FindMany(
+		filterParams...,
+	).With(
+		db.TaskResult.Task.Fetch(),
+	).OrderBy(
+		db.TaskResult.CreatedAt.Order(db.SortOrderAsc),
+		db.TaskResult.ID.Order(db.SortOrderAsc),
+	).Take(limit)
+
+	if cursor != "" {
+		query = query.Cursor(db.TaskResult.ID.Cursor(cursor)).Skip(1)
+	}
+
+	return query.Exec(ctx)
+}
+
 func (t *TaskResultORM) GetCompletedTResultByTaskAndWorker(ctx context.Context, taskId string, workerId string) ([]db.TaskResultModel, error) {
-	t.clientWrapper.BeforeQuery()
-	defer t.clientWrapper.AfterQuery()
+	queryTimer := t.clientWrapper.BeforeQuery()
+	defer t.clientWrapper.AfterQuery(queryTimer)
 
 	results, err := t.client.TaskResult.FindMany(
 		db.TaskResult.TaskID.Equals(taskId),
@@ -60,39 +125,117 @@ func (t *TaskResultORM) GetCompletedTResultByTaskAndWorker(ctx context.Context,
 	return results, nil
 }
 
-func (t *TaskResultORM) GetCompletedTResultByWorker(ctx context.Context, workerId string) ([]db.TaskResultModel, error) {
-	var results []db.TaskResultModel
-	cache := cache.GetCacheInstance()
-	cacheKey := cache.BuildCacheKey(cache.Keys.TaskResultByWorker, workerId)
+// GetCompletedTaskIdsByWorker returns which of the given tasks the worker already completed
+func (t *TaskResultORM) GetCompletedTaskIdsByWorker(ctx context.Context, workerId string, taskIds []string) (map[string]bool, error) {
+	queryTimer := t.clientWrapper.BeforeQuery()
+	defer t.clientWrapper.AfterQuery(queryTimer)
 
-	// Try to get from cache first
-	if err := cache.GetCacheValue(cacheKey, &results); err == nil {
-		return results, nil
+	completed := make(map[string]bool)
+	if len(taskIds) == 0 {
+		return completed, nil
 	}
 
-	// Cache miss, fetch from database
-	t.clientWrapper.BeforeQuery()
-	defer t.clientWrapper.AfterQuery()
-
 	results, err := t.client.TaskResult.FindMany(
 		db.TaskResult.WorkerID.Equals(workerId),
+		db.TaskResult.TaskID.In(taskIds),
 		db.TaskResult.Status.Equals(db.TaskResultStatusCompleted),
 	).Exec(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// Store in cache
-	if err := cache.SetCacheValue(cacheKey, results); err != nil {
-		log.Warn().Err(err).Msg("Failed to set task result cache")
+	for _, result := range results {
+		completed[result.TaskID] = true
 	}
+	return completed, nil
+}
 
-	return results, nil
+// GetWorkerHistory returns a page of the worker's results with their task, newest first, optionally limited to
+// task types and a creation time range, along with the total number of matching results
+func (t *TaskResultORM) GetWorkerHistory(ctx context.Context, workerId string, taskTypes []db.TaskType, from, to *time.Time, offset, limit int) ([]db.TaskResultModel, int, error) {
+	queryTimer := t.clientWrapper.BeforeQuery()
+	defer t.clientWrapper.AfterQuery(queryTimer)
+
+	filterParams := []db.TaskResultWhereParam{db.TaskResult.WorkerID.Equals(workerId)}
+	if len(taskTypes) > 0 {
+		filterParams = append(filterParams, db.TaskResult.Task.Where(db.Task.Type.In(taskTypes)))
+	}
+	if from != nil {
+		filterParams = append(filterParams, db.TaskResult.CreatedAt.Gte(*from))
+	}
+	if to != nil {
+		filterParams = append(filterParams, db.TaskResult.CreatedAt.Lte(*to))
+	}
+
+	results, err := t.client.TaskResult.FindMany(
+		filterParams...,
+	).With(
+		db.TaskResult.Task.Fetch(),
+	).OrderBy(
+		db.TaskResult.CreatedAt.Order(db.SortOrderDesc),
+		db.TaskResult.ID.Order(db.SortOrderDesc),
+	).Skip(offset).Take(limit).Exec(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := t.countWorkerHistory(ctx, workerId, taskTypes, from, to)
+	if err != nil {
+		return nil, 0, err
+	}
+	return results, total, nil
+}
+
+func (t *TaskResultORM) countWorkerHistory(ctx context.Context, workerId string, taskTypes []db.TaskType, from, to *time.Time) (int, error) {
+	query := sq.Select("count(*) as total").
+		From("\"TaskResult\" r").
+		Where(sq.Eq{"r.worker_id": workerId}).
+		PlaceholderFormat(sq.Dollar)
+	if len(taskTypes) > 0 {
+		types := make([]string, 0, len(taskTypes))
+		for _, taskType := range taskTypes {
+			types = append(types, string(taskType))
+		}
+		// compare as text since TaskType is a custom prisma enum type
+		query = query.Join("\"Task\" t ON t.id = r.task_id").Where(sq.Eq{"t.type::text": types})
+	}
+	if from != nil {
+		query = query.Where(sq.GtOrEq{"r.created_at": *from})
+	}
+	if to != nil {
+		query = query.Where(sq.LtOrEq{"r.created_at": *to})
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		log.Error().Err(err).Msg("Error building worker history count query")
+		return 0, err
+	}
+
+	var res []struct {
+		Total db.RawString `json:"total"`
+	}
+	if err := t.client.Prisma.QueryRaw(sql, args...).Exec(ctx, &res); err != nil {
+		return 0, err
+	}
+	if len(res) == 0 {
+		return 0, nil
+	}
+	return strconv.Atoi(string(res[0].Total))
+}
+
+// presetID returns the ID a result should be stored under when it was chosen before the result was created,
+// e.g. to lock the worker's stake under it
+func presetID(id string) *string {
+	if id == "" {
+		return nil
+	}
+	return &id
 }
 
 func (t *TaskResultORM) CreateTaskResultWithInvalid(ctx context.Context, taskResult *db.InnerTaskResult) (*db.TaskResultModel, error) {
-	t.clientWrapper.BeforeQuery()
-	defer t.clientWrapper.AfterQuery()
+	queryTimer := t.clientWrapper.BeforeQuery()
+	defer t.clientWrapper.AfterQuery(queryTimer)
 
 	createdTaskResult, err := t.client.TaskResult.CreateOne(
 		db.TaskResult.Status.Set(db.TaskResultStatusInvalid),
@@ -103,6 +246,14 @@ func (t *TaskResultORM) CreateTaskResultWithInvalid(ctx context.Context, taskRes
 		db.TaskResult.DojoWorker.Link(
 			db.DojoWorker.ID.Equals(taskResult.WorkerID),
 		),
+		db.TaskResult.Fingerprint.SetIfPresent(taskResult.Fingerprint),
+		db.TaskResult.ClientIP.SetIfPresent(taskResult.ClientIP),
+		db.TaskResult.TimeOnTask.SetIfPresent(taskResult.TimeOnTask),
+		db.TaskResult.ResponseOrder.Set(taskResult.ResponseOrder),
+		db.TaskResult.ID.SetIfPresent(presetID(taskResult.ID)),
+		db.TaskResult.StakeAmount.SetIfPresent(taskResult.StakeAmount),
+		db.TaskResult.PotentialReward.SetIfPresent(taskResult.PotentialReward),
+		db.TaskResult.PotentialLoss.SetIfPresent(taskResult.PotentialLoss),
 	).With(
 		db.TaskResult.Task.Fetch(),
 	).Exec(ctx)
@@ -112,28 +263,24 @@ func (t *TaskResultORM) CreateTaskResultWithInvalid(ctx context.Context, taskRes
 	return createdTaskResult, nil
 }
 
+// CreateTaskResultWithCompleted claims one of the task's result slots and creates the result, the task is completed
+// with its last slot. When the task already has its max results the result is created as INVALID instead.
 func (t *TaskResultORM) CreateTaskResultWithCompleted(ctx context.Context, taskResult *db.InnerTaskResult) (*db.TaskResultModel, error) {
-	t.clientWrapper.BeforeQuery()
-	defer t.clientWrapper.AfterQuery()
+	queryTimer := t.clientWrapper.BeforeQuery()
+	defer t.clientWrapper.AfterQuery(queryTimer)
 
-	// Retrieve the task object from the appropriate source
-	task, err := t.client.Task.FindUnique(db.Task.ID.Equals(taskResult.TaskID)).Exec(ctx)
+	// the slot is claimed in a single conditional update so concurrent submissions can never go past max results
+	claimed, err := t.client.Prisma.ExecuteRaw(claimResultSlotQuery, taskResult.TaskID).Exec(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	updateTaskParams := []db.TaskSetParam{
-		db.Task.NumResults.Increment(1),
-		db.Task.UpdatedAt.Set(time.Now()),
-	}
-	// Check if num_results equals max_results before updating the task status
-	if task.NumResults+1 == task.MaxResults && task.Status != db.TaskStatusCompleted {
-		updateTaskParams = append(updateTaskParams, db.Task.Status.Set(db.TaskStatusCompleted))
+	if claimed.Count == 0 {
+		log.Info().Str("taskId", taskResult.TaskID).Msg("Task has reached max results")
+		taskResult.GoldPassed = nil
+		return t.CreateTaskResultWithInvalid(ctx, taskResult)
 	}
 
-	// TODO add web3 integration fields when the time comes
-	updateTaskTx := t.client.Task.FindUnique(db.Task.ID.Equals(taskResult.TaskID)).Update(updateTaskParams...).Tx()
-
+	// finalised_reward and finalised_loss stay unset until the task is settled, see pkg/settlement
 	createResultTx := t.client.TaskResult.CreateOne(
 		db.TaskResult.Status.Set(db.TaskResultStatusCompleted),
 		db.TaskResult.ResultData.Set(taskResult.ResultData),
@@ -143,19 +290,85 @@ func (t *TaskResultORM) CreateTaskResultWithCompleted(ctx context.Context, taskR
 		db.TaskResult.DojoWorker.Link(
 			db.DojoWorker.ID.Equals(taskResult.WorkerID),
 		),
+		db.TaskResult.GoldPassed.SetIfPresent(taskResult.GoldPassed),
+		db.TaskResult.Fingerprint.SetIfPresent(taskResult.Fingerprint),
+		db.TaskResult.ClientIP.SetIfPresent(taskResult.ClientIP),
+		db.TaskResult.TimeOnTask.SetIfPresent(taskResult.TimeOnTask),
+		db.TaskResult.ResponseOrder.Set(taskResult.ResponseOrder),
+		db.TaskResult.ID.SetIfPresent(presetID(taskResult.ID)),
+		db.TaskResult.StakeAmount.SetIfPresent(taskResult.StakeAmount),
+		db.TaskResult.PotentialReward.SetIfPresent(taskResult.PotentialReward),
+		db.TaskResult.PotentialLoss.SetIfPresent(taskResult.PotentialLoss),
 	).With(
 		db.TaskResult.Task.Fetch(),
 	).Tx()
 
-	if err := t.client.Prisma.Transaction(updateTaskTx, createResultTx).Exec(ctx); err != nil {
+	txs := []db.PrismaTransaction{createResultTx}
+	// graded gold submissions update the worker's accuracy in the same transaction
+	if taskResult.GoldPassed != nil {
+		numPassed := 0
+		if *taskResult.GoldPassed {
+			numPassed = 1
+		}
+		upsertAccuracyTx := t.client.WorkerGoldAccuracy.UpsertOne(
+			db.WorkerGoldAccuracy.WorkerID.Equals(taskResult.WorkerID),
+		).Create(
+			db.WorkerGoldAccuracy.DojoWorker.Link(
+				db.DojoWorker.ID.Equals(taskResult.WorkerID),
+			),
+			db.WorkerGoldAccuracy.NumGraded.Set(1),
+			db.WorkerGoldAccuracy.NumPassed.Set(numPassed),
+		).Update(
+			db.WorkerGoldAccuracy.NumGraded.Increment(1),
+			db.WorkerGoldAccuracy.NumPassed.Increment(numPassed),
+		).Tx()
+		txs = append(txs, upsertAccuracyTx)
+	}
+
+	if err := t.client.Prisma.Transaction(txs...).Exec(ctx); err != nil {
+		// give the slot back so the task can still collect its max results
+		if _, releaseErr := t.client.Prisma.ExecuteRaw(releaseResultSlotQuery, taskResult.TaskID).Exec(ctx); releaseErr != nil {
+			log.Error().Err(releaseErr).Str("taskId", taskResult.TaskID).Msg("Failed to release result slot")
+		}
 		return nil, err
 	}
 	return createResultTx.Result(), nil
 }
 
+// GetResultStatusCountsByWorker returns the total number of results a worker submitted and how many of them were INVALID
+func (t *TaskResultORM) GetResultStatusCountsByWorker(ctx context.Context, workerId string) (int, int, error) {
+	queryTimer := t.clientWrapper.BeforeQuery()
+	defer t.clientWrapper.AfterQuery(queryTimer)
+
+	var result []struct {
+		Total   db.RawString `json:"total"`
+		Invalid db.RawString `json:"invalid"`
+	}
+
+	query := "SELECT COUNT(*) as total, COUNT(*) FILTER (WHERE status = 'INVALID') as invalid FROM \"TaskResult\" WHERE worker_id = $1;"
+	err := t.clientWrapper.Client.Prisma.QueryRaw(query, workerId).Exec(ctx, &result)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if len(result) == 0 {
+		return 0, 0, fmt.Errorf("no results found for worker result counts query")
+	}
+
+	total, err := strconv.Atoi(string(result[0].Total))
+	if err != nil {
+		return 0, 0, err
+	}
+	invalid, err := strconv.Atoi(string(result[0].Invalid))
+	if err != nil {
+		return 0, 0, err
+	}
+	return total, invalid, nil
+}
+
 func (t *TaskResultORM) GetCompletedTResultCount(ctx context.Context) (int, error) {
-	t.clientWrapper.BeforeQuery()
-	defer t.clientWrapper.AfterQuery()
+	queryTimer := t.clientWrapper.BeforeQuery()
+	defer t.clientWrapper.AfterQuery(queryTimer)
 
 	var result []struct {
 		Count db.RawString `json:"count"`
@@ -179,3 +392,86 @@ func (t *TaskResultORM) GetCompletedTResultCount(ctx context.Context) (int, erro
 
 	return taskResultCountInt, nil
 }
+
+// WorkerEarningsRow sums the worker's results of one miner within one time window, results that are not
+// settled yet only count towards the pending amounts
+type WorkerEarningsRow struct {
+	PeriodStart     db.RawDateTime `json:"period_start"`
+	MinerUserID     *string        `json:"miner_user_id"`
+	MinerHotkey     *string        `json:"miner_hotkey"`
+	NumResults      db.RawString   `json:"num_results"`
+	NumSettled      db.RawString   `json:"num_settled"`
+	FinalisedReward db.RawFloat    `json:"finalised_reward"`
+	FinalisedLoss   db.RawFloat    `json:"finalised_loss"`
+	PendingReward   db.RawFloat    `json:"pending_reward"`
+	PendingLoss     db.RawFloat    `json:"pending_loss"`
+}
+
+// GetTaskResultsByWorker returns a page of the worker's results created within [from, to) with their task and
+// the task's miner, newest first
+func (t *TaskResultORM) GetTaskResultsByWorker(ctx context.Context, workerId string, from time.Time, to time.Time, offset, limit int) ([]db.TaskResultModel, error) {
+	queryTimer := t.clientWrapper.BeforeQuery()
+	defer t.clientWrapper.AfterQuery(queryTimer)
+
+	return t.client.TaskResult.FindMany(
+		db.TaskResult.WorkerID.Equals(workerId),
+		db.TaskResult.CreatedAt.Gte(from),
+		db.TaskResult.CreatedAt.Lt(to),
+	).With(
+		db.TaskResult.Task.Fetch().With(
+			db.Task.MinerUser.Fetch(),
+		),
+	).OrderBy(
+		db.TaskResult.CreatedAt.Order(db.SortOrderDesc),
+		db.TaskResult.ID.Order(db.SortOrderDesc),
+	).Skip(offset).Take(limit).Exec(ctx)
+}
+
+func (t *TaskResultORM) CountTaskResultsByWorker(ctx context.Context, workerId string, from time.Time, to time.Time) (int, error) {
+	queryTimer := t.clientWrapper.BeforeQuery()
+	defer t.clientWrapper.AfterQuery(queryTimer)
+
+	var result []struct {
+		Total db.RawString `json:"total"`
+	}
+
+	query := "SELECT COUNT(*) as total FROM \"TaskResult\" WHERE worker_id = $1 AND created_at >= $2 AND created_at < $3;"
+	if err := t.client.Prisma.QueryRaw(query, workerId, from, to).Exec(ctx, &result); err != nil {
+		return 0, err
+	}
+	if len(result) == 0 {
+		return 0, nil
+	}
+	return strconv.Atoi(string(result[0].Total))
+}
+
+// GetWorkerEarnings sums the worker's rewards and losses per time window and miner, results are bucketed by
+// submission time and interval must be a postgres date_trunc field, e.g. day, week or month. Pending rewards
+// and losses only count COMPLETED results since INVALID ones are neither paid nor lose anything, see
+// settlement.ComputeShares.
+func (t *TaskResultORM) GetWorkerEarnings(ctx context.Context, workerId string, interval string, from time.Time, to time.Time) ([]WorkerEarningsRow, error) {
+	queryTimer := t.clientWrapper.BeforeQuery()
+	defer t.clientWrapper.AfterQuery(queryTimer)
+
+	query := `SELECT date_trunc($1, r.created_at) AS period_start,
+		t.miner_user_id,
+		m.hotkey AS miner_hotkey,
+		COUNT(*) AS num_results,
+		COUNT(r.finalised_reward) AS num_settled,
+		COALESCE(SUM(r.finalised_reward), 0) AS finalised_reward,
+		COALESCE(SUM(r.finalised_loss), 0) AS finalised_loss,
+		COALESCE(SUM(r.potential_reward) FILTER (WHERE r.finalised_reward IS NULL AND r.status = 'COMPLETED'), 0) AS pending_reward,
+		COALESCE(SUM(r.potential_loss) FILTER (WHERE r.finalised_reward IS NULL AND r.status = 'COMPLETED'), 0) AS pending_loss
+		FROM "TaskResult" r
+		JOIN "Task" t ON t.id = r.task_id
+		LEFT JOIN "MinerUser" m ON m.id = t.miner_user_id
+		WHERE r.worker_id = $2 AND r.created_at >= $3 AND r.created_at < $4
+		GROUP BY period_start, t.miner_user_id, m.hotkey
+		ORDER BY period_start ASC, t.miner_user_id ASC;`
+
+	var rows []WorkerEarningsRow
+	if err := t.client.Prisma.QueryRaw(query, interval, workerId, from, to).Exec(ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
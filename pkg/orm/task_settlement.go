@@ -0,0 +1,118 @@
+package orm
+
+import (
+	"context"
+
+	"dojo-api/db"
+)
+
+type TaskSettlementORM struct {
+	dbClient      *db.PrismaClient
+	clientWrapper *PrismaClientWrapper
+}
+
+func NewTaskSettlementORM() *TaskSettlementORM {
+	clientWrapper := GetPrismaClient()
+	return &TaskSettlementORM{
+		dbClient:      clientWrapper.Client,
+		clientWrapper: clientWrapper,
+	}
+}
+
+// ResultSettlement is the finalised reward and loss of one task result
+type ResultSettlement struct {
+	TaskResultID string
+	Reward       float64
+	Loss         float64
+}
+
+type unsettledTaskRow struct {
+	ID string `json:"id"`
+}
+
+// SaveSettlement stores the settlement of a task and the finalised fields of its results in a single transaction,
+// saving the same settlement again overwrites it with the same values
+func (o *TaskSettlementORM) SaveSettlement(ctx context.Context, settlement db.InnerTaskSettlement, results []ResultSettlement) (*db.TaskSettlementModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	upsertTx := o.dbClient.TaskSettlement.UpsertOne(
+		db.TaskSettlement.TaskID.Equals(settlement.TaskID),
+	).Create(
+		db.TaskSettlement.Task.Link(
+			db.Task.ID.Equals(settlement.TaskID),
+		),
+		db.TaskSettlement.Policy.Set(settlement.Policy),
+		db.TaskSettlement.TotalReward.Set(settlement.TotalReward),
+		db.TaskSettlement.TotalPaid.Set(settlement.TotalPaid),
+		db.TaskSettlement.NumValid.Set(settlement.NumValid),
+		db.TaskSettlement.Shares.Set(settlement.Shares),
+	).Update(
+		db.TaskSettlement.Policy.Set(settlement.Policy),
+		db.TaskSettlement.TotalReward.Set(settlement.TotalReward),
+		db.TaskSettlement.TotalPaid.Set(settlement.TotalPaid),
+		db.TaskSettlement.NumValid.Set(settlement.NumValid),
+		db.TaskSettlement.Shares.Set(settlement.Shares),
+	).Tx()
+
+	txs := []db.PrismaTransaction{upsertTx}
+	for _, result := range results {
+		updateTx := o.dbClient.TaskResult.FindUnique(
+			db.TaskResult.ID.Equals(result.TaskResultID),
+		).Update(
+			db.TaskResult.FinalisedReward.Set(result.Reward),
+			db.TaskResult.FinalisedLoss.Set(result.Loss),
+		).Tx()
+		txs = append(txs, updateTx)
+	}
+
+	if err := o.dbClient.Prisma.Transaction(txs...).Exec(ctx); err != nil {
+		return nil, err
+	}
+	return upsertTx.Result(), nil
+}
+
+func (o *TaskSettlementORM) GetByTaskId(ctx context.Context, taskId string) (*db.TaskSettlementModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.TaskSettlement.FindUnique(
+		db.TaskSettlement.TaskID.Equals(taskId),
+	).Exec(ctx)
+}
+
+// GetUnsettledTasks returns up to limit finished tasks that were never settled or changed after they were settled,
+// e.g. because a late result was stored or a flag was reviewed. Tasks with collusion flags awaiting review are left
+// out until they are reviewed, flags raised only for a too fast submission do not hold the task. Tasks are read from the database since a cached status may be stale.
+func (o *TaskSettlementORM) GetUnsettledTasks(ctx context.Context, limit int) ([]db.TaskModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	query := `SELECT t.id FROM "Task" t
+		LEFT JOIN "TaskSettlement" s ON s.task_id = t.id
+		WHERE t.status IN ('COMPLETED', 'EXPIRED', 'CANCELLED') AND (s.id IS NULL OR s.updated_at < t.updated_at
+			OR EXISTS (SELECT 1 FROM "TaskResult" r WHERE r.task_id = t.id AND r.created_at > s.updated_at)
+			OR EXISTS (SELECT 1 FROM "TaskResult" r JOIN "ResultFlag" f ON f.task_result_id = r.id
+				WHERE r.task_id = t.id AND f.updated_at > s.updated_at))
+		AND NOT EXISTS (SELECT 1 FROM "TaskResult" r JOIN "ResultFlag" f ON f.task_result_id = r.id
+			WHERE r.task_id = t.id AND f.status = 'PENDING' AND NOT f.reasons <@ ARRAY['too_fast'])
+		ORDER BY t.updated_at ASC
+		LIMIT $1;`
+
+	var rows []unsettledTaskRow
+	if err := o.clientWrapper.Client.Prisma.QueryRaw(query, limit).Exec(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return []db.TaskModel{}, nil
+	}
+
+	taskIds := make([]string, 0, len(rows))
+	for _, row := range rows {
+		taskIds = append(taskIds, row.ID)
+	}
+	return o.dbClient.Task.FindMany(
+		db.Task.ID.In(taskIds),
+	).Exec(ctx)
+}
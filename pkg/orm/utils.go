@@ -6,11 +6,14 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"runtime"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"dojo-api/db"
+	"dojo-api/pkg/monitoring"
 	"dojo-api/utils"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -29,12 +32,56 @@ type PrismaClientWrapper struct {
 	Client       *db.PrismaClient
 }
 
-func (p *PrismaClientWrapper) BeforeQuery() {
+// QueryTimer is returned by BeforeQuery and handed back to AfterQuery to measure the query
+type QueryTimer struct {
+	operation string
+	start     time.Time
+}
+
+// BeforeQuery tracks the query for graceful shutdown and starts timing it, the operation is the calling ORM
+// method, e.g. TaskORM.GetById
+func (p *PrismaClientWrapper) BeforeQuery() QueryTimer {
 	p.QueryTracker.BeforeQuery()
+	monitoring.DBActiveQueries.Inc()
+	return QueryTimer{operation: callerOperation(), start: time.Now()}
 }
 
-func (p *PrismaClientWrapper) AfterQuery() {
+func (p *PrismaClientWrapper) AfterQuery(timer QueryTimer) {
 	p.QueryTracker.AfterQuery()
+	monitoring.DBActiveQueries.Dec()
+	monitoring.DBQueriesTotal.WithLabelValues(timer.operation).Inc()
+	monitoring.DBQueryDuration.WithLabelValues(timer.operation).Observe(time.Since(timer.start).Seconds())
+}
+
+// callerOperation returns the operation name of the method calling BeforeQuery
+func callerOperation() string {
+	pc, _, _, ok := runtime.Caller(2)
+	if !ok {
+		return "unknown"
+	}
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "unknown"
+	}
+	return operationName(fn.Name())
+}
+
+// operationName strips the import path, package and pointer receiver decoration from a function name, e.g.
+// dojo-api/pkg/orm.(*TaskORM).GetById becomes TaskORM.GetById
+func operationName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	// drop the package and the pointer receiver decoration
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+	// closures are reported as their enclosing method
+	if i := strings.Index(name, ".func"); i >= 0 {
+		name = name[:i]
+	}
+	return name
 }
 
 type QueryTracker struct {
@@ -0,0 +1,39 @@
+package orm
+
+import "testing"
+
+func TestOperationName(t *testing.T) {
+	tests := []struct {
+		name     string
+		funcName string
+		want     string
+	}{
+		{"pointer receiver", "dojo-api/pkg/orm.(*TaskORM).GetById", "TaskORM.GetById"},
+		{"value receiver", "dojo-api/pkg/orm.TaskORM.GetById", "TaskORM.GetById"},
+		{"plain function", "dojo-api/pkg/orm.GetPrismaClient", "GetPrismaClient"},
+		{"closure", "dojo-api/pkg/orm.(*TaskORM).UpdateExpiredTasks.func1", "TaskORM.UpdateExpiredTasks"},
+		{"nested closure", "dojo-api/pkg/orm.(*MetricsORM).GetSourceCounts.func2.1", "MetricsORM.GetSourceCounts"},
+		{"generic receiver", "dojo-api/pkg/orm.(*Repo[...]).Find", "Repo[...].Find"},
+		{"without import path", "orm.(*TaskResultORM).CreateTaskResult", "TaskResultORM.CreateTaskResult"},
+		{"dotted import path", "github.com/org/repo.v2/pkg/orm.(*TaskORM).GetById", "TaskORM.GetById"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := operationName(tt.funcName); got != tt.want {
+				t.Errorf("operationName(%q) = %q, want %q", tt.funcName, got, tt.want)
+			}
+		})
+	}
+}
+
+// callerOperation is called from BeforeQuery, so it names the method two frames up
+func TestCallerOperation(t *testing.T) {
+	var got string
+	func() {
+		got = callerOperation()
+	}()
+	if want := "TestCallerOperation"; got != want {
+		t.Errorf("callerOperation() = %q, want %q", got, want)
+	}
+}
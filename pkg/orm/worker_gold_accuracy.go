@@ -0,0 +1,48 @@
+package orm
+
+import (
+	"context"
+
+	"dojo-api/db"
+)
+
+type WorkerGoldAccuracyORM struct {
+	dbClient      *db.PrismaClient
+	clientWrapper *PrismaClientWrapper
+}
+
+func NewWorkerGoldAccuracyORM() *WorkerGoldAccuracyORM {
+	clientWrapper := GetPrismaClient()
+	return &WorkerGoldAccuracyORM{
+		dbClient:      clientWrapper.Client,
+		clientWrapper: clientWrapper,
+	}
+}
+
+func (o *WorkerGoldAccuracyORM) GetByWorkerId(ctx context.Context, workerId string) (*db.WorkerGoldAccuracyModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.WorkerGoldAccuracy.FindUnique(
+		db.WorkerGoldAccuracy.WorkerID.Equals(workerId),
+	).Exec(ctx)
+}
+
+// GetByMinerUser returns the accuracy records of every worker that submitted a result to one of the miner's gold tasks
+func (o *WorkerGoldAccuracyORM) GetByMinerUser(ctx context.Context, minerUserId string) ([]db.WorkerGoldAccuracyModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.WorkerGoldAccuracy.FindMany(
+		db.WorkerGoldAccuracy.DojoWorker.Where(
+			db.DojoWorker.TaskResults.Some(
+				db.TaskResult.Task.Where(
+					db.Task.MinerUserID.Equals(minerUserId),
+					db.Task.IsGold.Equals(true),
+				),
+			),
+		),
+	).OrderBy(
+		db.WorkerGoldAccuracy.UpdatedAt.Order(db.SortOrderDesc),
+	).Exec(ctx)
+}
@@ -21,8 +21,8 @@ func NewWorkerPartnerORM() *WorkerPartnerORM {
 }
 
 func (m *WorkerPartnerORM) CreateWorkerPartner(workerId string, subscriptionId string, optionalName string) (*db.WorkerPartnerModel, error) {
-	m.clientWrapper.BeforeQuery()
-	defer m.clientWrapper.AfterQuery()
+	queryTimer := m.clientWrapper.BeforeQuery()
+	defer m.clientWrapper.AfterQuery(queryTimer)
 
 	ctx := context.Background()
 
@@ -49,8 +49,8 @@ func (m *WorkerPartnerORM) CreateWorkerPartner(workerId string, subscriptionId s
 }
 
 func (m *WorkerPartnerORM) UpdateSubscriptionKey(workerId string, minerSubscriptionKey string, newMinerSubscriptionKey string, name string) (*db.WorkerPartnerModel, error) {
-	m.clientWrapper.BeforeQuery()
-	defer m.clientWrapper.AfterQuery()
+	queryTimer := m.clientWrapper.BeforeQuery()
+	defer m.clientWrapper.AfterQuery(queryTimer)
 	ctx := context.Background()
 
 	type RawQueryParams struct {
@@ -126,8 +126,8 @@ func (m *WorkerPartnerORM) UpdateSubscriptionKey(workerId string, minerSubscript
 }
 
 func (m *WorkerPartnerORM) DisablePartnerByWorker(workerId string, minerSubscriptionKey string, toDisable bool) (int, error) {
-	m.clientWrapper.BeforeQuery()
-	defer m.clientWrapper.AfterQuery()
+	queryTimer := m.clientWrapper.BeforeQuery()
+	defer m.clientWrapper.AfterQuery(queryTimer)
 
 	ctx := context.Background()
 
@@ -151,8 +151,8 @@ func (m *WorkerPartnerORM) DisablePartnerByWorker(workerId string, minerSubscrip
 }
 
 func (m *WorkerPartnerORM) DisablePartnerByMiner(workerId string, minerSubscriptionKey string, toDisable bool) (int, error) {
-	m.clientWrapper.BeforeQuery()
-	defer m.clientWrapper.AfterQuery()
+	queryTimer := m.clientWrapper.BeforeQuery()
+	defer m.clientWrapper.AfterQuery(queryTimer)
 
 	ctx := context.Background()
 
@@ -176,8 +176,8 @@ func (m *WorkerPartnerORM) DisablePartnerByMiner(workerId string, minerSubscript
 }
 
 func (m *WorkerPartnerORM) GetWorkerPartnerByWorkerId(workerId string) ([]db.WorkerPartnerModel, error) {
-	m.clientWrapper.BeforeQuery()
-	defer m.clientWrapper.AfterQuery()
+	queryTimer := m.clientWrapper.BeforeQuery()
+	defer m.clientWrapper.AfterQuery(queryTimer)
 
 	ctx := context.Background()
 
@@ -191,8 +191,8 @@ func (m *WorkerPartnerORM) GetWorkerPartnerByWorkerId(workerId string) ([]db.Wor
 }
 
 func (m *WorkerPartnerORM) GetWorkerPartnerByWorkerIdAndSubscriptionKey(workerId string, minerSubscriptionKey string) (*db.WorkerPartnerModel, error) {
-	m.clientWrapper.BeforeQuery()
-	defer m.clientWrapper.AfterQuery()
+	queryTimer := m.clientWrapper.BeforeQuery()
+	defer m.clientWrapper.AfterQuery(queryTimer)
 
 	ctx := context.Background()
 	workerPartner, err := m.dbClient.WorkerPartner.FindFirst(
@@ -0,0 +1,92 @@
+package orm
+
+import (
+	"context"
+	"strconv"
+
+	"dojo-api/db"
+
+	"github.com/google/uuid"
+)
+
+// the agreement is added to the running sum in the same statement that reads it, so concurrent submissions by the
+// worker never overwrite each other's agreement, the other components are overwritten right after
+const addConsensusAgreementQuery = `INSERT INTO "WorkerReputation"
+		(id, updated_at, worker_id, score, gold_accuracy, consensus_agreement, invalid_rate, account_age_days, consensus_sum, consensus_count)
+	VALUES ($1, now(), $2, 0, 0, 0, 0, 0, $3, 1)
+	ON CONFLICT (worker_id) DO UPDATE SET
+		consensus_sum = "WorkerReputation".consensus_sum + EXCLUDED.consensus_sum,
+		consensus_count = "WorkerReputation".consensus_count + 1,
+		updated_at = now()
+	RETURNING consensus_sum, consensus_count::text AS consensus_count;`
+
+type WorkerReputationORM struct {
+	dbClient      *db.PrismaClient
+	clientWrapper *PrismaClientWrapper
+}
+
+func NewWorkerReputationORM() *WorkerReputationORM {
+	clientWrapper := GetPrismaClient()
+	return &WorkerReputationORM{
+		dbClient:      clientWrapper.Client,
+		clientWrapper: clientWrapper,
+	}
+}
+
+func (o *WorkerReputationORM) GetByWorkerId(ctx context.Context, workerId string) (*db.WorkerReputationModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.WorkerReputation.FindUnique(
+		db.WorkerReputation.WorkerID.Equals(workerId),
+	).Exec(ctx)
+}
+
+// AddConsensusAgreement adds the agreement of one submission to the worker's consensus component and returns the
+// updated sum and count
+func (o *WorkerReputationORM) AddConsensusAgreement(ctx context.Context, workerId string, agreement float64) (float64, int, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	var rows []struct {
+		ConsensusSum   db.RawFloat  `json:"consensus_sum"`
+		ConsensusCount db.RawString `json:"consensus_count"`
+	}
+	if err := o.dbClient.Prisma.QueryRaw(addConsensusAgreementQuery, uuid.New().String(), workerId, agreement).Exec(ctx, &rows); err != nil {
+		return 0, 0, err
+	}
+	if len(rows) == 0 {
+		return 0, 0, db.ErrNotFound
+	}
+	count, err := strconv.Atoi(string(rows[0].ConsensusCount))
+	if err != nil {
+		return 0, 0, err
+	}
+	return float64(rows[0].ConsensusSum), count, nil
+}
+
+// UpsertWorkerReputation overwrites the components of the worker's reputation with the given values, the consensus
+// sum and count are only changed through AddConsensusAgreement
+func (o *WorkerReputationORM) UpsertWorkerReputation(ctx context.Context, reputation db.InnerWorkerReputation) (*db.WorkerReputationModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.WorkerReputation.UpsertOne(
+		db.WorkerReputation.WorkerID.Equals(reputation.WorkerID),
+	).Create(
+		db.WorkerReputation.DojoWorker.Link(
+			db.DojoWorker.ID.Equals(reputation.WorkerID),
+		),
+		db.WorkerReputation.Score.Set(reputation.Score),
+		db.WorkerReputation.GoldAccuracy.Set(reputation.GoldAccuracy),
+		db.WorkerReputation.ConsensusAgreement.Set(reputation.ConsensusAgreement),
+		db.WorkerReputation.InvalidRate.Set(reputation.InvalidRate),
+		db.WorkerReputation.AccountAgeDays.Set(reputation.AccountAgeDays),
+	).Update(
+		db.WorkerReputation.Score.Set(reputation.Score),
+		db.WorkerReputation.GoldAccuracy.Set(reputation.GoldAccuracy),
+		db.WorkerReputation.ConsensusAgreement.Set(reputation.ConsensusAgreement),
+		db.WorkerReputation.InvalidRate.Set(reputation.InvalidRate),
+		db.WorkerReputation.AccountAgeDays.Set(reputation.AccountAgeDays),
+	).Exec(ctx)
+}
@@ -0,0 +1,74 @@
+package orm
+
+import (
+	"context"
+
+	"dojo-api/db"
+)
+
+// ScoreSample is one submitted score normalised to [0, 1] along with the bucket it falls into
+type ScoreSample struct {
+	CriteriaType string
+	Bucket       int
+	Value        float64
+}
+
+type WorkerScoreBucketORM struct {
+	dbClient      *db.PrismaClient
+	clientWrapper *PrismaClientWrapper
+}
+
+func NewWorkerScoreBucketORM() *WorkerScoreBucketORM {
+	clientWrapper := GetPrismaClient()
+	return &WorkerScoreBucketORM{
+		dbClient:      clientWrapper.Client,
+		clientWrapper: clientWrapper,
+	}
+}
+
+// AddScoreSamples counts the samples into the worker's buckets in a single transaction,
+// the counters are incremented in place so concurrent submissions do not overwrite each other
+func (o *WorkerScoreBucketORM) AddScoreSamples(ctx context.Context, workerId string, samples []ScoreSample) error {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	if len(samples) == 0 {
+		return nil
+	}
+
+	txs := make([]db.PrismaTransaction, 0, len(samples))
+	for _, sample := range samples {
+		upsertTx := o.dbClient.WorkerScoreBucket.UpsertOne(
+			db.WorkerScoreBucket.WorkerIDCriteriaTypeBucket(
+				db.WorkerScoreBucket.WorkerID.Equals(workerId),
+				db.WorkerScoreBucket.CriteriaType.Equals(sample.CriteriaType),
+				db.WorkerScoreBucket.Bucket.Equals(sample.Bucket),
+			),
+		).Create(
+			db.WorkerScoreBucket.DojoWorker.Link(
+				db.DojoWorker.ID.Equals(workerId),
+			),
+			db.WorkerScoreBucket.CriteriaType.Set(sample.CriteriaType),
+			db.WorkerScoreBucket.Bucket.Set(sample.Bucket),
+			db.WorkerScoreBucket.Count.Set(1),
+			db.WorkerScoreBucket.Sum.Set(sample.Value),
+			db.WorkerScoreBucket.SumSq.Set(sample.Value*sample.Value),
+		).Update(
+			db.WorkerScoreBucket.Count.Increment(1),
+			db.WorkerScoreBucket.Sum.Increment(sample.Value),
+			db.WorkerScoreBucket.SumSq.Increment(sample.Value*sample.Value),
+		).Tx()
+		txs = append(txs, upsertTx)
+	}
+
+	return o.dbClient.Prisma.Transaction(txs...).Exec(ctx)
+}
+
+func (o *WorkerScoreBucketORM) GetByWorkerIds(ctx context.Context, workerIds []string) ([]db.WorkerScoreBucketModel, error) {
+	queryTimer := o.clientWrapper.BeforeQuery()
+	defer o.clientWrapper.AfterQuery(queryTimer)
+
+	return o.dbClient.WorkerScoreBucket.FindMany(
+		db.WorkerScoreBucket.WorkerID.In(workerIds),
+	).Exec(ctx)
+}
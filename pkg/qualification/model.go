@@ -0,0 +1,108 @@
+package qualification
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"dojo-api/db"
+	"dojo-api/pkg/task"
+)
+
+// QualificationQuestion is a single gold task of a qualification test, graded the same way as gold tasks
+type QualificationQuestion struct {
+	TaskData    task.TaskData     `json:"taskData"`
+	GoldAnswers []task.GoldAnswer `json:"goldAnswers"`
+}
+
+type CreateQualificationTestRequest struct {
+	Title    string      `json:"title"`
+	TaskType db.TaskType `json:"taskType"`
+	// only workers partnered through this key need to pass the test, all partnered workers when empty
+	SubscriptionKey *string                 `json:"subscriptionKey,omitempty"`
+	Questions       []QualificationQuestion `json:"questions"`
+	// fraction of questions that must be answered correctly, between 0 and 1
+	PassThreshold float64 `json:"passThreshold"`
+	// hours a worker has to wait after a failed attempt before retaking the test
+	CooldownHours int `json:"cooldownHours"`
+}
+
+type QualificationTestResponse struct {
+	TestId          string      `json:"testId"`
+	Title           string      `json:"title"`
+	TaskType        db.TaskType `json:"taskType"`
+	SubscriptionKey *string     `json:"subscriptionKey,omitempty"`
+	NumQuestions    int         `json:"numQuestions"`
+	PassThreshold   float64     `json:"passThreshold"`
+	CooldownHours   int         `json:"cooldownHours"`
+	IsActive        bool        `json:"isActive"`
+	CreatedAt       time.Time   `json:"createdAt"`
+}
+
+type MinerQualificationTestListResponse struct {
+	Tests []QualificationTestResponse `json:"tests"`
+}
+
+// WorkerQualificationResponse is a test the worker has to pass along with the worker's latest attempt, if any
+type WorkerQualificationResponse struct {
+	TestId        string                  `json:"testId"`
+	Title         string                  `json:"title"`
+	TaskType      db.TaskType             `json:"taskType"`
+	NumQuestions  int                     `json:"numQuestions"`
+	PassThreshold float64                 `json:"passThreshold"`
+	Status        *db.QualificationStatus `json:"status"`
+	Score         *float64                `json:"score"`
+	Attempts      int                     `json:"attempts"`
+	RetryAt       *time.Time              `json:"retryAt"`
+}
+
+type WorkerQualificationListResponse struct {
+	Qualifications []WorkerQualificationResponse `json:"qualifications"`
+}
+
+// QualificationTestQuestionsResponse is the worker facing view of a test, without the gold answers
+type QualificationTestQuestionsResponse struct {
+	TestId        string          `json:"testId"`
+	Title         string          `json:"title"`
+	TaskType      db.TaskType     `json:"taskType"`
+	PassThreshold float64         `json:"passThreshold"`
+	Questions     []task.TaskData `json:"questions"`
+}
+
+// QualificationAnswer is the worker's result for the question at QuestionIndex
+type QualificationAnswer struct {
+	QuestionIndex int           `json:"questionIndex"`
+	ResultData    []task.Result `json:"resultData"`
+}
+
+type SubmitQualificationTestRequest struct {
+	Answers []QualificationAnswer `json:"answers" binding:"required"`
+}
+
+type SubmitQualificationTestResponse struct {
+	Status   db.QualificationStatus `json:"status"`
+	Score    float64                `json:"score"`
+	Attempts int                    `json:"attempts"`
+	RetryAt  *time.Time             `json:"retryAt"`
+}
+
+// ErrAlreadyQualified is returned when a worker submits a test they have already passed
+var ErrAlreadyQualified = errors.New("qualification test already passed")
+
+// ErrQualificationCooldown is returned when a worker retakes a failed test before the cooldown is over
+type ErrQualificationCooldown struct {
+	RetryAt time.Time
+}
+
+func (e *ErrQualificationCooldown) Error() string {
+	return fmt.Sprintf("qualification test can be retaken at %s", e.RetryAt.Format(time.RFC3339))
+}
+
+// ErrInvalidQualificationTest is returned for test definitions or submissions that fail validation
+type ErrInvalidQualificationTest struct {
+	Reason string
+}
+
+func (e *ErrInvalidQualificationTest) Error() string {
+	return fmt.Sprintf("invalid qualification test: %s", e.Reason)
+}
@@ -0,0 +1,380 @@
+package qualification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"dojo-api/db"
+	"dojo-api/pkg/orm"
+	"dojo-api/pkg/task"
+
+	"github.com/rs/zerolog/log"
+)
+
+type QualificationService struct {
+	qualificationORM *orm.QualificationORM
+}
+
+func NewQualificationService() *QualificationService {
+	return &QualificationService{
+		qualificationORM: orm.NewQualificationORM(),
+	}
+}
+
+// CreateTest validates and stores a qualification test for the miner's tasks of the given task type
+func (s *QualificationService) CreateTest(ctx context.Context, minerUserId string, request CreateQualificationTestRequest) (*QualificationTestResponse, error) {
+	if err := s.validateCreateTestRequest(minerUserId, &request); err != nil {
+		return nil, err
+	}
+
+	questions, err := json.Marshal(request.Questions)
+	if err != nil {
+		log.Error().Err(err).Msg("Error marshaling qualification questions")
+		return nil, err
+	}
+
+	test, err := s.qualificationORM.CreateTest(ctx, db.InnerQualificationTest{
+		TaskType:        request.TaskType,
+		SubscriptionKey: request.SubscriptionKey,
+		Title:           request.Title,
+		Questions:       db.JSON(questions),
+		PassThreshold:   request.PassThreshold,
+		CooldownHours:   request.CooldownHours,
+	}, minerUserId)
+	if err != nil {
+		log.Error().Err(err).Str("minerUserId", minerUserId).Msg("Error creating qualification test")
+		return nil, err
+	}
+	return buildQualificationTestResponse(test, len(request.Questions)), nil
+}
+
+func (s *QualificationService) validateCreateTestRequest(minerUserId string, request *CreateQualificationTestRequest) error {
+	if request.Title == "" {
+		return &ErrInvalidQualificationTest{Reason: "title is required"}
+	}
+	if _, err := task.IsValidTaskType(request.TaskType); err != nil {
+		return &ErrInvalidQualificationTest{Reason: err.Error()}
+	}
+	if request.PassThreshold <= 0 || request.PassThreshold > 1 {
+		return &ErrInvalidQualificationTest{Reason: "passThreshold must be greater than 0 and at most 1"}
+	}
+	if request.CooldownHours < 0 {
+		return &ErrInvalidQualificationTest{Reason: "cooldownHours must not be negative"}
+	}
+	if len(request.Questions) == 0 {
+		return &ErrInvalidQualificationTest{Reason: "questions is required"}
+	}
+
+	if request.SubscriptionKey != nil {
+		subscriptionKey, err := orm.NewSubscriptionKeyORM().GetSubscriptionByKey(*request.SubscriptionKey)
+		if err != nil || subscriptionKey.IsDelete || subscriptionKey.MinerUserID != minerUserId {
+			return &ErrInvalidQualificationTest{Reason: "subscriptionKey does not belong to the miner"}
+		}
+	}
+
+	for i, question := range request.Questions {
+		if question.TaskData.Task != request.TaskType {
+			return &ErrInvalidQualificationTest{Reason: fmt.Sprintf("question %d is not a %s task", i, request.TaskType)}
+		}
+		if err := task.ValidateTaskData(question.TaskData); err != nil {
+			return &ErrInvalidQualificationTest{Reason: fmt.Sprintf("question %d: %s", i, err)}
+		}
+		if len(question.GoldAnswers) == 0 {
+			return &ErrInvalidQualificationTest{Reason: fmt.Sprintf("question %d: goldAnswers is required", i)}
+		}
+		if err := task.ValidateGoldAnswers(question.TaskData, question.GoldAnswers); err != nil {
+			return &ErrInvalidQualificationTest{Reason: fmt.Sprintf("question %d: %s", i, err)}
+		}
+
+		if question.TaskData.Task == db.TaskTypeCodeGeneration {
+			processedTaskData, err := task.ProcessCodeCompletion(question.TaskData)
+			if err != nil {
+				return &ErrInvalidQualificationTest{Reason: fmt.Sprintf("question %d: %s", i, err)}
+			}
+			request.Questions[i].TaskData = processedTaskData
+		}
+	}
+	return nil
+}
+
+func (s *QualificationService) GetTestsByMinerUser(ctx context.Context, minerUserId string) (*MinerQualificationTestListResponse, error) {
+	tests, err := s.qualificationORM.GetTestsByMinerUser(ctx, minerUserId)
+	if err != nil {
+		log.Error().Err(err).Str("minerUserId", minerUserId).Msg("Error getting qualification tests")
+		return nil, err
+	}
+
+	responses := make([]QualificationTestResponse, 0, len(tests))
+	for i := range tests {
+		questions, err := parseQuestions(&tests[i])
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, *buildQualificationTestResponse(&tests[i], len(questions)))
+	}
+	return &MinerQualificationTestListResponse{Tests: responses}, nil
+}
+
+// GetWorkerQualifications lists every test the worker has to pass along with their latest attempt
+func (s *QualificationService) GetWorkerQualifications(ctx context.Context, workerId string) (*WorkerQualificationListResponse, error) {
+	tests, qualifications, err := s.getTestsAndQualifications(ctx, workerId)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]WorkerQualificationResponse, 0, len(tests))
+	for i := range tests {
+		test := &tests[i]
+		questions, err := parseQuestions(test)
+		if err != nil {
+			return nil, err
+		}
+
+		response := WorkerQualificationResponse{
+			TestId:        test.ID,
+			Title:         test.Title,
+			TaskType:      test.TaskType,
+			NumQuestions:  len(questions),
+			PassThreshold: test.PassThreshold,
+		}
+		if qualification, ok := qualifications[test.ID]; ok {
+			status, score := qualification.Status, qualification.Score
+			response.Status = &status
+			response.Score = &score
+			response.Attempts = qualification.Attempts
+			response.RetryAt = retryAt(test, qualification)
+		}
+		responses = append(responses, response)
+	}
+	return &WorkerQualificationListResponse{Qualifications: responses}, nil
+}
+
+// GetTestForWorker returns the questions of a test that applies to the worker, with the gold answers stripped
+func (s *QualificationService) GetTestForWorker(ctx context.Context, workerId string, testId string) (*QualificationTestQuestionsResponse, error) {
+	test, err := s.getApplicableTest(ctx, workerId, testId)
+	if err != nil {
+		return nil, err
+	}
+
+	questions, err := parseQuestions(test)
+	if err != nil {
+		return nil, err
+	}
+
+	taskData := make([]task.TaskData, 0, len(questions))
+	for i, question := range questions {
+		task.BlindModels(&question.TaskData, questionScope(test.ID, i))
+		taskData = append(taskData, question.TaskData)
+	}
+	return &QualificationTestQuestionsResponse{
+		TestId:        test.ID,
+		Title:         test.Title,
+		TaskType:      test.TaskType,
+		PassThreshold: test.PassThreshold,
+		Questions:     taskData,
+	}, nil
+}
+
+// SubmitTest grades the worker's answers and records the attempt, the score is the fraction of questions
+// whose gold answers were all matched and unanswered questions count as wrong
+func (s *QualificationService) SubmitTest(ctx context.Context, workerId string, testId string, answers []QualificationAnswer) (*SubmitQualificationTestResponse, error) {
+	test, err := s.getApplicableTest(ctx, workerId, testId)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := s.qualificationORM.GetWorkerQualification(ctx, testId, workerId)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		log.Error().Err(err).Str("testId", testId).Str("workerId", workerId).Msg("Error getting worker qualification")
+		return nil, err
+	}
+	if previous != nil {
+		if previous.Status == db.QualificationStatusPassed {
+			return nil, ErrAlreadyQualified
+		}
+		if retryTime := retryAt(test, previous); retryTime != nil {
+			return nil, &ErrQualificationCooldown{RetryAt: *retryTime}
+		}
+	}
+
+	questions, err := parseQuestions(test)
+	if err != nil {
+		return nil, err
+	}
+
+	answersByQuestion := make(map[int][]task.Result)
+	for _, answer := range answers {
+		if answer.QuestionIndex < 0 || answer.QuestionIndex >= len(questions) {
+			return nil, &ErrInvalidQualificationTest{Reason: fmt.Sprintf("question %d does not exist", answer.QuestionIndex)}
+		}
+		questionData := questions[answer.QuestionIndex].TaskData
+		resultData := task.UnblindResults(answer.ResultData, questionData, questionScope(testId, answer.QuestionIndex))
+		results, err := task.ValidateResultsForTaskData(resultData, questionData)
+		if err != nil {
+			return nil, &ErrInvalidQualificationTest{Reason: fmt.Sprintf("question %d: %s", answer.QuestionIndex, err)}
+		}
+		answersByQuestion[answer.QuestionIndex] = results
+	}
+
+	numPassed := 0
+	for i, question := range questions {
+		if results, ok := answersByQuestion[i]; ok && task.GradeGoldAnswers(question.GoldAnswers, results) {
+			numPassed++
+		}
+	}
+
+	score := float64(numPassed) / float64(len(questions))
+	status := db.QualificationStatusFailed
+	if score >= test.PassThreshold {
+		status = db.QualificationStatusPassed
+	}
+
+	recorded, err := s.qualificationORM.UpsertWorkerQualification(ctx, testId, workerId, status, score, test.CooldownHours)
+	if err != nil {
+		log.Error().Err(err).Str("testId", testId).Str("workerId", workerId).Msg("Error saving worker qualification")
+		return nil, err
+	}
+
+	// read back, when nothing was recorded a concurrent attempt of the worker got in first
+	qualification, err := s.qualificationORM.GetWorkerQualification(ctx, testId, workerId)
+	if err != nil {
+		log.Error().Err(err).Str("testId", testId).Str("workerId", workerId).Msg("Error getting worker qualification")
+		return nil, err
+	}
+	if !recorded {
+		if qualification.Status == db.QualificationStatusPassed {
+			return nil, ErrAlreadyQualified
+		}
+		return nil, &ErrQualificationCooldown{RetryAt: qualification.LastAttemptAt.Add(time.Duration(test.CooldownHours) * time.Hour)}
+	}
+
+	log.Info().Str("testId", testId).Str("workerId", workerId).Float64("score", score).Str("status", string(status)).Msg("Qualification test graded")
+	return &SubmitQualificationTestResponse{
+		Status:   qualification.Status,
+		Score:    qualification.Score,
+		Attempts: qualification.Attempts,
+		RetryAt:  retryAt(test, qualification),
+	}, nil
+}
+
+// GetUnqualifiedScopes returns the miner and task type pairs the worker has an unpassed test for
+func (s *QualificationService) GetUnqualifiedScopes(ctx context.Context, workerId string) ([]orm.MinerTaskType, error) {
+	tests, qualifications, err := s.getTestsAndQualifications(ctx, workerId)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[orm.MinerTaskType]bool)
+	scopes := make([]orm.MinerTaskType, 0)
+	for _, test := range tests {
+		if qualification, ok := qualifications[test.ID]; ok && qualification.Status == db.QualificationStatusPassed {
+			continue
+		}
+		scope := orm.MinerTaskType{MinerUserID: test.MinerUserID, Type: test.TaskType}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+// IsQualifiedForTask reports whether the worker passed every test covering the task's miner and task type
+func (s *QualificationService) IsQualifiedForTask(ctx context.Context, workerId string, taskModel *db.TaskModel) (bool, error) {
+	minerUserId, ok := taskModel.MinerUserID()
+	if !ok {
+		return true, nil
+	}
+
+	scopes, err := s.GetUnqualifiedScopes(ctx, workerId)
+	if err != nil {
+		return false, err
+	}
+	for _, scope := range scopes {
+		if scope.MinerUserID == minerUserId && scope.Type == taskModel.Type {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (s *QualificationService) getTestsAndQualifications(ctx context.Context, workerId string) ([]db.QualificationTestModel, map[string]*db.WorkerQualificationModel, error) {
+	tests, err := s.qualificationORM.GetActiveTestsForWorker(ctx, workerId)
+	if err != nil {
+		log.Error().Err(err).Str("workerId", workerId).Msg("Error getting qualification tests for worker")
+		return nil, nil, err
+	}
+
+	qualifications, err := s.qualificationORM.GetWorkerQualifications(ctx, workerId)
+	if err != nil {
+		log.Error().Err(err).Str("workerId", workerId).Msg("Error getting worker qualifications")
+		return nil, nil, err
+	}
+
+	qualificationByTest := make(map[string]*db.WorkerQualificationModel, len(qualifications))
+	for i := range qualifications {
+		qualificationByTest[qualifications[i].TestID] = &qualifications[i]
+	}
+	return tests, qualificationByTest, nil
+}
+
+// getApplicableTest returns the test only when it is active and applies to the worker
+func (s *QualificationService) getApplicableTest(ctx context.Context, workerId string, testId string) (*db.QualificationTestModel, error) {
+	tests, err := s.qualificationORM.GetActiveTestsForWorker(ctx, workerId)
+	if err != nil {
+		log.Error().Err(err).Str("workerId", workerId).Msg("Error getting qualification tests for worker")
+		return nil, err
+	}
+	for i := range tests {
+		if tests[i].ID == testId {
+			return &tests[i], nil
+		}
+	}
+	return nil, db.ErrNotFound
+}
+
+func parseQuestions(test *db.QualificationTestModel) ([]QualificationQuestion, error) {
+	var questions []QualificationQuestion
+	if err := json.Unmarshal(test.Questions, &questions); err != nil {
+		log.Error().Err(err).Str("testId", test.ID).Msg("Error unmarshaling qualification questions")
+		return nil, err
+	}
+	return questions, nil
+}
+
+// retryAt returns when a failed attempt can be retaken, nil if the test was passed or the cooldown is over
+func retryAt(test *db.QualificationTestModel, qualification *db.WorkerQualificationModel) *time.Time {
+	if qualification.Status == db.QualificationStatusPassed {
+		return nil
+	}
+	retryTime := qualification.LastAttemptAt.Add(time.Duration(test.CooldownHours) * time.Hour)
+	if !retryTime.After(time.Now()) {
+		return nil
+	}
+	return &retryTime
+}
+
+func buildQualificationTestResponse(test *db.QualificationTestModel, numQuestions int) *QualificationTestResponse {
+	response := &QualificationTestResponse{
+		TestId:        test.ID,
+		Title:         test.Title,
+		TaskType:      test.TaskType,
+		NumQuestions:  numQuestions,
+		PassThreshold: test.PassThreshold,
+		CooldownHours: test.CooldownHours,
+		IsActive:      test.IsActive,
+		CreatedAt:     test.CreatedAt,
+	}
+	if subscriptionKey, ok := test.SubscriptionKey(); ok {
+		response.SubscriptionKey = &subscriptionKey
+	}
+	return response
+}
+
+// questionScope gives every question of a test its own model aliases
+func questionScope(testId string, questionIndex int) string {
+	return fmt.Sprintf("%s:%d", testId, questionIndex)
+}
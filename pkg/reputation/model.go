@@ -0,0 +1,14 @@
+package reputation
+
+import "time"
+
+// WorkerReputationResponse holds the combined score and its components, all between 0 and 1
+type WorkerReputationResponse struct {
+	WorkerId           string    `json:"workerId"`
+	Score              float64   `json:"score"`
+	GoldAccuracy       float64   `json:"goldAccuracy"`
+	ConsensusAgreement float64   `json:"consensusAgreement"`
+	InvalidRate        float64   `json:"invalidRate"`
+	AccountAgeDays     int       `json:"accountAgeDays"`
+	UpdatedAt          time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,213 @@
+package reputation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+
+	"dojo-api/db"
+	"dojo-api/pkg/orm"
+	"dojo-api/pkg/task"
+
+	"github.com/rs/zerolog/log"
+)
+
+// weights of each component in the combined score, they add up to 1
+const (
+	goldAccuracyWeight = 0.4
+	consensusWeight    = 0.3
+	validityWeight     = 0.2
+	accountAgeWeight   = 0.1
+)
+
+// accounts get the full account age component once they are this old
+const fullAccountAgeDays = 30
+
+// components without any data yet are neither rewarded nor penalised
+const neutralComponent = 0.5
+
+type ReputationService struct {
+	workerReputationORM   *orm.WorkerReputationORM
+	workerGoldAccuracyORM *orm.WorkerGoldAccuracyORM
+	taskResultORM         *orm.TaskResultORM
+}
+
+func NewReputationService() *ReputationService {
+	return &ReputationService{
+		workerReputationORM:   orm.NewWorkerReputationORM(),
+		workerGoldAccuracyORM: orm.NewWorkerGoldAccuracyORM(),
+		taskResultORM:         orm.NewTaskResultORM(),
+	}
+}
+
+// GetWorkerReputation returns the stored reputation of the worker, computing it first for workers without one
+func (s *ReputationService) GetWorkerReputation(ctx context.Context, worker *db.DojoWorkerModel) (*WorkerReputationResponse, error) {
+	reputation, err := s.workerReputationORM.GetByWorkerId(ctx, worker.ID)
+	if err == nil {
+		return buildWorkerReputationResponse(reputation), nil
+	}
+	if !errors.Is(err, db.ErrNotFound) {
+		log.Error().Err(err).Str("workerId", worker.ID).Msg("Error getting worker reputation")
+		return nil, err
+	}
+	return s.UpdateWorkerReputation(ctx, worker, nil)
+}
+
+// UpdateWorkerReputation recomputes the worker's reputation, when a task is given the worker's result on it
+// is compared with the other workers' results and added to the consensus component
+func (s *ReputationService) UpdateWorkerReputation(ctx context.Context, worker *db.DojoWorkerModel, submittedTask *db.TaskModel) (*WorkerReputationResponse, error) {
+	reputation := db.InnerWorkerReputation{
+		WorkerID: worker.ID,
+	}
+
+	added := false
+	if submittedTask != nil {
+		agreement, ok, err := s.consensusAgreement(ctx, worker.ID, submittedTask)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			reputation.ConsensusSum, reputation.ConsensusCount, err = s.workerReputationORM.AddConsensusAgreement(ctx, worker.ID, agreement)
+			if err != nil {
+				log.Error().Err(err).Str("workerId", worker.ID).Msg("Error adding consensus agreement")
+				return nil, err
+			}
+			added = true
+		}
+	}
+	if !added {
+		existing, err := s.workerReputationORM.GetByWorkerId(ctx, worker.ID)
+		if err != nil && !errors.Is(err, db.ErrNotFound) {
+			return nil, err
+		}
+		if existing != nil {
+			reputation.ConsensusSum = existing.ConsensusSum
+			reputation.ConsensusCount = existing.ConsensusCount
+		}
+	}
+
+	reputation.ConsensusAgreement = neutralComponent
+	if reputation.ConsensusCount > 0 {
+		reputation.ConsensusAgreement = reputation.ConsensusSum / float64(reputation.ConsensusCount)
+	}
+
+	reputation.GoldAccuracy = neutralComponent
+	goldAccuracy, err := s.workerGoldAccuracyORM.GetByWorkerId(ctx, worker.ID)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		return nil, err
+	}
+	if goldAccuracy != nil {
+		// smoothed towards neutral so a single graded submission does not swing the score to 0 or 1
+		reputation.GoldAccuracy = (float64(goldAccuracy.NumPassed) + 1) / (float64(goldAccuracy.NumGraded) + 2)
+	}
+
+	total, invalid, err := s.taskResultORM.GetResultStatusCountsByWorker(ctx, worker.ID)
+	if err != nil {
+		return nil, err
+	}
+	if total > 0 {
+		reputation.InvalidRate = float64(invalid) / float64(total)
+	}
+
+	reputation.AccountAgeDays = int(time.Since(worker.CreatedAt).Hours() / 24)
+	accountAge := math.Min(float64(reputation.AccountAgeDays)/fullAccountAgeDays, 1)
+
+	reputation.Score = goldAccuracyWeight*reputation.GoldAccuracy +
+		consensusWeight*reputation.ConsensusAgreement +
+		validityWeight*(1-reputation.InvalidRate) +
+		accountAgeWeight*accountAge
+
+	updated, err := s.workerReputationORM.UpsertWorkerReputation(ctx, reputation)
+	if err != nil {
+		log.Error().Err(err).Str("workerId", worker.ID).Msg("Error storing worker reputation")
+		return nil, err
+	}
+	return buildWorkerReputationResponse(updated), nil
+}
+
+// consensusAgreement scores how close the worker's result on the task is to the mean of the other workers' results,
+// 1 means identical and 0 means a full criteria range apart. Returns false when no other worker submitted yet.
+func (s *ReputationService) consensusAgreement(ctx context.Context, workerId string, submittedTask *db.TaskModel) (float64, bool, error) {
+	var taskData task.TaskData
+	if err := json.Unmarshal(submittedTask.TaskData, &taskData); err != nil {
+		return 0, false, err
+	}
+
+	criteriaRanges := make(map[string]float64)
+	for _, response := range taskData.Responses {
+		for _, criteria := range response.Criteria {
+			if scoreCriteria, ok := criteria.(task.ScoreCriteria); ok && scoreCriteria.Max > scoreCriteria.Min {
+				criteriaRanges[response.Model] = scoreCriteria.Max - scoreCriteria.Min
+			}
+		}
+	}
+
+	taskResults, err := s.taskResultORM.GetTaskResultsByTaskId(ctx, submittedTask.ID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	var workerScores map[string]float64
+	othersSum := make(map[string]float64)
+	othersCount := make(map[string]int)
+	for _, taskResult := range taskResults {
+		if taskResult.Status != db.TaskResultStatusCompleted {
+			continue
+		}
+
+		var resultData []task.Result
+		if err := json.Unmarshal(taskResult.ResultData, &resultData); err != nil {
+			return 0, false, err
+		}
+
+		scores := make(map[string]float64)
+		for _, result := range resultData {
+			for _, criteria := range result.Criteria {
+				if scoreCriteria, ok := criteria.(task.ScoreCriteria); ok {
+					scores[result.Model] = scoreCriteria.MinerScore
+					break
+				}
+			}
+		}
+
+		if taskResult.WorkerID == workerId {
+			workerScores = scores
+			continue
+		}
+		for model, score := range scores {
+			othersSum[model] += score
+			othersCount[model]++
+		}
+	}
+
+	var totalAgreement float64
+	numModels := 0
+	for model, score := range workerScores {
+		scoreRange, ok := criteriaRanges[model]
+		if !ok || othersCount[model] == 0 {
+			continue
+		}
+		consensus := othersSum[model] / float64(othersCount[model])
+		totalAgreement += math.Max(0, 1-math.Abs(score-consensus)/scoreRange)
+		numModels++
+	}
+
+	if numModels == 0 {
+		return 0, false, nil
+	}
+	return totalAgreement / float64(numModels), true, nil
+}
+
+func buildWorkerReputationResponse(reputation *db.WorkerReputationModel) *WorkerReputationResponse {
+	return &WorkerReputationResponse{
+		WorkerId:           reputation.WorkerID,
+		Score:              reputation.Score,
+		GoldAccuracy:       reputation.GoldAccuracy,
+		ConsensusAgreement: reputation.ConsensusAgreement,
+		InvalidRate:        reputation.InvalidRate,
+		AccountAgeDays:     reputation.AccountAgeDays,
+		UpdatedAt:          reputation.UpdatedAt,
+	}
+}
@@ -0,0 +1,44 @@
+package settlement
+
+import (
+	"errors"
+	"time"
+
+	"dojo-api/db"
+)
+
+var (
+	ErrTaskNotFinished  = errors.New("task has not finished yet")
+	ErrSettlementOnHold = errors.New("task has results with collusion flags awaiting review")
+)
+
+// reasons a result is left out of the reward split
+const (
+	InvalidReasonStatus     = "not_completed"
+	InvalidReasonGoldFailed = "gold_failed"
+	InvalidReasonCollusion  = "confirmed_collusion"
+)
+
+// ResultShare is what one result of the task was paid or lost at settlement
+type ResultShare struct {
+	TaskResultId string              `json:"taskResultId"`
+	WorkerId     string              `json:"workerId"`
+	Status       db.TaskResultStatus `json:"status"`
+	Valid        bool                `json:"valid"`
+	// why the result was left out of the split, empty for valid results
+	InvalidReason string  `json:"invalidReason,omitempty"`
+	Weight        float64 `json:"weight"`
+	Reward        float64 `json:"reward"`
+	Loss          float64 `json:"loss"`
+}
+
+type SettlementReportResponse struct {
+	TaskId      string              `json:"taskId"`
+	TaskStatus  db.TaskStatus       `json:"taskStatus"`
+	Policy      db.SettlementPolicy `json:"policy"`
+	TotalReward float64             `json:"totalReward"`
+	TotalPaid   float64             `json:"totalPaid"`
+	NumValid    int                 `json:"numValid"`
+	Shares      []ResultShare       `json:"shares"`
+	SettledAt   time.Time           `json:"settledAt"`
+}
@@ -0,0 +1,370 @@
+package settlement
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"time"
+
+	"dojo-api/db"
+	"dojo-api/pkg/cache"
+	"dojo-api/pkg/collusion"
+	"dojo-api/pkg/orm"
+	"dojo-api/pkg/staking"
+	"dojo-api/pkg/task"
+
+	"github.com/rs/zerolog/log"
+)
+
+// how often finished tasks that are not settled yet are looked for, and how many are settled at a time
+const (
+	settlementInterval  = 10 * time.Minute
+	settlementBatchSize = 100
+)
+
+type SettlementService struct {
+	taskResultORM     *orm.TaskResultORM
+	taskSettlementORM *orm.TaskSettlementORM
+	stakingService    *staking.StakingService
+}
+
+func NewSettlementService() *SettlementService {
+	return &SettlementService{
+		taskResultORM:     orm.NewTaskResultORM(),
+		taskSettlementORM: orm.NewTaskSettlementORM(),
+		stakingService:    staking.NewStakingService(),
+	}
+}
+
+// configuredPolicy reads SETTLEMENT_POLICY, tasks are split equally unless consensus weighting is configured
+func configuredPolicy() db.SettlementPolicy {
+	switch policy := db.SettlementPolicy(os.Getenv("SETTLEMENT_POLICY")); policy {
+	case db.SettlementPolicyEqualShare, db.SettlementPolicyConsensusWeighted:
+		return policy
+	case "":
+		return db.SettlementPolicyEqualShare
+	default:
+		log.Warn().Str("policy", string(policy)).Msg("Unknown settlement policy, falling back to equal share")
+		return db.SettlementPolicyEqualShare
+	}
+}
+
+func isTaskFinished(taskModel *db.TaskModel) bool {
+	return taskModel.Status == db.TaskStatusCompleted || taskModel.Status == db.TaskStatusExpired ||
+		taskModel.Status == db.TaskStatusCancelled
+}
+
+// SettleTask splits the task's total reward among its valid results and writes the finalised reward and loss
+// of every result, locked stake is released or slashed. A task that did not change since it was last settled
+// is not settled again. A task with flags awaiting review is held, so the stake of a result confirmed as
+// collusion can still be slashed, and is settled again once the flags are reviewed. Results flagged only for being
+// submitted too fast do not hold the task. A cancelled task pays
+// nothing and gives back all the stake locked by its results.
+func (s *SettlementService) SettleTask(ctx context.Context, taskModel *db.TaskModel) (*db.TaskSettlementModel, error) {
+	if !isTaskFinished(taskModel) {
+		return nil, ErrTaskNotFinished
+	}
+
+	existing, err := s.taskSettlementORM.GetByTaskId(ctx, taskModel.ID)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		log.Error().Err(err).Str("taskId", taskModel.ID).Msg("Error getting task settlement")
+		return nil, err
+	}
+
+	taskResults, err := s.taskResultORM.GetTaskResultsWithFlag(ctx, taskModel.ID)
+	if err != nil {
+		log.Error().Err(err).Str("taskId", taskModel.ID).Msg("Error getting task results for settlement")
+		return nil, err
+	}
+	cancelled := taskModel.Status == db.TaskStatusCancelled
+	if !cancelled && hasPendingFlags(taskResults) {
+		if existing != nil {
+			return existing, nil
+		}
+		return nil, ErrSettlementOnHold
+	}
+	if existing != nil && !needsResettlement(existing, taskModel, taskResults) {
+		return existing, nil
+	}
+
+	policy := configuredPolicy()
+	totalReward, _ := taskModel.TotalReward()
+	shares, err := ComputeShares(taskResults, totalReward, policy)
+	if err != nil {
+		log.Error().Err(err).Str("taskId", taskModel.ID).Msg("Error computing settlement shares")
+		return nil, err
+	}
+	if cancelled {
+		totalReward = 0
+		cancelShares(shares)
+	}
+
+	// stake is settled first, a failure leaves the task unsettled so the next run retries it
+	if err := s.stakingService.SettleStakes(ctx, buildStakeOutcomes(taskResults, shares)); err != nil {
+		log.Error().Err(err).Str("taskId", taskModel.ID).Msg("Error settling stakes")
+		return nil, err
+	}
+
+	totalPaid := 0.0
+	numValid := 0
+	resultSettlements := make([]orm.ResultSettlement, 0, len(shares))
+	for _, share := range shares {
+		totalPaid += share.Reward
+		if share.Valid {
+			numValid++
+		}
+		resultSettlements = append(resultSettlements, orm.ResultSettlement{
+			TaskResultID: share.TaskResultId,
+			Reward:       share.Reward,
+			Loss:         share.Loss,
+		})
+	}
+
+	sharesJSON, err := json.Marshal(shares)
+	if err != nil {
+		log.Error().Err(err).Msg("Error marshaling settlement shares")
+		return nil, err
+	}
+
+	settlement, err := s.taskSettlementORM.SaveSettlement(ctx, db.InnerTaskSettlement{
+		TaskID:      taskModel.ID,
+		Policy:      policy,
+		TotalReward: totalReward,
+		TotalPaid:   totalPaid,
+		NumValid:    numValid,
+		Shares:      sharesJSON,
+	}, resultSettlements)
+	if err != nil {
+		log.Error().Err(err).Str("taskId", taskModel.ID).Msg("Error saving task settlement")
+		return nil, err
+	}
+
+	log.Info().Str("taskId", taskModel.ID).Str("policy", string(policy)).Float64("totalPaid", totalPaid).Int("numValid", numValid).Msg("Task settled")
+	return settlement, nil
+}
+
+// needsResettlement tells whether the task, its results or their flags changed since the settlement was saved
+func needsResettlement(settlement *db.TaskSettlementModel, taskModel *db.TaskModel, taskResults []db.TaskResultModel) bool {
+	if settlement.UpdatedAt.Before(taskModel.UpdatedAt) {
+		return true
+	}
+	for _, taskResult := range taskResults {
+		if taskResult.CreatedAt.After(settlement.UpdatedAt) {
+			return true
+		}
+		if flag, ok := taskResult.ResultFlag(); ok && flag.UpdatedAt.After(settlement.UpdatedAt) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPendingFlags(taskResults []db.TaskResultModel) bool {
+	for _, taskResult := range taskResults {
+		if flag, ok := taskResult.ResultFlag(); ok && flag.Status == db.ResultFlagStatusPending && collusion.IsClusterFlag(flag) {
+			return true
+		}
+	}
+	return false
+}
+
+// cancelShares takes the reward and the loss out of every share, the workers of a cancelled task are neither paid
+// nor slashed
+func cancelShares(shares []ResultShare) {
+	for i := range shares {
+		shares[i].Weight = 0
+		shares[i].Reward = 0
+		shares[i].Loss = 0
+	}
+}
+
+func buildStakeOutcomes(taskResults []db.TaskResultModel, shares []ResultShare) []staking.StakeOutcome {
+	outcomes := make([]staking.StakeOutcome, 0, len(taskResults))
+	for i, taskResult := range taskResults {
+		stakeAmount, ok := taskResult.StakeAmount()
+		if !ok {
+			continue
+		}
+		outcomes = append(outcomes, staking.StakeOutcome{
+			TaskResultId: taskResult.ID,
+			WorkerId:     taskResult.WorkerID,
+			TaskId:       taskResult.TaskID,
+			StakeAmount:  stakeAmount,
+			SlashAmount:  shares[i].Loss,
+		})
+	}
+	return outcomes
+}
+
+// ComputeShares decides the reward and loss of every result of a task. Only COMPLETED results that did not fail
+// a gold check and were not confirmed as collusion share the reward. Results that failed a gold check or were
+// confirmed as collusion lose their potential loss, results that are merely not COMPLETED lose nothing.
+func ComputeShares(taskResults []db.TaskResultModel, totalReward float64, policy db.SettlementPolicy) ([]ResultShare, error) {
+	shares := make([]ResultShare, 0, len(taskResults))
+	validIndexes := make([]int, 0, len(taskResults))
+	vectors := make([]map[string]float64, 0, len(taskResults))
+	for _, taskResult := range taskResults {
+		share := ResultShare{
+			TaskResultId:  taskResult.ID,
+			WorkerId:      taskResult.WorkerID,
+			Status:        taskResult.Status,
+			InvalidReason: invalidReason(&taskResult),
+		}
+		share.Valid = share.InvalidReason == ""
+
+		if share.Valid {
+			var resultData []task.Result
+			if err := json.Unmarshal(taskResult.ResultData, &resultData); err != nil {
+				return nil, err
+			}
+			validIndexes = append(validIndexes, len(shares))
+			vectors = append(vectors, task.ResultScoreVector(resultData))
+		} else if potentialLoss, ok := taskResult.PotentialLoss(); ok && share.InvalidReason != InvalidReasonStatus {
+			share.Loss = potentialLoss
+		}
+		shares = append(shares, share)
+	}
+
+	if len(validIndexes) == 0 {
+		return shares, nil
+	}
+
+	weights := make([]float64, len(validIndexes))
+	for i := range weights {
+		weights[i] = 1
+	}
+	if policy == db.SettlementPolicyConsensusWeighted {
+		weights = consensusWeights(vectors)
+	}
+
+	totalWeight := 0.0
+	for _, weight := range weights {
+		totalWeight += weight
+	}
+	// nobody agreed with anybody, there is no basis to favour any result
+	if totalWeight == 0 {
+		for i := range weights {
+			weights[i] = 1
+		}
+		totalWeight = float64(len(weights))
+	}
+
+	for i, index := range validIndexes {
+		shares[index].Weight = weights[i] / totalWeight
+		shares[index].Reward = totalReward * shares[index].Weight
+	}
+	return shares, nil
+}
+
+func invalidReason(taskResult *db.TaskResultModel) string {
+	if taskResult.Status != db.TaskResultStatusCompleted {
+		return InvalidReasonStatus
+	}
+	if goldPassed, ok := taskResult.GoldPassed(); ok && !goldPassed {
+		return InvalidReasonGoldFailed
+	}
+	if flag, ok := taskResult.ResultFlag(); ok && flag.Status == db.ResultFlagStatusConfirmed {
+		return InvalidReasonCollusion
+	}
+	return ""
+}
+
+// consensusWeights scores each result by how close its normalised scores are to the mean of the other results,
+// 1 means identical and 0 means a full criteria range apart. A result nobody else scored alike counts as 1.
+func consensusWeights(vectors []map[string]float64) []float64 {
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	for _, vector := range vectors {
+		for key, value := range vector {
+			sums[key] += value
+			counts[key]++
+		}
+	}
+
+	weights := make([]float64, len(vectors))
+	for i, vector := range vectors {
+		totalDistance := 0.0
+		numKeys := 0
+		for key, value := range vector {
+			if counts[key] < 2 {
+				continue
+			}
+			consensus := (sums[key] - value) / float64(counts[key]-1)
+			totalDistance += math.Abs(value - consensus)
+			numKeys++
+		}
+
+		weights[i] = 1
+		if numKeys > 0 {
+			weights[i] = math.Max(0, 1-totalDistance/float64(numKeys))
+		}
+	}
+	return weights
+}
+
+// GetSettlementReport returns how the task's reward was split, settling the task first if it finished since
+func (s *SettlementService) GetSettlementReport(ctx context.Context, taskModel *db.TaskModel) (*SettlementReportResponse, error) {
+	settlement, err := s.SettleTask(ctx, taskModel)
+	if err != nil {
+		return nil, err
+	}
+
+	var shares []ResultShare
+	if err := json.Unmarshal(settlement.Shares, &shares); err != nil {
+		log.Error().Err(err).Str("taskId", taskModel.ID).Msg("Error unmarshaling settlement shares")
+		return nil, err
+	}
+
+	return &SettlementReportResponse{
+		TaskId:      taskModel.ID,
+		TaskStatus:  taskModel.Status,
+		Policy:      settlement.Policy,
+		TotalReward: settlement.TotalReward,
+		TotalPaid:   settlement.TotalPaid,
+		NumValid:    settlement.NumValid,
+		Shares:      shares,
+		SettledAt:   settlement.UpdatedAt,
+	}, nil
+}
+
+// SettleFinishedTasks periodically settles tasks that completed, expired or were cancelled, tasks completed by a
+// submission are usually settled right away and this catches expired, cancelled and early closed tasks. Only one replica settles each round.
+func (s *SettlementService) SettleFinishedTasks(ctx context.Context) {
+	for range time.Tick(settlementInterval) {
+		if !cache.GetCacheInstance().TryPeriodicLock(ctx, "settlement", settlementInterval) {
+			continue
+		}
+		numSettled, err := s.settlePendingTasks(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Error settling finished tasks")
+			continue
+		}
+		log.Info().Int("numSettled", numSettled).Msg("Settled finished tasks")
+	}
+}
+
+func (s *SettlementService) settlePendingTasks(ctx context.Context) (int, error) {
+	numSettled := 0
+	for {
+		tasks, err := s.taskSettlementORM.GetUnsettledTasks(ctx, settlementBatchSize)
+		if err != nil {
+			return numSettled, err
+		}
+
+		numSettledInBatch := 0
+		for i := range tasks {
+			if _, err := s.SettleTask(ctx, &tasks[i]); err != nil {
+				continue
+			}
+			numSettledInBatch++
+		}
+
+		numSettled += numSettledInBatch
+		// a batch where nothing could be settled would be picked up again, retry on the next tick instead
+		if len(tasks) < settlementBatchSize || numSettledInBatch == 0 {
+			return numSettled, nil
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package settlement
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"dojo-api/db"
+	"dojo-api/pkg/staking"
+)
+
+const epsilon = 1e-9
+
+// scoredResult builds a result scoring every model on a 0-10 range
+func scoredResult(t *testing.T, id string, status db.TaskResultStatus, scores map[string]float64) db.TaskResultModel {
+	t.Helper()
+	resultData := make([]map[string]interface{}, 0, len(scores))
+	for model, score := range scores {
+		resultData = append(resultData, map[string]interface{}{
+			"model":    model,
+			"criteria": []map[string]interface{}{{"type": "score", "min": 0, "max": 10, "value": score}},
+		})
+	}
+	data, err := json.Marshal(resultData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db.TaskResultModel{
+		InnerTaskResult: db.InnerTaskResult{
+			ID:         id,
+			WorkerID:   "worker-" + id,
+			Status:     status,
+			ResultData: data,
+		},
+	}
+}
+
+func withLoss(taskResult db.TaskResultModel, loss float64) db.TaskResultModel {
+	taskResult.PotentialLoss = &loss
+	return taskResult
+}
+
+func withGold(taskResult db.TaskResultModel, passed bool) db.TaskResultModel {
+	taskResult.GoldPassed = &passed
+	return taskResult
+}
+
+func withFlag(taskResult db.TaskResultModel, status db.ResultFlagStatus, reasons ...string) db.TaskResultModel {
+	if len(reasons) == 0 {
+		reasons = []string{"identical_result"}
+	}
+	taskResult.RelationsTaskResult.ResultFlag = &db.ResultFlagModel{
+		InnerResultFlag: db.InnerResultFlag{Status: status, Reasons: reasons},
+	}
+	return taskResult
+}
+
+func TestComputeShares(t *testing.T) {
+	completed := db.TaskResultStatusCompleted
+	tests := []struct {
+		name        string
+		taskResults []db.TaskResultModel
+		totalReward float64
+		policy      db.SettlementPolicy
+		// expected reward, loss and invalid reason by task result id
+		rewards map[string]float64
+		losses  map[string]float64
+		reasons map[string]string
+	}{
+		{
+			name:        "no results",
+			totalReward: 100,
+			policy:      db.SettlementPolicyEqualShare,
+		},
+		{
+			name: "equal share splits evenly",
+			taskResults: []db.TaskResultModel{
+				scoredResult(t, "a", completed, map[string]float64{"m1": 2}),
+				scoredResult(t, "b", completed, map[string]float64{"m1": 8}),
+				scoredResult(t, "c", completed, map[string]float64{"m1": 5}),
+				scoredResult(t, "d", completed, map[string]float64{"m1": 5}),
+			},
+			totalReward: 100,
+			policy:      db.SettlementPolicyEqualShare,
+			rewards:     map[string]float64{"a": 25, "b": 25, "c": 25, "d": 25},
+			losses:      map[string]float64{"a": 0, "b": 0, "c": 0, "d": 0},
+		},
+		{
+			name: "invalid results lose nothing and are left out",
+			taskResults: []db.TaskResultModel{
+				withLoss(scoredResult(t, "a", completed, map[string]float64{"m1": 5}), 3),
+				withLoss(scoredResult(t, "b", db.TaskResultStatusInvalid, map[string]float64{"m1": 5}), 3),
+			},
+			totalReward: 10,
+			policy:      db.SettlementPolicyEqualShare,
+			rewards:     map[string]float64{"a": 10, "b": 0},
+			losses:      map[string]float64{"a": 0, "b": 0},
+			reasons:     map[string]string{"a": "", "b": InvalidReasonStatus},
+		},
+		{
+			name: "failed gold and confirmed collusion lose their potential loss",
+			taskResults: []db.TaskResultModel{
+				withLoss(withGold(scoredResult(t, "a", completed, map[string]float64{"m1": 5}), true), 2),
+				withLoss(withGold(scoredResult(t, "b", completed, map[string]float64{"m1": 5}), false), 2),
+				withLoss(withFlag(scoredResult(t, "c", completed, map[string]float64{"m1": 5}), db.ResultFlagStatusConfirmed), 4),
+				withLoss(withFlag(scoredResult(t, "d", completed, map[string]float64{"m1": 5}), db.ResultFlagStatusDismissed), 4),
+			},
+			totalReward: 30,
+			policy:      db.SettlementPolicyEqualShare,
+			rewards:     map[string]float64{"a": 15, "b": 0, "c": 0, "d": 15},
+			losses:      map[string]float64{"a": 0, "b": 2, "c": 4, "d": 0},
+			reasons:     map[string]string{"a": "", "b": InvalidReasonGoldFailed, "c": InvalidReasonCollusion, "d": ""},
+		},
+		{
+			name: "consensus weighted favours agreeing results",
+			taskResults: []db.TaskResultModel{
+				scoredResult(t, "a", completed, map[string]float64{"m1": 5}),
+				scoredResult(t, "b", completed, map[string]float64{"m1": 5}),
+				scoredResult(t, "c", completed, map[string]float64{"m1": 10}),
+			},
+			totalReward: 100,
+			policy:      db.SettlementPolicyConsensusWeighted,
+			// weights 0.75, 0.75 and 0.5 out of 2
+			rewards: map[string]float64{"a": 37.5, "b": 37.5, "c": 25},
+		},
+		{
+			name: "consensus weighted falls back to equal shares when nobody agrees",
+			taskResults: []db.TaskResultModel{
+				scoredResult(t, "a", completed, map[string]float64{"m1": 0}),
+				scoredResult(t, "b", completed, map[string]float64{"m1": 10}),
+			},
+			totalReward: 50,
+			policy:      db.SettlementPolicyConsensusWeighted,
+			rewards:     map[string]float64{"a": 25, "b": 25},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares, err := ComputeShares(tt.taskResults, tt.totalReward, tt.policy)
+			if err != nil {
+				t.Fatalf("ComputeShares() error = %v", err)
+			}
+			if len(shares) != len(tt.taskResults) {
+				t.Fatalf("ComputeShares() returned %d shares, want %d", len(shares), len(tt.taskResults))
+			}
+
+			totalPaid := 0.0
+			for _, share := range shares {
+				totalPaid += share.Reward
+				if want, ok := tt.rewards[share.TaskResultId]; ok && math.Abs(share.Reward-want) > epsilon {
+					t.Errorf("reward of %s = %v, want %v", share.TaskResultId, share.Reward, want)
+				}
+				if want, ok := tt.losses[share.TaskResultId]; ok && math.Abs(share.Loss-want) > epsilon {
+					t.Errorf("loss of %s = %v, want %v", share.TaskResultId, share.Loss, want)
+				}
+				if want, ok := tt.reasons[share.TaskResultId]; ok && share.InvalidReason != want {
+					t.Errorf("invalid reason of %s = %q, want %q", share.TaskResultId, share.InvalidReason, want)
+				}
+				if share.Valid != (share.InvalidReason == "") {
+					t.Errorf("share %s is valid = %v with invalid reason %q", share.TaskResultId, share.Valid, share.InvalidReason)
+				}
+			}
+			// the whole reward is paid out as soon as one result is valid
+			if len(tt.rewards) > 0 && math.Abs(totalPaid-tt.totalReward) > epsilon {
+				t.Errorf("total paid = %v, want %v", totalPaid, tt.totalReward)
+			}
+		})
+	}
+}
+
+func TestConsensusWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		vectors []map[string]float64
+		want    []float64
+	}{
+		{
+			name:    "no results",
+			vectors: []map[string]float64{},
+			want:    []float64{},
+		},
+		{
+			name:    "a single result counts fully",
+			vectors: []map[string]float64{{"m1:score": 0.3}},
+			want:    []float64{1},
+		},
+		{
+			name:    "identical results agree fully",
+			vectors: []map[string]float64{{"m1:score": 0.4}, {"m1:score": 0.4}, {"m1:score": 0.4}},
+			want:    []float64{1, 1, 1},
+		},
+		{
+			name:    "opposite results do not agree at all",
+			vectors: []map[string]float64{{"m1:score": 0}, {"m1:score": 1}},
+			want:    []float64{0, 0},
+		},
+		{
+			name:    "distance to the mean of the others",
+			vectors: []map[string]float64{{"m1:score": 0.5}, {"m1:score": 0.5}, {"m1:score": 1}},
+			want:    []float64{0.75, 0.75, 0.5},
+		},
+		{
+			name: "distances are averaged over the shared keys",
+			vectors: []map[string]float64{
+				{"m1:score": 0.2, "m2:score": 0.8},
+				{"m1:score": 0.4, "m2:score": 0.8},
+			},
+			want: []float64{0.9, 0.9},
+		},
+		{
+			name:    "keys nobody else scored are ignored",
+			vectors: []map[string]float64{{"m1:score": 0.5, "m2:score": 0}, {"m1:score": 0.5}},
+			want:    []float64{1, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := consensusWeights(tt.vectors)
+			if len(got) != len(tt.want) {
+				t.Fatalf("consensusWeights() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if math.Abs(got[i]-tt.want[i]) > epsilon {
+					t.Errorf("consensusWeights() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestHasPendingFlags(t *testing.T) {
+	completed := db.TaskResultStatusCompleted
+	tests := []struct {
+		name        string
+		taskResults []db.TaskResultModel
+		want        bool
+	}{
+		{
+			name:        "no flags",
+			taskResults: []db.TaskResultModel{scoredResult(t, "a", completed, nil)},
+			want:        false,
+		},
+		{
+			name:        "pending collusion flag holds the task",
+			taskResults: []db.TaskResultModel{withFlag(scoredResult(t, "a", completed, nil), db.ResultFlagStatusPending)},
+			want:        true,
+		},
+		{
+			name:        "reviewed collusion flag does not hold the task",
+			taskResults: []db.TaskResultModel{withFlag(scoredResult(t, "a", completed, nil), db.ResultFlagStatusDismissed)},
+			want:        false,
+		},
+		{
+			name:        "pending too fast flag does not hold the task",
+			taskResults: []db.TaskResultModel{withFlag(scoredResult(t, "a", completed, nil), db.ResultFlagStatusPending, "too_fast")},
+			want:        false,
+		},
+		{
+			name: "too fast flag merged into a collusion cluster holds the task",
+			taskResults: []db.TaskResultModel{
+				withFlag(scoredResult(t, "a", completed, nil), db.ResultFlagStatusPending, "same_ip", "too_fast"),
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasPendingFlags(tt.taskResults); got != tt.want {
+				t.Errorf("hasPendingFlags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildStakeOutcomes(t *testing.T) {
+	stake := 5.0
+	staked := scoredResult(t, "a", db.TaskResultStatusCompleted, map[string]float64{"m1": 5})
+	staked.StakeAmount = &stake
+	staked.TaskID = "task"
+	unstaked := scoredResult(t, "b", db.TaskResultStatusCompleted, map[string]float64{"m1": 5})
+
+	outcomes := buildStakeOutcomes(
+		[]db.TaskResultModel{staked, unstaked},
+		[]ResultShare{{TaskResultId: "a", Loss: 2}, {TaskResultId: "b", Loss: 1}},
+	)
+	if len(outcomes) != 1 {
+		t.Fatalf("buildStakeOutcomes() returned %d outcomes, want 1", len(outcomes))
+	}
+	want := staking.StakeOutcome{TaskResultId: "a", WorkerId: "worker-a", TaskId: "task", StakeAmount: 5, SlashAmount: 2}
+	if outcomes[0] != want {
+		t.Errorf("buildStakeOutcomes() = %+v, want %+v", outcomes[0], want)
+	}
+}
+
+func TestCancelShares(t *testing.T) {
+	shares := []ResultShare{
+		{TaskResultId: "a", Valid: true, Weight: 0.5, Reward: 50},
+		{TaskResultId: "b", InvalidReason: InvalidReasonGoldFailed, Loss: 5},
+	}
+	cancelShares(shares)
+	for _, share := range shares {
+		if share.Weight != 0 || share.Reward != 0 || share.Loss != 0 {
+			t.Errorf("cancelled share %s = %+v, want no weight, reward or loss", share.TaskResultId, share)
+		}
+	}
+	// the reason a result would have been invalid is kept for the report
+	if shares[1].InvalidReason != InvalidReasonGoldFailed {
+		t.Errorf("invalid reason = %q, want %q", shares[1].InvalidReason, InvalidReasonGoldFailed)
+	}
+}
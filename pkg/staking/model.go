@@ -0,0 +1,65 @@
+package staking
+
+import (
+	"errors"
+	"time"
+
+	"dojo-api/db"
+)
+
+var (
+	ErrInsufficientStake  = errors.New("insufficient stake")
+	ErrInvalidStakeAmount = errors.New("stake amount must be greater than 0")
+)
+
+type DepositStakeRequest struct {
+	Amount float64 `json:"amount" binding:"required"`
+	// the on-chain transaction the operator verified the deposit against, kept on the ledger entry
+	Reference string `json:"reference" binding:"required"`
+}
+
+type StakeBalanceResponse struct {
+	WorkerId string `json:"workerId"`
+	// stake that can be locked by new submissions
+	Available float64 `json:"available"`
+	// stake locked by submissions whose tasks are not settled yet
+	Locked         float64 `json:"locked"`
+	TotalDeposited float64 `json:"totalDeposited"`
+	TotalSlashed   float64 `json:"totalSlashed"`
+}
+
+type StakeLedgerEntryResponse struct {
+	Id           string            `json:"id"`
+	Type         db.StakeEntryType `json:"type"`
+	Amount       float64           `json:"amount"`
+	TaskId       *string           `json:"taskId"`
+	TaskResultId *string           `json:"taskResultId"`
+	Reference    *string           `json:"reference"`
+	CreatedAt    time.Time         `json:"createdAt"`
+}
+
+type StakeLedgerResponse struct {
+	Entries    []StakeLedgerEntryResponse `json:"entries"`
+	TotalItems int                        `json:"totalItems"`
+	Page       int                        `json:"page"`
+	Limit      int                        `json:"limit"`
+}
+
+// StakeLock is the stake locked for a submission, the result must be stored under TaskResultId
+type StakeLock struct {
+	WorkerId        string
+	TaskId          string
+	TaskResultId    string
+	StakeAmount     float64
+	PotentialReward float64
+	PotentialLoss   float64
+}
+
+// StakeOutcome is how much of a settled submission's stake is slashed, the rest is released
+type StakeOutcome struct {
+	TaskResultId string
+	WorkerId     string
+	TaskId       string
+	StakeAmount  float64
+	SlashAmount  float64
+}
@@ -0,0 +1,211 @@
+package staking
+
+import (
+	"context"
+	"math"
+	"os"
+	"strconv"
+
+	"dojo-api/db"
+	"dojo-api/pkg/orm"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+type StakingService struct {
+	stakeLedgerORM *orm.StakeLedgerORM
+}
+
+func NewStakingService() *StakingService {
+	return &StakingService{
+		stakeLedgerORM: orm.NewStakeLedgerORM(),
+	}
+}
+
+// stakePerSubmission reads TASK_STAKE_AMOUNT, the stake locked by every submission, staking is off when it is unset or 0
+func stakePerSubmission() float64 {
+	value := os.Getenv("TASK_STAKE_AMOUNT")
+	if value == "" {
+		return 0
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount < 0 {
+		log.Warn().Str("value", value).Msg("Invalid TASK_STAKE_AMOUNT, staking is disabled")
+		return 0
+	}
+	return amount
+}
+
+// Deposit credits stake to the worker, deposits are verified by an operator before they are credited
+func (s *StakingService) Deposit(ctx context.Context, worker *db.DojoWorkerModel, request DepositStakeRequest) (*StakeBalanceResponse, error) {
+	if request.Amount <= 0 || math.IsInf(request.Amount, 0) || math.IsNaN(request.Amount) {
+		return nil, ErrInvalidStakeAmount
+	}
+
+	if err := s.stakeLedgerORM.Deposit(ctx, worker.ID, request.Amount, request.Reference); err != nil {
+		log.Error().Err(err).Str("workerId", worker.ID).Msg("Error depositing stake")
+		return nil, err
+	}
+
+	log.Info().Str("workerId", worker.ID).Float64("amount", request.Amount).Msg("Stake deposited")
+	return s.GetBalance(ctx, worker.ID)
+}
+
+// LockStake locks the configured stake for the worker's submission to the task, returns nil when staking is off
+func (s *StakingService) LockStake(ctx context.Context, workerId string, taskModel *db.TaskModel) (*StakeLock, error) {
+	stakeAmount := stakePerSubmission()
+	if stakeAmount == 0 {
+		return nil, nil
+	}
+
+	lock := &StakeLock{
+		WorkerId:     workerId,
+		TaskId:       taskModel.ID,
+		TaskResultId: uuid.New().String(),
+		StakeAmount:  stakeAmount,
+		// the whole stake is slashed for a failed gold check or confirmed collusion
+		PotentialLoss: stakeAmount,
+	}
+	// an equal share of the reward, the settled reward differs under consensus weighting or with invalid results
+	if totalReward, ok := taskModel.TotalReward(); ok && taskModel.MaxResults > 0 {
+		lock.PotentialReward = totalReward / float64(taskModel.MaxResults)
+	}
+
+	locked, err := s.stakeLedgerORM.Lock(ctx, workerId, stakeAmount, taskModel.ID, lock.TaskResultId)
+	if err != nil {
+		log.Error().Err(err).Str("workerId", workerId).Str("taskId", taskModel.ID).Msg("Error locking stake")
+		return nil, err
+	}
+	if !locked {
+		return nil, ErrInsufficientStake
+	}
+	return lock, nil
+}
+
+// ReleaseLock gives back the stake of a submission that could not be stored
+func (s *StakingService) ReleaseLock(ctx context.Context, lock *StakeLock) error {
+	if err := s.stakeLedgerORM.Release(ctx, lock.WorkerId, lock.StakeAmount, lock.TaskId, lock.TaskResultId); err != nil {
+		log.Error().Err(err).Str("taskResultId", lock.TaskResultId).Msg("Error releasing stake")
+		return err
+	}
+	return nil
+}
+
+// SettleStakes slashes and releases the stake of every settled submission. Settling a task again never moves
+// stake twice, a submission whose stake was already settled only has the loss it owes on top of what was
+// already slashed clawed back, e.g. when it was confirmed as collusion after the task was first settled.
+func (s *StakingService) SettleStakes(ctx context.Context, outcomes []StakeOutcome) error {
+	taskResultIds := make([]string, 0, len(outcomes))
+	for _, outcome := range outcomes {
+		if outcome.StakeAmount > 0 {
+			taskResultIds = append(taskResultIds, outcome.TaskResultId)
+		}
+	}
+	if len(taskResultIds) == 0 {
+		return nil
+	}
+
+	settled, err := s.stakeLedgerORM.GetSettledStakes(ctx, taskResultIds)
+	if err != nil {
+		log.Error().Err(err).Msg("Error getting settled stakes")
+		return err
+	}
+
+	for _, outcome := range outcomes {
+		if outcome.StakeAmount <= 0 {
+			continue
+		}
+
+		if settledStake, ok := settled[outcome.TaskResultId]; ok {
+			clawbackAmount := clawbackStake(outcome, settledStake)
+			if clawbackAmount <= 0 {
+				continue
+			}
+			if err := s.stakeLedgerORM.Clawback(ctx, outcome.WorkerId, clawbackAmount, outcome.TaskId, outcome.TaskResultId); err != nil {
+				log.Error().Err(err).Str("taskResultId", outcome.TaskResultId).Msg("Error clawing back stake")
+				return err
+			}
+			log.Info().Str("taskResultId", outcome.TaskResultId).Float64("amount", clawbackAmount).Msg("Stake clawed back")
+			continue
+		}
+
+		slashAmount, releaseAmount := splitStake(outcome)
+		if err := s.stakeLedgerORM.Settle(ctx, outcome.WorkerId, slashAmount, releaseAmount, outcome.TaskId, outcome.TaskResultId); err != nil {
+			log.Error().Err(err).Str("taskResultId", outcome.TaskResultId).Msg("Error settling stake")
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStake returns how much of a submission's stake is slashed and how much is given back, the slash is
+// capped to the stake
+func splitStake(outcome StakeOutcome) (float64, float64) {
+	slashAmount := math.Min(math.Max(outcome.SlashAmount, 0), outcome.StakeAmount)
+	return slashAmount, outcome.StakeAmount - slashAmount
+}
+
+// clawbackStake returns how much of the loss of an already settled submission was not slashed yet, at most what
+// was released to the worker
+func clawbackStake(outcome StakeOutcome, settled orm.SettledStake) float64 {
+	slashAmount, _ := splitStake(outcome)
+	return math.Min(math.Max(slashAmount-settled.Slashed, 0), settled.Released)
+}
+
+// GetBalance rebuilds the worker's balances from the ledger
+func (s *StakingService) GetBalance(ctx context.Context, workerId string) (*StakeBalanceResponse, error) {
+	rows, err := s.stakeLedgerORM.GetTotals(ctx, workerId)
+	if err != nil {
+		log.Error().Err(err).Str("workerId", workerId).Msg("Error getting stake totals")
+		return nil, err
+	}
+
+	totals := make(map[db.StakeEntryType]float64)
+	for _, row := range rows {
+		totals[row.Type] = float64(row.Total)
+	}
+
+	return &StakeBalanceResponse{
+		WorkerId:       workerId,
+		Available:      totals[db.StakeEntryTypeDeposit] - totals[db.StakeEntryTypeLock] + totals[db.StakeEntryTypeRelease] - totals[db.StakeEntryTypeClawback],
+		Locked:         totals[db.StakeEntryTypeLock] - totals[db.StakeEntryTypeRelease] - totals[db.StakeEntryTypeSlash],
+		TotalDeposited: totals[db.StakeEntryTypeDeposit],
+		TotalSlashed:   totals[db.StakeEntryTypeSlash] + totals[db.StakeEntryTypeClawback],
+	}, nil
+}
+
+func (s *StakingService) GetLedger(ctx context.Context, workerId string, page int, limit int) (*StakeLedgerResponse, error) {
+	entries, total, err := s.stakeLedgerORM.GetEntries(ctx, workerId, (page-1)*limit, limit)
+	if err != nil {
+		log.Error().Err(err).Str("workerId", workerId).Msg("Error getting stake ledger")
+		return nil, err
+	}
+
+	responses := make([]StakeLedgerEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		response := StakeLedgerEntryResponse{
+			Id:        entry.ID,
+			Type:      entry.Type,
+			Amount:    entry.Amount,
+			CreatedAt: entry.CreatedAt,
+		}
+		if taskId, ok := entry.TaskID(); ok {
+			response.TaskId = &taskId
+		}
+		if taskResultId, ok := entry.TaskResultID(); ok {
+			response.TaskResultId = &taskResultId
+		}
+		if reference, ok := entry.Reference(); ok {
+			response.Reference = &reference
+		}
+		responses = append(responses, response)
+	}
+
+	return &StakeLedgerResponse{
+		Entries:    responses,
+		TotalItems: total,
+		Page:       page,
+		Limit:      limit,
+	}, nil
+}
@@ -0,0 +1,120 @@
+package staking
+
+import (
+	"testing"
+
+	"dojo-api/pkg/orm"
+)
+
+func TestSplitStake(t *testing.T) {
+	tests := []struct {
+		name        string
+		outcome     StakeOutcome
+		wantSlash   float64
+		wantRelease float64
+	}{
+		{
+			name:        "nothing slashed releases the whole stake",
+			outcome:     StakeOutcome{StakeAmount: 10, SlashAmount: 0},
+			wantSlash:   0,
+			wantRelease: 10,
+		},
+		{
+			name:        "partial slash releases the rest",
+			outcome:     StakeOutcome{StakeAmount: 10, SlashAmount: 4},
+			wantSlash:   4,
+			wantRelease: 6,
+		},
+		{
+			name:        "full slash releases nothing",
+			outcome:     StakeOutcome{StakeAmount: 10, SlashAmount: 10},
+			wantSlash:   10,
+			wantRelease: 0,
+		},
+		{
+			name:        "slash is capped to the stake",
+			outcome:     StakeOutcome{StakeAmount: 10, SlashAmount: 25},
+			wantSlash:   10,
+			wantRelease: 0,
+		},
+		{
+			name:        "negative slash is ignored",
+			outcome:     StakeOutcome{StakeAmount: 10, SlashAmount: -3},
+			wantSlash:   0,
+			wantRelease: 10,
+		},
+		{
+			name:        "no stake moves nothing",
+			outcome:     StakeOutcome{StakeAmount: 0, SlashAmount: 5},
+			wantSlash:   0,
+			wantRelease: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slash, release := splitStake(tt.outcome)
+			if slash != tt.wantSlash || release != tt.wantRelease {
+				t.Errorf("splitStake() = (%v, %v), want (%v, %v)", slash, release, tt.wantSlash, tt.wantRelease)
+			}
+			// stake is never created or lost by settling it
+			if slash+release != tt.outcome.StakeAmount {
+				t.Errorf("slash %v + release %v != stake %v", slash, release, tt.outcome.StakeAmount)
+			}
+		})
+	}
+}
+
+func TestClawbackStake(t *testing.T) {
+	tests := []struct {
+		name    string
+		outcome StakeOutcome
+		settled orm.SettledStake
+		want    float64
+	}{
+		{
+			name:    "loss already slashed takes nothing back",
+			outcome: StakeOutcome{StakeAmount: 10, SlashAmount: 10},
+			settled: orm.SettledStake{Slashed: 10},
+			want:    0,
+		},
+		{
+			name:    "no loss takes nothing back",
+			outcome: StakeOutcome{StakeAmount: 10, SlashAmount: 0},
+			settled: orm.SettledStake{Released: 10},
+			want:    0,
+		},
+		{
+			name:    "loss found after the release takes back the difference",
+			outcome: StakeOutcome{StakeAmount: 10, SlashAmount: 10},
+			settled: orm.SettledStake{Released: 10},
+			want:    10,
+		},
+		{
+			name:    "loss above a partial slash takes back the rest",
+			outcome: StakeOutcome{StakeAmount: 10, SlashAmount: 10},
+			settled: orm.SettledStake{Released: 6, Slashed: 4},
+			want:    6,
+		},
+		{
+			name:    "loss is capped to the stake",
+			outcome: StakeOutcome{StakeAmount: 10, SlashAmount: 25},
+			settled: orm.SettledStake{Released: 10},
+			want:    10,
+		},
+		{
+			name:    "never more than was released",
+			outcome: StakeOutcome{StakeAmount: 10, SlashAmount: 10},
+			settled: orm.SettledStake{Released: 3, Slashed: 4},
+			want:    3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clawbackStake(tt.outcome, tt.settled); got != tt.want {
+				t.Errorf("clawbackStake() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
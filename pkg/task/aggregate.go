@@ -0,0 +1,305 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dojo-api/db"
+	"dojo-api/pkg/cache"
+	"dojo-api/pkg/monitoring"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// The aggregate of a task is cached as a Redis hash of value counts, numResultsField holds the number of submissions
+// and every other field, model|criteria type|value, how often the value was submitted. Submissions are added with
+// HINCRBY so concurrent submissions never overwrite each other, the hash is rebuilt from the stored results whenever
+// its number of submissions differs from the task's.
+const (
+	numResultsField   = "numResults"
+	aggregateFieldSep = "|"
+)
+
+// only adds to a cached aggregate, a missing one is rebuilt from the stored results on the next read
+var addToAggregateScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+for i = 1, #ARGV, 2 do
+	redis.call('HINCRBY', KEYS[1], ARGV[i], ARGV[i + 1])
+end
+return 1`)
+
+// valueCounts maps model -> criteria type -> submitted value -> number of submissions
+type valueCounts map[string]map[CriteriaType]map[float64]int
+
+func (counts valueCounts) addValue(model string, criteriaType CriteriaType, value float64, count int) {
+	if _, ok := counts[model]; !ok {
+		counts[model] = make(map[CriteriaType]map[float64]int)
+	}
+	if _, ok := counts[model][criteriaType]; !ok {
+		counts[model][criteriaType] = make(map[float64]int)
+	}
+	counts[model][criteriaType][value] += count
+}
+
+func (counts valueCounts) addResults(resultData []Result) {
+	for _, result := range resultData {
+		for _, criteria := range result.Criteria {
+			if value, ok := criteriaValue(criteria); ok {
+				counts.addValue(result.Model, criteria.GetType(), value, 1)
+			}
+		}
+	}
+}
+
+func aggregateCacheKey(taskId string) string {
+	cache := cache.GetCacheInstance()
+	return cache.BuildCacheKey(cache.Keys.TaskResultAggregate, taskId)
+}
+
+func aggregateField(model string, criteriaType CriteriaType, value float64) string {
+	return model + aggregateFieldSep + string(criteriaType) + aggregateFieldSep + strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// parseAggregateField splits from the right since model names may contain the separator
+func parseAggregateField(field string) (string, CriteriaType, float64, bool) {
+	valueSep := strings.LastIndex(field, aggregateFieldSep)
+	if valueSep < 0 {
+		return "", "", 0, false
+	}
+	typeSep := strings.LastIndex(field[:valueSep], aggregateFieldSep)
+	if typeSep < 0 {
+		return "", "", 0, false
+	}
+	value, err := strconv.ParseFloat(field[valueSep+1:], 64)
+	if err != nil {
+		return "", "", 0, false
+	}
+	return field[:typeSep], CriteriaType(field[typeSep+1 : valueSep]), value, true
+}
+
+// GetTaskResultAggregate returns the score statistics of a task, the task is read from the database since its
+// number of results decides whether the cached value counts are current
+func (taskService *TaskService) GetTaskResultAggregate(ctx context.Context, taskId string) (*TaskResultAggregateResponse, error) {
+	task, err := taskService.taskORM.GetByIdUncached(ctx, taskId)
+	if err != nil {
+		log.Error().Err(err).Str("taskId", taskId).Msg("Error getting task for aggregation")
+		return nil, err
+	}
+
+	counts, numResults, err := loadValueCounts(ctx, taskId)
+	if err != nil {
+		log.Warn().Err(err).Str("taskId", taskId).Msg("Failed to read cached task result aggregate")
+	}
+	if err != nil || numResults != task.NumResults {
+		monitoring.CacheRequestsTotal.WithLabelValues(monitoring.CacheMiss).Inc()
+		return taskService.RefreshTaskResultAggregate(ctx, task)
+	}
+
+	monitoring.CacheRequestsTotal.WithLabelValues(monitoring.CacheHit).Inc()
+	return buildTaskResultAggregate(task, counts, numResults)
+}
+
+// RefreshTaskResultAggregate recomputes the aggregate from the stored results and replaces the cached value counts
+func (taskService *TaskService) RefreshTaskResultAggregate(ctx context.Context, task *db.TaskModel) (*TaskResultAggregateResponse, error) {
+	taskResults, err := taskService.taskResultORM.GetTaskResultsByTaskId(ctx, task.ID)
+	if err != nil {
+		log.Error().Err(err).Str("taskId", task.ID).Msg("Error getting task results for aggregation")
+		return nil, err
+	}
+
+	counts, numResults, err := collectValueCounts(taskResults)
+	if err != nil {
+		log.Error().Err(err).Str("taskId", task.ID).Msg("Error aggregating task results")
+		return nil, err
+	}
+
+	fields := []interface{}{numResultsField, numResults}
+	for model, criteriaCounts := range counts {
+		for criteriaType, countsByValue := range criteriaCounts {
+			for value, count := range countsByValue {
+				fields = append(fields, aggregateField(model, criteriaType, value), count)
+			}
+		}
+	}
+
+	redisCache := cache.GetCacheInstance()
+	cacheKey := aggregateCacheKey(task.ID)
+	if _, err := redisCache.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, cacheKey)
+		pipe.HSet(ctx, cacheKey, fields...)
+		pipe.Expire(ctx, cacheKey, redisCache.GetCacheExpiration(redisCache.Keys.TaskResultAggregate))
+		return nil
+	}); err != nil {
+		log.Warn().Err(err).Str("taskId", task.ID).Msg("Failed to cache task result aggregate")
+	}
+
+	return buildTaskResultAggregate(task, counts, numResults)
+}
+
+// AddResultToAggregate adds a COMPLETED submission to the cached value counts of the task
+func (taskService *TaskService) AddResultToAggregate(ctx context.Context, taskId string, resultData []Result) error {
+	counts := make(valueCounts)
+	counts.addResults(resultData)
+
+	args := []interface{}{numResultsField, 1}
+	for model, criteriaCounts := range counts {
+		for criteriaType, countsByValue := range criteriaCounts {
+			for value, count := range countsByValue {
+				args = append(args, aggregateField(model, criteriaType, value), count)
+			}
+		}
+	}
+	return addToAggregateScript.Run(ctx, cache.GetCacheInstance().Redis, []string{aggregateCacheKey(taskId)}, args...).Err()
+}
+
+// loadValueCounts reads the cached value counts of a task, a task without cached counts has no submissions
+func loadValueCounts(ctx context.Context, taskId string) (valueCounts, int, error) {
+	fields, err := cache.GetCacheInstance().Redis.HGetAll(ctx, aggregateCacheKey(taskId)).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	counts := make(valueCounts)
+	numResults := 0
+	for field, rawCount := range fields {
+		count, err := strconv.Atoi(rawCount)
+		if err != nil {
+			return nil, 0, err
+		}
+		if field == numResultsField {
+			numResults = count
+			continue
+		}
+		if model, criteriaType, value, ok := parseAggregateField(field); ok {
+			counts.addValue(model, criteriaType, value, count)
+		}
+	}
+	return counts, numResults, nil
+}
+
+// collectValueCounts counts the values of the COMPLETED results, the only results that count as submissions
+func collectValueCounts(taskResults []db.TaskResultModel) (valueCounts, int, error) {
+	counts := make(valueCounts)
+	numResults := 0
+	for _, taskResult := range taskResults {
+		if taskResult.Status != db.TaskResultStatusCompleted {
+			continue
+		}
+
+		var resultData []Result
+		if err := json.Unmarshal(taskResult.ResultData, &resultData); err != nil {
+			return nil, 0, err
+		}
+		numResults++
+		counts.addResults(resultData)
+	}
+	return counts, numResults, nil
+}
+
+// AggregateTaskResults computes the statistics of every model response and criterion defined in the task data,
+// only COMPLETED results count as submissions
+func AggregateTaskResults(task *db.TaskModel, taskResults []db.TaskResultModel) (*TaskResultAggregateResponse, error) {
+	counts, numResults, err := collectValueCounts(taskResults)
+	if err != nil {
+		return nil, err
+	}
+	return buildTaskResultAggregate(task, counts, numResults)
+}
+
+func buildTaskResultAggregate(task *db.TaskModel, counts valueCounts, numResults int) (*TaskResultAggregateResponse, error) {
+	var taskData TaskData
+	if err := json.Unmarshal(task.TaskData, &taskData); err != nil {
+		return nil, err
+	}
+
+	models := make([]ModelAggregate, 0, len(taskData.Responses))
+	for _, response := range taskData.Responses {
+		criteriaAggregates := make([]CriteriaAggregate, 0, len(response.Criteria))
+		for _, criteria := range response.Criteria {
+			criteriaAggregates = append(criteriaAggregates, aggregateCounts(criteria.GetType(), counts[response.Model][criteria.GetType()]))
+		}
+		models = append(models, ModelAggregate{
+			Model:    response.Model,
+			Criteria: criteriaAggregates,
+		})
+	}
+
+	return &TaskResultAggregateResponse{
+		TaskId:     task.ID,
+		TaskStatus: task.Status,
+		NumResults: numResults,
+		Models:     models,
+		ComputedAt: time.Now(),
+	}, nil
+}
+
+// criteriaValue returns the numeric value submitted for a criterion, false for non numeric criteria
+func criteriaValue(criteria Criteria) (float64, bool) {
+	switch c := criteria.(type) {
+	case ScoreCriteria:
+		return c.MinerScore, true
+	default:
+		return 0, false
+	}
+}
+
+func aggregateCounts(criteriaType CriteriaType, counts map[float64]int) CriteriaAggregate {
+	aggregate := CriteriaAggregate{
+		Type:      criteriaType,
+		Histogram: make([]HistogramBucket, 0, len(counts)),
+	}
+	for value, count := range counts {
+		if count > 0 {
+			aggregate.Histogram = append(aggregate.Histogram, HistogramBucket{Value: value, Count: count})
+			aggregate.Count += count
+		}
+	}
+	if aggregate.Count == 0 {
+		return aggregate
+	}
+	sort.Slice(aggregate.Histogram, func(i, j int) bool {
+		return aggregate.Histogram[i].Value < aggregate.Histogram[j].Value
+	})
+
+	var sum float64
+	for _, bucket := range aggregate.Histogram {
+		sum += bucket.Value * float64(bucket.Count)
+	}
+	aggregate.Mean = sum / float64(aggregate.Count)
+
+	var squaredDiffs float64
+	for _, bucket := range aggregate.Histogram {
+		squaredDiffs += float64(bucket.Count) * (bucket.Value - aggregate.Mean) * (bucket.Value - aggregate.Mean)
+	}
+	// population standard deviation, every submission of the task is included
+	aggregate.Std = math.Sqrt(squaredDiffs / float64(aggregate.Count))
+
+	middle := aggregate.Count / 2
+	if aggregate.Count%2 == 0 {
+		aggregate.Median = (valueAt(aggregate.Histogram, middle-1) + valueAt(aggregate.Histogram, middle)) / 2
+	} else {
+		aggregate.Median = valueAt(aggregate.Histogram, middle)
+	}
+	aggregate.Min = aggregate.Histogram[0].Value
+	aggregate.Max = aggregate.Histogram[len(aggregate.Histogram)-1].Value
+	return aggregate
+}
+
+// valueAt returns the value at the given position of the sorted submissions
+func valueAt(histogram []HistogramBucket, position int) float64 {
+	for _, bucket := range histogram {
+		if position < bucket.Count {
+			return bucket.Value
+		}
+		position -= bucket.Count
+	}
+	return histogram[len(histogram)-1].Value
+}
@@ -0,0 +1,51 @@
+package task
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+)
+
+// ModelAlias returns the opaque name workers see in place of a model, scoped to one task so the same model
+// gets a different alias on every task. The secret keeps workers from hashing candidate model names themselves.
+func ModelAlias(scope string, model string) string {
+	mac := hmac.New(sha256.New, []byte(os.Getenv("MODEL_ALIAS_SECRET")))
+	mac.Write([]byte(scope + ":" + model))
+	return "model-" + hex.EncodeToString(mac.Sum(nil))[:12]
+}
+
+// BlindModels replaces the model names of the task's responses with their aliases
+func BlindModels(taskData *TaskData, scope string) {
+	for i := range taskData.Responses {
+		taskData.Responses[i].Model = ModelAlias(scope, taskData.Responses[i].Model)
+	}
+}
+
+// BlindResults replaces the model names in the results with their aliases, the opposite of UnblindResults
+func BlindResults(results []Result, scope string) []Result {
+	blinded := make([]Result, 0, len(results))
+	for _, result := range results {
+		result.Model = ModelAlias(scope, result.Model)
+		blinded = append(blinded, result)
+	}
+	return blinded
+}
+
+// UnblindResults maps aliased model names in the results back to the real model names of the task,
+// results that already name a real model are left as they are
+func UnblindResults(results []Result, taskData TaskData, scope string) []Result {
+	modelByAlias := make(map[string]string, len(taskData.Responses))
+	for _, response := range taskData.Responses {
+		modelByAlias[ModelAlias(scope, response.Model)] = response.Model
+	}
+
+	unblinded := make([]Result, 0, len(results))
+	for _, result := range results {
+		if model, ok := modelByAlias[result.Model]; ok {
+			result.Model = model
+		}
+		unblinded = append(unblinded, result)
+	}
+	return unblinded
+}
@@ -0,0 +1,183 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"dojo-api/db"
+	"dojo-api/pkg/orm"
+
+	"github.com/rs/zerolog/log"
+)
+
+type CalibrationMethod string
+
+const (
+	// CalibrationZScore expresses a score as standard deviations from the worker's own mean score
+	CalibrationZScore CalibrationMethod = "zscore"
+	// CalibrationQuantile maps a score to its percentile among the worker's scores, stretched over the criteria range
+	CalibrationQuantile CalibrationMethod = "quantile"
+)
+
+// number of fixed width buckets the normalised [0, 1] score range is split into
+const calibrationBuckets = 20
+
+// workers with fewer scores for a criteria type are not calibrated, their statistics are too noisy
+const minCalibrationSamples = 10
+
+func ParseCalibrationMethod(method string) (CalibrationMethod, error) {
+	switch CalibrationMethod(method) {
+	case CalibrationZScore, CalibrationQuantile:
+		return CalibrationMethod(method), nil
+	default:
+		return "", fmt.Errorf("invalid calibration method: %s", method)
+	}
+}
+
+// workerScoreStats is the distribution of one worker's normalised scores for one criteria type
+type workerScoreStats struct {
+	count   int
+	sum     float64
+	sumSq   float64
+	buckets [calibrationBuckets]int
+}
+
+// Calibrator turns raw scores into per worker calibrated scores using the workers' running score distributions
+type Calibrator struct {
+	method CalibrationMethod
+	// worker ID -> criteria type -> stats
+	stats map[string]map[CriteriaType]*workerScoreStats
+}
+
+// UpdateWorkerScoreStats adds the scores of a completed submission to the worker's running distribution
+func (t *TaskService) UpdateWorkerScoreStats(ctx context.Context, workerId string, results []Result) error {
+	samples := make([]orm.ScoreSample, 0)
+	for _, result := range results {
+		for _, criteria := range result.Criteria {
+			if value, ok := normalisedScore(criteria); ok {
+				samples = append(samples, orm.ScoreSample{
+					CriteriaType: string(criteria.GetType()),
+					Bucket:       scoreBucket(value),
+					Value:        value,
+				})
+			}
+		}
+	}
+	return orm.NewWorkerScoreBucketORM().AddScoreSamples(ctx, workerId, samples)
+}
+
+// NewCalibrator loads the score distributions of the workers who submitted the given task results
+func NewCalibrator(ctx context.Context, method CalibrationMethod, taskResults []db.TaskResultModel) (*Calibrator, error) {
+	calibrator := &Calibrator{
+		method: method,
+		stats:  make(map[string]map[CriteriaType]*workerScoreStats),
+	}
+	if len(taskResults) == 0 {
+		return calibrator, nil
+	}
+
+	workerIds := make([]string, 0, len(taskResults))
+	for _, taskResult := range taskResults {
+		workerIds = append(workerIds, taskResult.WorkerID)
+	}
+
+	buckets, err := orm.NewWorkerScoreBucketORM().GetByWorkerIds(ctx, workerIds)
+	if err != nil {
+		log.Error().Err(err).Msg("Error getting worker score buckets")
+		return nil, err
+	}
+	calibrator.addBuckets(buckets)
+	return calibrator, nil
+}
+
+func (c *Calibrator) addBuckets(buckets []db.WorkerScoreBucketModel) {
+	for _, bucket := range buckets {
+		if bucket.Bucket < 0 || bucket.Bucket >= calibrationBuckets {
+			continue
+		}
+		if _, ok := c.stats[bucket.WorkerID]; !ok {
+			c.stats[bucket.WorkerID] = make(map[CriteriaType]*workerScoreStats)
+		}
+		criteriaType := CriteriaType(bucket.CriteriaType)
+		stats, ok := c.stats[bucket.WorkerID][criteriaType]
+		if !ok {
+			stats = &workerScoreStats{}
+			c.stats[bucket.WorkerID][criteriaType] = stats
+		}
+		stats.count += bucket.Count
+		stats.sum += bucket.Sum
+		stats.sumSq += bucket.SumSq
+		stats.buckets[bucket.Bucket] += bucket.Count
+	}
+}
+
+// Calibrate returns the raw and calibrated value of every numeric criterion in the results,
+// the calibrated value is nil while the worker has too few scores for that criteria type
+func (c *Calibrator) Calibrate(workerId string, results []Result) []CalibratedScore {
+	calibratedScores := make([]CalibratedScore, 0)
+	for _, result := range results {
+		for _, criteria := range result.Criteria {
+			scoreCriteria, ok := criteria.(ScoreCriteria)
+			if !ok {
+				continue
+			}
+
+			calibratedScore := CalibratedScore{
+				Model:  result.Model,
+				Type:   criteria.GetType(),
+				Method: c.method,
+				Value:  scoreCriteria.MinerScore,
+			}
+			stats := c.stats[workerId][criteria.GetType()]
+			if stats != nil {
+				calibratedScore.NumSamples = stats.count
+			}
+			if value, ok := normalisedScore(criteria); ok && stats != nil && stats.count >= minCalibrationSamples {
+				calibrated := c.calibrate(stats, value, scoreCriteria)
+				calibratedScore.CalibratedValue = &calibrated
+			}
+			calibratedScores = append(calibratedScores, calibratedScore)
+		}
+	}
+	return calibratedScores
+}
+
+func (c *Calibrator) calibrate(stats *workerScoreStats, value float64, criteria ScoreCriteria) float64 {
+	switch c.method {
+	case CalibrationQuantile:
+		// mid rank of the score's bucket, so a worker who always gives the same score lands in the middle
+		bucket := scoreBucket(value)
+		below := 0
+		for i := 0; i < bucket; i++ {
+			below += stats.buckets[i]
+		}
+		percentile := (float64(below) + float64(stats.buckets[bucket])/2) / float64(stats.count)
+		return criteria.Min + percentile*(criteria.Max-criteria.Min)
+	default:
+		mean := stats.sum / float64(stats.count)
+		variance := stats.sumSq/float64(stats.count) - mean*mean
+		if variance <= 0 {
+			return 0
+		}
+		return (value - mean) / math.Sqrt(variance)
+	}
+}
+
+// normalisedScore maps a score onto [0, 1] over its criteria range so scores of tasks with different ranges are comparable
+func normalisedScore(criteria Criteria) (float64, bool) {
+	scoreCriteria, ok := criteria.(ScoreCriteria)
+	if !ok || scoreCriteria.Max <= scoreCriteria.Min {
+		return 0, false
+	}
+	value := (scoreCriteria.MinerScore - scoreCriteria.Min) / (scoreCriteria.Max - scoreCriteria.Min)
+	return math.Min(math.Max(value, 0), 1), true
+}
+
+func scoreBucket(value float64) int {
+	bucket := int(value * calibrationBuckets)
+	if bucket >= calibrationBuckets {
+		bucket = calibrationBuckets - 1
+	}
+	return bucket
+}
@@ -0,0 +1,42 @@
+package task
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ResultFingerprint hashes the submitted values of every model response in a canonical order,
+// so two submissions with the same scores get the same fingerprint regardless of the order they were sent in
+func ResultFingerprint(results []Result) string {
+	entries := make([]string, 0)
+	for _, result := range results {
+		for _, criteria := range result.Criteria {
+			value := ""
+			if v, ok := criteriaValue(criteria); ok {
+				value = strconv.FormatFloat(v, 'f', 4, 64)
+			}
+			entries = append(entries, result.Model+"|"+string(criteria.GetType())+"|"+value)
+		}
+	}
+	sort.Strings(entries)
+
+	hash := sha256.Sum256([]byte(strings.Join(entries, "\n")))
+	return hex.EncodeToString(hash[:])
+}
+
+// ResultScoreVector returns the normalised [0, 1] score of every model response and criteria type,
+// keyed by "model:type", used to compare submissions made on the same task
+func ResultScoreVector(results []Result) map[string]float64 {
+	vector := make(map[string]float64)
+	for _, result := range results {
+		for _, criteria := range result.Criteria {
+			if value, ok := normalisedScore(criteria); ok {
+				vector[result.Model+":"+string(criteria.GetType())] = value
+			}
+		}
+	}
+	return vector
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"dojo-api/db"
+	"dojo-api/pkg/orm"
 )
 
 // TaskResponse reflects the task structure used in API responses
@@ -29,6 +30,33 @@ type TaskPaginationResponse struct {
 	IsCompletedByWorker bool `json:"isCompletedByWorker"`
 }
 
+type WorkerHistoryParams struct {
+	Page  int
+	Limit int
+	Types []db.TaskType
+	From  *time.Time
+	To    *time.Time
+}
+
+// WorkerHistoryItem is one submission of the worker, ResultData holds the scores as submitted rather than scaled to the
+// task's range and FinalisedReward is nil until the task is settled
+type WorkerHistoryItem struct {
+	TaskResultId    string              `json:"taskResultId"`
+	TaskId          string              `json:"taskId"`
+	TaskTitle       string              `json:"taskTitle"`
+	TaskType        db.TaskType         `json:"taskType"`
+	Status          db.TaskResultStatus `json:"status"`
+	ResultData      []Result            `json:"resultData"`
+	FinalisedReward *float64            `json:"finalisedReward"`
+	CreatedAt       time.Time           `json:"createdAt"`
+	UpdatedAt       time.Time           `json:"updatedAt"`
+}
+
+type WorkerHistoryResponse struct {
+	History    []WorkerHistoryItem `json:"history"`
+	Pagination Pagination          `json:"pagination"`
+}
+
 type SortField string
 
 const (
@@ -59,6 +87,20 @@ type CreateTaskRequest struct {
 	TaskData     []TaskData  `json:"taskData"`
 	MaxResults   int         `json:"maxResults"`
 	TotalRewards float64     `json:"totalRewards"`
+	// gold tasks look like ordinary tasks to workers but every submission is graded against GoldAnswers
+	IsGold      bool         `json:"isGold"`
+	GoldAnswers []GoldAnswer `json:"goldAnswers,omitempty"`
+	// workers below this reputation score do not see the task and cannot submit to it
+	MinReputation *float64 `json:"minReputation,omitempty"`
+}
+
+// GoldAnswer is the expected score for one model response of a gold task, a submitted score
+// passes when it is within Tolerance of Value
+type GoldAnswer struct {
+	Model     string       `json:"model"`
+	Type      CriteriaType `json:"type"`
+	Value     float64      `json:"value"`
+	Tolerance float64      `json:"tolerance"`
 }
 
 type TaskData struct {
@@ -106,6 +148,12 @@ const (
 	CriteriaMultiScore      CriteriaType = "multi-score"
 )
 
+// the range workers submit scores in, they are scaled to the range of the task's criteria before they are stored
+const (
+	submittedScoreMin = 1
+	submittedScoreMax = 10
+)
+
 type Result struct {
 	Model    string     `json:"model"`
 	Criteria []Criteria `json:"criteria"`
@@ -113,15 +161,87 @@ type Result struct {
 
 // embed TaskResultModel to reuse its fields
 // override ResultData, also will shadow the original "result_data" JSON field
+// TaskResult is a result as returned by the public task result endpoint, fields used to grade or screen results
+// such as gold_passed, fingerprint and client_ip are deliberately left out
 type TaskResult struct {
-	db.TaskResultModel
-	ResultData []Result `json:"result_data"`
+	ID              string              `json:"id"`
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+	Status          db.TaskResultStatus `json:"status"`
+	TaskID          string              `json:"task_id"`
+	WorkerID        string              `json:"worker_id"`
+	StakeAmount     *float64            `json:"stake_amount,omitempty"`
+	PotentialReward *float64            `json:"potential_reward,omitempty"`
+	PotentialLoss   *float64            `json:"potential_loss,omitempty"`
+	FinalisedReward *float64            `json:"finalised_reward,omitempty"`
+	FinalisedLoss   *float64            `json:"finalised_loss,omitempty"`
+	ResultData      []Result            `json:"result_data"`
+	// only set when calibration is requested, ResultData always holds the raw scores
+	CalibratedScores []CalibratedScore `json:"calibrated_scores,omitempty"`
+}
+
+func NewTaskResult(taskResult db.TaskResultModel, resultData []Result) TaskResult {
+	return TaskResult{
+		ID:              taskResult.ID,
+		CreatedAt:       taskResult.CreatedAt,
+		UpdatedAt:       taskResult.UpdatedAt,
+		Status:          taskResult.Status,
+		TaskID:          taskResult.TaskID,
+		WorkerID:        taskResult.WorkerID,
+		StakeAmount:     taskResult.InnerTaskResult.StakeAmount,
+		PotentialReward: taskResult.InnerTaskResult.PotentialReward,
+		PotentialLoss:   taskResult.InnerTaskResult.PotentialLoss,
+		FinalisedReward: taskResult.InnerTaskResult.FinalisedReward,
+		FinalisedLoss:   taskResult.InnerTaskResult.FinalisedLoss,
+		ResultData:      resultData,
+	}
+}
+
+// CalibratedScore pairs the raw value of a criterion with the value calibrated against the worker's own scores
+type CalibratedScore struct {
+	Model           string            `json:"model"`
+	Type            CriteriaType      `json:"type"`
+	Method          CalibrationMethod `json:"method"`
+	Value           float64           `json:"value"`
+	CalibratedValue *float64          `json:"calibratedValue"`
+	// number of scores of this criteria type the worker's distribution is built from
+	NumSamples int `json:"numSamples"`
 }
 
 type TaskResultResponse struct {
 	TaskResults []TaskResult `json:"taskResults"`
 }
 
+type HistogramBucket struct {
+	Value float64 `json:"value"`
+	Count int     `json:"count"`
+}
+
+type CriteriaAggregate struct {
+	Type   CriteriaType `json:"type"`
+	Count  int          `json:"count"`
+	Mean   float64      `json:"mean"`
+	Median float64      `json:"median"`
+	Std    float64      `json:"std"`
+	Min    float64      `json:"min"`
+	Max    float64      `json:"max"`
+	// one bucket per distinct submitted value, sorted by value
+	Histogram []HistogramBucket `json:"histogram"`
+}
+
+type ModelAggregate struct {
+	Model    string              `json:"model"`
+	Criteria []CriteriaAggregate `json:"criteria"`
+}
+
+type TaskResultAggregateResponse struct {
+	TaskId     string           `json:"taskId"`
+	TaskStatus db.TaskStatus    `json:"taskStatus"`
+	NumResults int              `json:"numResults"`
+	Models     []ModelAggregate `json:"models"`
+	ComputedAt time.Time        `json:"computedAt"`
+}
+
 type SubmitTaskResultRequest struct {
 	ResultData []Result `json:"resultData" binding:"required"`
 }
@@ -141,12 +261,126 @@ type NextTaskResponse struct {
 	NextInProgressTaskId string `json:"nextInProgressTaskId"`
 }
 
+type CloseTaskAction string
+
+const (
+	// CloseTaskActionCancel withdraws the task, it disappears from workers' feeds
+	CloseTaskActionCancel CloseTaskAction = "cancel"
+	// CloseTaskActionClose stops collecting results early and keeps the collected ones
+	CloseTaskActionClose CloseTaskAction = "close"
+)
+
+type CloseTasksRequest struct {
+	TaskIds []string        `json:"taskIds" binding:"required"`
+	Action  CloseTaskAction `json:"action" binding:"required"`
+}
+
+type CloseTasksResponse struct {
+	UpdatedTaskIds []string `json:"updatedTaskIds"`
+	SkippedTaskIds []string `json:"skippedTaskIds"`
+}
+
+// UpdateTaskRequest only contains the fields a miner may edit after creation, nil fields are left untouched
+type UpdateTaskRequest struct {
+	Title         *string  `json:"title"`
+	Body          *string  `json:"body"`
+	ExpireAt      *string  `json:"expireAt"`
+	MaxResults    *int     `json:"maxResults"`
+	TotalRewards  *float64 `json:"totalRewards"`
+	MinReputation *float64 `json:"minReputation"`
+	// must be explicitly set to move an EXPIRED or COMPLETED task back to IN_PROGRESS
+	Reopen bool `json:"reopen"`
+}
+
+type TaskFieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+type UpdateTaskResponse struct {
+	Task    TaskResponse               `json:"task"`
+	Changes map[string]TaskFieldChange `json:"changes"`
+}
+
+type MinerTaskFilterParams struct {
+	Statuses    []db.TaskStatus
+	Types       []db.TaskType
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	MinResults  int
+	Cursor      string
+	Limit       int
+}
+
+type TaskProgress struct {
+	NumResults      int     `json:"numResults"`
+	MaxResults      int     `json:"maxResults"`
+	PercentComplete float64 `json:"percentComplete"`
+	// seconds left until expireAt, 0 once the task has expired
+	TimeToExpiry int64 `json:"timeToExpiry"`
+}
+
+type MinerTaskResponse struct {
+	ID            string        `json:"taskId"`
+	Title         string        `json:"title"`
+	Type          db.TaskType   `json:"type"`
+	Status        db.TaskStatus `json:"status"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	ExpireAt      time.Time     `json:"expireAt"`
+	TotalReward   *float64      `json:"totalReward"`
+	IsGold        bool          `json:"isGold"`
+	MinReputation *float64      `json:"minReputation"`
+	Progress      TaskProgress  `json:"progress"`
+}
+
+type WorkerGoldAccuracyResponse struct {
+	WorkerId  string    `json:"workerId"`
+	NumGraded int       `json:"numGraded"`
+	NumPassed int       `json:"numPassed"`
+	Accuracy  float64   `json:"accuracy"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type WorkerGoldAccuracyListResponse struct {
+	Workers []WorkerGoldAccuracyResponse `json:"workers"`
+}
+
+type MinerTaskListResponse struct {
+	Tasks []MinerTaskResponse `json:"tasks"`
+	// pass as the cursor query param to fetch the next page, empty when there are no more tasks
+	NextCursor string `json:"nextCursor"`
+}
+
+// SubmissionMeta describes how a result was submitted, alongside the result itself
+type SubmissionMeta struct {
+	ClientIP string
+	// seconds between the worker first fetching the task and submitting, nil when no fetch was recorded
+	TimeOnTask *float64
+	// set when the worker fetched the task with their token and was shown the responses in their own order
+	ShuffledOrderServed bool
+	FlagReasons         []string
+	// set when the worker's stake was locked for the submission
+	Stake *SubmissionStake
+}
+
+// SubmissionStake is the stake locked for a submission, the result is stored under TaskResultID
+type SubmissionStake struct {
+	TaskResultID    string
+	StakeAmount     float64
+	PotentialReward float64
+	PotentialLoss   float64
+}
+
 type PaginationParams struct {
 	Page  int          `json:"page"`
 	Limit int          `json:"limit"`
 	Types []string     `json:"types"`
 	Sort  string       `json:"sort"`
 	Order db.SortOrder `json:"order"`
+	// tasks requiring a higher reputation than the worker's are left out
+	WorkerReputation float64 `json:"-"`
+	// miner and task type pairs whose qualification test the worker has not passed yet
+	UnqualifiedScopes []orm.MinerTaskType `json:"-"`
 }
 
 // Implement GetType for all criteria types
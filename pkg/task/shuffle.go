@@ -0,0 +1,26 @@
+package task
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/rand"
+)
+
+// ResponsePermutation returns the order a worker sees the n responses of a task in, as the original index
+// of the response at each position. It is seeded by the worker and task so every fetch shows the same order.
+func ResponsePermutation(workerId string, taskId string, n int) []int {
+	hash := sha256.Sum256([]byte(workerId + ":" + taskId))
+	seed := int64(binary.BigEndian.Uint64(hash[:8]))
+	return rand.New(rand.NewSource(seed)).Perm(n)
+}
+
+// ShuffleResponses reorders the task's responses for the worker and returns the permutation applied
+func ShuffleResponses(taskData *TaskData, workerId string, taskId string) []int {
+	permutation := ResponsePermutation(workerId, taskId, len(taskData.Responses))
+	shuffled := make([]ModelResponse, len(taskData.Responses))
+	for position, index := range permutation {
+		shuffled[position] = taskData.Responses[index]
+	}
+	taskData.Responses = shuffled
+	return permutation
+}
@@ -32,8 +32,9 @@ func NewTaskService() *TaskService {
 	}
 }
 
-// get task by id
-func (taskService *TaskService) GetTaskResponseById(ctx context.Context, id string) (*TaskResponse, error) {
+// get task by id, workers and anyone but the miner who owns the task see the model names behind aliases, the
+// responses are also shuffled for the worker when a worker ID is given
+func (taskService *TaskService) GetTaskResponseById(ctx context.Context, id string, workerId string, minerUserId string) (*TaskResponse, error) {
 	taskORM := orm.NewTaskORM()
 
 	task, err := taskORM.GetById(ctx, id)
@@ -46,8 +47,42 @@ func (taskService *TaskService) GetTaskResponseById(ctx context.Context, id stri
 		return nil, fmt.Errorf("no task found with ID %s", id)
 	}
 
+	ownerUserId, _ := task.MinerUserID()
+	isOwner := workerId == "" && minerUserId != "" && minerUserId == ownerUserId
+	return buildWorkerTaskResponse(task, workerId, !isOwner)
+}
+
+// buildWorkerTaskResponse hides the model names behind aliases when blind and, for a known worker,
+// shows the responses in the worker's own order to counter position bias
+func buildWorkerTaskResponse(task *db.TaskModel, workerId string, blind bool) (*TaskResponse, error) {
+	var taskData TaskData
+	if err := json.Unmarshal(task.TaskData, &taskData); err != nil {
+		log.Error().Err(err).Msg("Error parsing task data")
+		return nil, err
+	}
+	if blind {
+		BlindModels(&taskData, task.ID)
+	}
+	if workerId != "" {
+		ShuffleResponses(&taskData, workerId, task.ID)
+	}
+
+	return &TaskResponse{
+		ID:         task.ID,
+		Title:      task.Title,
+		Body:       task.Body,
+		ExpireAt:   task.ExpireAt,
+		Type:       task.Type,
+		TaskData:   taskData,
+		Status:     task.Status,
+		MaxResults: task.MaxResults,
+		NumResults: task.NumResults,
+	}, nil
+}
+
+func buildTaskResponse(task *db.TaskModel) (*TaskResponse, error) {
 	var rawJSON json.RawMessage
-	err = json.Unmarshal([]byte(task.TaskData), &rawJSON)
+	err := json.Unmarshal([]byte(task.TaskData), &rawJSON)
 	if err != nil {
 		log.Error().Err(err).Msg("Error parsing task data")
 		return nil, err
@@ -87,17 +122,19 @@ func (taskService *TaskService) GetTasksByPagination(ctx context.Context, worker
 		return nil, errs
 	}
 
-	// Fetch all completed task by this worker
-	completedTaskMap, _ := taskService.GetCompletedTaskMap(ctx, workerId)
-
-	log.Debug().Interface("completedTaskMap", completedTaskMap).Msg("Completed Task Mapping -------")
-
-	tasks, totalTasks, err := taskService.taskORM.GetTasksByWorkerSubscription(ctx, workerId, offset, params.Limit, sortQuery, taskTypes)
+	tasks, totalTasks, err := taskService.taskORM.GetTasksByWorkerSubscription(ctx, workerId, offset, params.Limit, sortQuery, taskTypes, params.WorkerReputation, params.UnqualifiedScopes)
 	if err != nil {
 		log.Error().Err(err).Msg("Error getting tasks by pagination")
 		return nil, []error{err}
 	}
 
+	// Only look up completion for the tasks on this page
+	taskIds := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		taskIds = append(taskIds, task.ID)
+	}
+	completedTaskMap, _ := taskService.GetCompletedTaskMap(ctx, workerId, taskIds)
+
 	// Convert tasks to TaskResponse model
 	taskResponses := make([]TaskPaginationResponse, 0)
 	for _, task := range tasks {
@@ -111,6 +148,8 @@ func (taskService *TaskService) GetTasksByPagination(ctx context.Context, worker
 		for i := range taskData.Responses {
 			taskData.Responses[i].Completion = nil
 		}
+		BlindModels(&taskData, task.ID)
+		ShuffleResponses(&taskData, workerId, task.ID)
 
 		taskResponse := TaskPaginationResponse{
 			TaskResponse: TaskResponse{ // Fill the embedded TaskResponse structure.
@@ -145,6 +184,114 @@ func (taskService *TaskService) GetTasksByPagination(ctx context.Context, worker
 	}, []error{}
 }
 
+// GetTasksByMinerUser lists the miner's own tasks together with their progress, using cursor based pagination
+func (taskService *TaskService) GetTasksByMinerUser(ctx context.Context, minerUserId string, params MinerTaskFilterParams) (*MinerTaskListResponse, error) {
+	filterParams := make([]db.TaskWhereParam, 0)
+	if len(params.Statuses) > 0 {
+		filterParams = append(filterParams, db.Task.Status.In(params.Statuses))
+	}
+	if len(params.Types) > 0 {
+		filterParams = append(filterParams, db.Task.Type.In(params.Types))
+	}
+	if params.CreatedFrom != nil {
+		filterParams = append(filterParams, db.Task.CreatedAt.Gte(*params.CreatedFrom))
+	}
+	if params.CreatedTo != nil {
+		filterParams = append(filterParams, db.Task.CreatedAt.Lte(*params.CreatedTo))
+	}
+	if params.MinResults > 0 {
+		filterParams = append(filterParams, db.Task.NumResults.Gte(params.MinResults))
+	}
+
+	// fetch one extra task to know whether there is a next page
+	tasks, err := taskService.taskORM.GetTasksByMinerUser(ctx, minerUserId, filterParams, params.Cursor, params.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	nextCursor := ""
+	if len(tasks) > params.Limit {
+		tasks = tasks[:params.Limit]
+		nextCursor = tasks[len(tasks)-1].ID
+	}
+
+	now := time.Now()
+	minerTasks := make([]MinerTaskResponse, 0, len(tasks))
+	for _, task := range tasks {
+		var totalReward *float64
+		if reward, ok := task.TotalReward(); ok {
+			totalReward = &reward
+		}
+
+		var minReputation *float64
+		if value, ok := task.MinReputation(); ok {
+			minReputation = &value
+		}
+
+		var percentComplete float64
+		if task.MaxResults > 0 {
+			percentComplete = math.Round(float64(task.NumResults)/float64(task.MaxResults)*10000) / 100
+		}
+
+		var timeToExpiry int64
+		if task.ExpireAt.After(now) {
+			timeToExpiry = int64(task.ExpireAt.Sub(now).Seconds())
+		}
+
+		minerTasks = append(minerTasks, MinerTaskResponse{
+			ID:            task.ID,
+			Title:         task.Title,
+			Type:          task.Type,
+			Status:        task.Status,
+			CreatedAt:     task.CreatedAt,
+			ExpireAt:      task.ExpireAt,
+			TotalReward:   totalReward,
+			IsGold:        task.IsGold,
+			MinReputation: minReputation,
+			Progress: TaskProgress{
+				NumResults:      task.NumResults,
+				MaxResults:      task.MaxResults,
+				PercentComplete: percentComplete,
+				TimeToExpiry:    timeToExpiry,
+			},
+		})
+	}
+
+	return &MinerTaskListResponse{
+		Tasks:      minerTasks,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// ParseTaskStatuses converts a list of strings into task statuses, rejecting unknown values
+func ParseTaskStatuses(statuses []string) ([]db.TaskStatus, error) {
+	validStatuses := []db.TaskStatus{db.TaskStatusInProgress, db.TaskStatusCompleted, db.TaskStatusExpired, db.TaskStatusCancelled}
+	converted := make([]db.TaskStatus, 0, len(statuses))
+	for _, status := range statuses {
+		found := false
+		for _, validStatus := range validStatuses {
+			if status == string(validStatus) {
+				converted = append(converted, validStatus)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid task status: '%v', supported statuses are %v", status, validStatuses)
+		}
+	}
+	return converted, nil
+}
+
+// ParseTaskTypes converts a list of strings into task types, rejecting unknown values
+func ParseTaskTypes(taskTypes []string) ([]db.TaskType, error) {
+	converted, errs := convertStringToTaskTypes(taskTypes)
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return converted, nil
+}
+
 func convertStringToTaskTypes(taskTypes []string) ([]db.TaskType, []error) {
 	convertedTypes := make([]db.TaskType, 0)
 	errors := make([]error, 0)
@@ -268,6 +415,20 @@ func (s *TaskService) CreateTasks(ctx context.Context, request CreateTaskRequest
 			taskToCreate.TotalReward = &request.TotalRewards
 		}
 
+		taskToCreate.MinReputation = request.MinReputation
+
+		if request.IsGold {
+			goldAnswers, err := json.Marshal(request.GoldAnswers)
+			if err != nil {
+				log.Error().Err(err).Msgf("Error marshaling gold answers")
+				errors = append(errors, err)
+				continue
+			}
+			goldAnswersJSON := db.JSON(goldAnswers)
+			taskToCreate.IsGold = true
+			taskToCreate.GoldAnswers = &goldAnswersJSON
+		}
+
 		task, err := taskORM.CreateTask(ctx, taskToCreate, minerUserId)
 		if err != nil {
 			log.Error().Msgf("Error creating task: %v", err)
@@ -278,6 +439,243 @@ func (s *TaskService) CreateTasks(ctx context.Context, request CreateTaskRequest
 	return tasks, errors
 }
 
+// GetWorkerGoldAccuracyByMinerUser lists the gold accuracy of every worker that submitted to one of the miner's gold tasks
+func (t *TaskService) GetWorkerGoldAccuracyByMinerUser(ctx context.Context, minerUserId string) (*WorkerGoldAccuracyListResponse, error) {
+	accuracies, err := orm.NewWorkerGoldAccuracyORM().GetByMinerUser(ctx, minerUserId)
+	if err != nil {
+		log.Error().Err(err).Str("minerUserId", minerUserId).Msg("Error getting worker gold accuracy")
+		return nil, err
+	}
+
+	workers := make([]WorkerGoldAccuracyResponse, 0, len(accuracies))
+	for _, accuracy := range accuracies {
+		var ratio float64
+		if accuracy.NumGraded > 0 {
+			ratio = float64(accuracy.NumPassed) / float64(accuracy.NumGraded)
+		}
+		workers = append(workers, WorkerGoldAccuracyResponse{
+			WorkerId:  accuracy.WorkerID,
+			NumGraded: accuracy.NumGraded,
+			NumPassed: accuracy.NumPassed,
+			Accuracy:  ratio,
+			UpdatedAt: accuracy.UpdatedAt,
+		})
+	}
+	return &WorkerGoldAccuracyListResponse{Workers: workers}, nil
+}
+
+// CloseTasks cancels or early-closes the miner's in progress tasks, tasks that are not owned by the miner
+// or are no longer in progress are reported back as skipped
+func (t *TaskService) CloseTasks(ctx context.Context, minerUserId string, request CloseTasksRequest) (*CloseTasksResponse, error) {
+	var status db.TaskStatus
+	switch request.Action {
+	case CloseTaskActionCancel:
+		status = db.TaskStatusCancelled
+	case CloseTaskActionClose:
+		status = db.TaskStatusCompleted
+	default:
+		return nil, fmt.Errorf("invalid action: '%v', supported actions are %v and %v", request.Action, CloseTaskActionCancel, CloseTaskActionClose)
+	}
+
+	if len(request.TaskIds) == 0 {
+		return nil, errors.New("taskIds shouldn't be empty")
+	}
+
+	updatedTaskIds, err := t.taskORM.UpdateInProgressTasksStatus(ctx, minerUserId, request.TaskIds, status)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := make(map[string]bool, len(updatedTaskIds))
+	for _, taskId := range updatedTaskIds {
+		updated[taskId] = true
+	}
+
+	skippedTaskIds := make([]string, 0)
+	for _, taskId := range request.TaskIds {
+		if !updated[taskId] {
+			skippedTaskIds = append(skippedTaskIds, taskId)
+		}
+	}
+
+	return &CloseTasksResponse{
+		UpdatedTaskIds: updatedTaskIds,
+		SkippedTaskIds: skippedTaskIds,
+	}, nil
+}
+
+type ErrInvalidTaskUpdate struct {
+	Reason string
+}
+
+func (e *ErrInvalidTaskUpdate) Error() string {
+	return fmt.Sprintf("invalid task update: %s", e.Reason)
+}
+
+// UpdateTask applies a miner's edits to a task they own, every applied change is written to the task history
+//
+//nolint:gocyclo
+func (t *TaskService) UpdateTask(ctx context.Context, minerUserId string, taskId string, request UpdateTaskRequest) (*UpdateTaskResponse, error) {
+	task, err := t.taskORM.GetByIdAndMinerUser(ctx, taskId, minerUserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.Status == db.TaskStatusCancelled {
+		return nil, &ErrInvalidTaskUpdate{Reason: "task is cancelled"}
+	}
+
+	changes := make(map[string]TaskFieldChange)
+	params := make([]db.TaskSetParam, 0)
+
+	if request.Title != nil && *request.Title != task.Title {
+		if *request.Title == "" {
+			return nil, &ErrInvalidTaskUpdate{Reason: "title cannot be empty"}
+		}
+		changes["title"] = TaskFieldChange{From: task.Title, To: *request.Title}
+		params = append(params, db.Task.Title.Set(*request.Title))
+	}
+
+	if request.Body != nil && *request.Body != task.Body {
+		if *request.Body == "" {
+			return nil, &ErrInvalidTaskUpdate{Reason: "body cannot be empty"}
+		}
+		changes["body"] = TaskFieldChange{From: task.Body, To: *request.Body}
+		params = append(params, db.Task.Body.Set(*request.Body))
+	}
+
+	if request.TotalRewards != nil {
+		if *request.TotalRewards < 0 {
+			return nil, &ErrInvalidTaskUpdate{Reason: "totalRewards cannot be negative"}
+		}
+		currentReward, hasReward := task.TotalReward()
+		if !hasReward || currentReward != *request.TotalRewards {
+			// rewards of a settled task were already paid out of its total reward
+			settled, err := isTaskSettled(ctx, task.ID)
+			if err != nil {
+				return nil, err
+			}
+			if settled {
+				return nil, &ErrInvalidTaskUpdate{Reason: "task is already settled and its totalRewards cannot be changed"}
+			}
+			var from interface{}
+			if hasReward {
+				from = currentReward
+			}
+			changes["totalRewards"] = TaskFieldChange{From: from, To: *request.TotalRewards}
+			params = append(params, db.Task.TotalReward.Set(*request.TotalRewards))
+		}
+	}
+
+	if request.MinReputation != nil {
+		if *request.MinReputation < 0 || *request.MinReputation > 1 {
+			return nil, &ErrInvalidTaskUpdate{Reason: "minReputation must be between 0 and 1"}
+		}
+		currentMinReputation, hasMinReputation := task.MinReputation()
+		if !hasMinReputation || currentMinReputation != *request.MinReputation {
+			var from interface{}
+			if hasMinReputation {
+				from = currentMinReputation
+			}
+			changes["minReputation"] = TaskFieldChange{From: from, To: *request.MinReputation}
+			params = append(params, db.Task.MinReputation.Set(*request.MinReputation))
+		}
+	}
+
+	expireAt := task.ExpireAt
+	if request.ExpireAt != nil {
+		newExpireAt := utils.ParseDate(*request.ExpireAt)
+		if newExpireAt == nil {
+			return nil, &ErrInvalidTaskUpdate{Reason: "error parsing expireAt"}
+		}
+		if !newExpireAt.After(time.Now()) {
+			return nil, &ErrInvalidTaskUpdate{Reason: "expireAt must be in the future"}
+		}
+		if !newExpireAt.Equal(task.ExpireAt) {
+			expireAt = *newExpireAt
+			changes["expireAt"] = TaskFieldChange{From: task.ExpireAt, To: expireAt}
+			params = append(params, db.Task.ExpireAt.Set(expireAt))
+		}
+	}
+
+	maxResults := task.MaxResults
+	if request.MaxResults != nil && *request.MaxResults != task.MaxResults {
+		if *request.MaxResults < task.NumResults {
+			return nil, &ErrInvalidTaskUpdate{Reason: fmt.Sprintf("maxResults cannot be lower than the number of results already collected (%d)", task.NumResults)}
+		}
+		if *request.MaxResults <= 0 {
+			return nil, &ErrInvalidTaskUpdate{Reason: "maxResults must be greater than 0"}
+		}
+		maxResults = *request.MaxResults
+		changes["maxResults"] = TaskFieldChange{From: task.MaxResults, To: maxResults}
+		params = append(params, db.Task.MaxResults.Set(maxResults))
+	}
+
+	// Work out the resulting status, tasks that stopped collecting results only go back to IN_PROGRESS on request
+	status := task.Status
+	switch task.Status {
+	case db.TaskStatusInProgress:
+		if maxResults == task.NumResults {
+			status = db.TaskStatusCompleted
+		}
+	case db.TaskStatusExpired, db.TaskStatusCompleted:
+		if request.Reopen {
+			if !expireAt.After(time.Now()) {
+				return nil, &ErrInvalidTaskUpdate{Reason: "a future expireAt is required to reopen the task"}
+			}
+			if maxResults <= task.NumResults {
+				return nil, &ErrInvalidTaskUpdate{Reason: "maxResults must be greater than the number of results already collected to reopen the task"}
+			}
+			// rewards of a settled task were already paid out of its total reward
+			settled, err := isTaskSettled(ctx, task.ID)
+			if err != nil {
+				return nil, err
+			}
+			if settled {
+				return nil, &ErrInvalidTaskUpdate{Reason: "task is already settled and cannot be reopened"}
+			}
+			status = db.TaskStatusInProgress
+		} else if task.Status == db.TaskStatusExpired && request.ExpireAt != nil {
+			return nil, &ErrInvalidTaskUpdate{Reason: "task is expired, set reopen to true to extend it"}
+		}
+	}
+
+	if status != task.Status {
+		changes["status"] = TaskFieldChange{From: task.Status, To: status}
+		params = append(params, db.Task.Status.Set(status))
+	}
+
+	if len(changes) == 0 {
+		return nil, &ErrInvalidTaskUpdate{Reason: "no changes to apply"}
+	}
+
+	updatedTask, err := t.taskORM.UpdateTaskWithHistory(ctx, task.ID, minerUserId, changes, params...)
+	if err != nil {
+		log.Error().Err(err).Str("taskId", task.ID).Msg("Error updating task")
+		return nil, err
+	}
+
+	taskResponse, err := buildTaskResponse(updatedTask)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UpdateTaskResponse{
+		Task:    *taskResponse,
+		Changes: changes,
+	}, nil
+}
+
+func isTaskSettled(ctx context.Context, taskId string) (bool, error) {
+	if _, err := orm.NewTaskSettlementORM().GetByTaskId(ctx, taskId); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
 func (t *TaskService) GetTaskById(ctx context.Context, id string) (*db.TaskModel, error) {
 	task, err := t.taskORM.GetById(ctx, id)
 	if err != nil {
@@ -291,13 +689,26 @@ func (t *TaskService) GetTaskById(ctx context.Context, id string) (*db.TaskModel
 }
 
 // TODO: Update this function with the new Resultdata structure
-func (t *TaskService) UpdateTaskResults(ctx context.Context, task *db.TaskModel, dojoWorkerId string, results []Result) (*db.TaskModel, error) {
+// UpdateTaskResults validates and stores a worker's submission along with how it was submitted,
+// a submission carrying flag reasons is stored and flagged for operator review
+func (t *TaskService) UpdateTaskResults(ctx context.Context, task *db.TaskModel, dojoWorkerId string, results []Result, meta SubmissionMeta) (*db.TaskModel, error) {
 	validatedResults, err := ValidateResultData(results, task)
 	if err != nil {
 		log.Error().Err(err).Msg("Error validating result data")
 		return nil, err
 	}
 
+	// Gold answers are expressed in the range workers submit in, so grade before the scores are scaled
+	var goldPassed *bool
+	if task.IsGold {
+		passed, err := GradeGoldResult(task, validatedResults)
+		if err != nil {
+			log.Error().Err(err).Msg("Error grading gold task result")
+			return nil, err
+		}
+		goldPassed = &passed
+	}
+
 	// Process and scale the scores
 	processedResults, err := ProcessScores(validatedResults, task)
 	if err != nil {
@@ -311,17 +722,44 @@ func (t *TaskService) UpdateTaskResults(ctx context.Context, task *db.TaskModel,
 		return nil, err
 	}
 
+	// results are keyed by model so the order only matters for analysis, it is the order the worker was shown and
+	// is only known when the worker was served their shuffled order
+	responseOrder := []int{}
+	if meta.ShuffledOrderServed {
+		var taskData TaskData
+		if err := json.Unmarshal(task.TaskData, &taskData); err != nil {
+			log.Error().Err(err).Msg("Error unmarshaling task data")
+			return nil, err
+		}
+		responseOrder = ResponsePermutation(dojoWorkerId, task.ID, len(taskData.Responses))
+	}
+
+	fingerprint := ResultFingerprint(processedResults)
 	newTaskResultData := db.InnerTaskResult{
-		Status:     db.TaskResultStatusCompleted,
-		ResultData: jsonResults,
-		TaskID:     task.ID,
-		WorkerID:   dojoWorkerId,
+		Status:        db.TaskResultStatusCompleted,
+		ResultData:    jsonResults,
+		TaskID:        task.ID,
+		WorkerID:      dojoWorkerId,
+		GoldPassed:    goldPassed,
+		Fingerprint:   &fingerprint,
+		TimeOnTask:    meta.TimeOnTask,
+		ResponseOrder: responseOrder,
+	}
+	if meta.ClientIP != "" {
+		newTaskResultData.ClientIP = &meta.ClientIP
+	}
+	if meta.Stake != nil {
+		newTaskResultData.ID = meta.Stake.TaskResultID
+		newTaskResultData.StakeAmount = &meta.Stake.StakeAmount
+		newTaskResultData.PotentialReward = &meta.Stake.PotentialReward
+		newTaskResultData.PotentialLoss = &meta.Stake.PotentialLoss
 	}
 
-	// Check if the task has reached the max results, no way we can have greater than max results, or something's wrong
+	// Check if the task has reached the max results, the slot itself is claimed atomically when the result is stored
 	if task.NumResults >= task.MaxResults {
 		log.Info().Msg("Task has reached max results")
 		newTaskResultData.Status = db.TaskResultStatusInvalid
+		newTaskResultData.GoldPassed = nil
 	}
 
 	// Insert the task result data
@@ -331,6 +769,28 @@ func (t *TaskService) UpdateTaskResults(ctx context.Context, task *db.TaskModel,
 		return nil, err
 	}
 
+	// the submission is already stored, a failed aggregation is recomputed on the next read
+	if createdTaskResult.Status == db.TaskResultStatusCompleted {
+		if err := t.AddResultToAggregate(ctx, task.ID, processedResults); err != nil {
+			log.Warn().Err(err).Str("taskId", task.ID).Msg("Failed to add result to task result aggregate")
+		}
+		if err := t.UpdateWorkerScoreStats(ctx, dojoWorkerId, processedResults); err != nil {
+			log.Warn().Err(err).Str("workerId", dojoWorkerId).Msg("Failed to update worker score stats")
+		}
+	}
+
+	if len(meta.FlagReasons) > 0 {
+		flag := db.InnerResultFlag{
+			TaskResultID: createdTaskResult.ID,
+			ClusterID:    createdTaskResult.ID,
+			Reasons:      meta.FlagReasons,
+			Score:        1,
+		}
+		if err := orm.NewResultFlagORM().CreateFlags(ctx, []db.InnerResultFlag{flag}); err != nil {
+			log.Warn().Err(err).Str("taskResultId", createdTaskResult.ID).Msg("Failed to flag task result")
+		}
+	}
+
 	return createdTaskResult.Task(), nil
 }
 
@@ -341,7 +801,12 @@ func ValidateResultData(results []Result, task *db.TaskModel) ([]Result, error)
 		log.Error().Err(err).Msg("Error unmarshaling task data")
 		return nil, err
 	}
+	// workers answer with the aliases they were shown, results are stored under the real model names
+	return ValidateResultsForTaskData(UnblindResults(results, taskData, task.ID), taskData)
+}
 
+// ValidateResultsForTaskData checks each result against the criteria of the matching model response
+func ValidateResultsForTaskData(results []Result, taskData TaskData) ([]Result, error) {
 	// Pre-process task criteria for faster lookup
 	modelCriteriaMap := make(map[string]map[CriteriaType]Criteria)
 	for _, response := range taskData.Responses {
@@ -437,7 +902,7 @@ func ProcessScores(results []Result, task *db.TaskModel) ([]Result, error) {
 					return nil, fmt.Errorf("no matching score criteria found in task for model %s", result.Model)
 				}
 
-				scaledScore := scaleScore(submitted.MinerScore, 1, 10, taskCriteria.Min, taskCriteria.Max)
+				scaledScore := scaleScore(submitted.MinerScore, submittedScoreMin, submittedScoreMax, taskCriteria.Min, taskCriteria.Max)
 				results[i].Criteria[j] = ScoreCriteria{
 					Type:       CriteriaTypeScore,
 					Min:        taskCriteria.Min,
@@ -455,6 +920,28 @@ func scaleScore(score, oldMin, oldMax, newMin, newMax float64) float64 {
 	return ((score-oldMin)/(oldMax-oldMin))*(newMax-newMin) + newMin
 }
 
+// UnscaleScores reverses ProcessScores, the stored scores are brought back to the range the worker submitted in
+func UnscaleScores(results []Result) []Result {
+	unscaled := make([]Result, 0, len(results))
+	for _, result := range results {
+		criteria := make([]Criteria, 0, len(result.Criteria))
+		for _, c := range result.Criteria {
+			if scoreCriteria, ok := c.(ScoreCriteria); ok && scoreCriteria.Max > scoreCriteria.Min {
+				score := scaleScore(scoreCriteria.MinerScore, scoreCriteria.Min, scoreCriteria.Max, submittedScoreMin, submittedScoreMax)
+				// drop the floating point error picked up by scaling back and forth
+				scoreCriteria.MinerScore = math.Round(score*1e6) / 1e6
+				scoreCriteria.Min = submittedScoreMin
+				scoreCriteria.Max = submittedScoreMax
+				c = scoreCriteria
+			}
+			criteria = append(criteria, c)
+		}
+		result.Criteria = criteria
+		unscaled = append(unscaled, result)
+	}
+	return unscaled
+}
+
 // Validates a single task, reads the `type` field to determine different flows.
 //
 //nolint:gocyclo
@@ -566,9 +1053,102 @@ func ValidateTaskRequest(request CreateTaskRequest) error {
 		return errors.New("maxResults is required")
 	}
 
+	if request.MinReputation != nil && (*request.MinReputation < 0 || *request.MinReputation > 1) {
+		return errors.New("minReputation must be between 0 and 1")
+	}
+
+	if request.IsGold {
+		if err := validateGoldAnswers(request); err != nil {
+			return err
+		}
+	} else if len(request.GoldAnswers) > 0 {
+		return errors.New("goldAnswers can only be set on gold tasks")
+	}
+
+	return nil
+}
+
+// validateGoldAnswers checks that every task in the request has a score criteria for each gold answer
+// and that the expected value lies within that criteria's range
+func validateGoldAnswers(request CreateTaskRequest) error {
+	if len(request.GoldAnswers) == 0 {
+		return errors.New("goldAnswers is required for gold tasks")
+	}
+
+	for _, currTask := range request.TaskData {
+		if err := ValidateGoldAnswers(currTask, request.GoldAnswers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateGoldAnswers checks gold answers against the score criteria of a single task data entry
+func ValidateGoldAnswers(taskData TaskData, goldAnswers []GoldAnswer) error {
+	modelCriteriaMap := make(map[string]ScoreCriteria)
+	for _, response := range taskData.Responses {
+		for _, criteria := range response.Criteria {
+			if scoreCriteria, ok := criteria.(ScoreCriteria); ok {
+				modelCriteriaMap[response.Model] = scoreCriteria
+			}
+		}
+	}
+
+	for _, goldAnswer := range goldAnswers {
+		if goldAnswer.Type != CriteriaTypeScore {
+			return fmt.Errorf("unsupported gold answer type: %s", goldAnswer.Type)
+		}
+		if goldAnswer.Tolerance < 0 {
+			return fmt.Errorf("tolerance for model %s must not be negative", goldAnswer.Model)
+		}
+
+		scoreCriteria, ok := modelCriteriaMap[goldAnswer.Model]
+		if !ok {
+			return fmt.Errorf("no score criteria found for gold answer model %s", goldAnswer.Model)
+		}
+		if goldAnswer.Value < scoreCriteria.Min || goldAnswer.Value > scoreCriteria.Max {
+			return fmt.Errorf("gold answer %v for model %s is out of the valid range [%v, %v]",
+				goldAnswer.Value, goldAnswer.Model, scoreCriteria.Min, scoreCriteria.Max)
+		}
+	}
 	return nil
 }
 
+// GradeGoldResult passes a submission only if every gold answer of the task is matched within its tolerance,
+// a missing score counts as a miss
+func GradeGoldResult(task *db.TaskModel, results []Result) (bool, error) {
+	rawGoldAnswers, ok := task.GoldAnswers()
+	if !ok {
+		return false, fmt.Errorf("gold task %s has no gold answers", task.ID)
+	}
+
+	var goldAnswers []GoldAnswer
+	if err := json.Unmarshal(rawGoldAnswers, &goldAnswers); err != nil {
+		return false, err
+	}
+	return GradeGoldAnswers(goldAnswers, results), nil
+}
+
+// GradeGoldAnswers reports whether the submitted scores match every gold answer within its tolerance
+func GradeGoldAnswers(goldAnswers []GoldAnswer, results []Result) bool {
+	submittedScores := make(map[string]float64)
+	for _, result := range results {
+		for _, criteria := range result.Criteria {
+			if scoreCriteria, ok := criteria.(ScoreCriteria); ok {
+				submittedScores[result.Model] = scoreCriteria.MinerScore
+			}
+		}
+	}
+
+	for _, goldAnswer := range goldAnswers {
+		score, ok := submittedScores[goldAnswer.Model]
+		if !ok || math.Abs(score-goldAnswer.Value) > goldAnswer.Tolerance {
+			return false
+		}
+	}
+	return true
+}
+
 func ProcessTaskRequest(taskData CreateTaskRequest) (CreateTaskRequest, error) {
 	processedTaskData := make([]TaskData, 0)
 	for _, taskInterface := range taskData.TaskData {
@@ -629,25 +1209,60 @@ func (t *TaskService) ValidateCompletedTResultByWorker(ctx context.Context, task
 	return len(taskResult) > 0, nil // Task result exists
 }
 
-func (t *TaskService) GetCompletedTaskMap(ctx context.Context, workerId string) (map[string]bool, error) {
-	// Fetch all completed task by this worker
-	completedtResult, err := t.taskResultORM.GetCompletedTResultByWorker(ctx, workerId)
-	// Convert to a map for quick lookup
-	completedTaskMap := make(map[string]bool)
+// GetCompletedTaskMap tells which of the given tasks the worker already completed
+func (t *TaskService) GetCompletedTaskMap(ctx context.Context, workerId string, taskIds []string) (map[string]bool, error) {
+	completedTaskMap, err := t.taskResultORM.GetCompletedTaskIdsByWorker(ctx, workerId, taskIds)
+	if err != nil {
+		log.Error().Err(err).Str("workerId", workerId).Msg("Error getting completed tasks of worker")
+		return make(map[string]bool), err
+	}
+	return completedTaskMap, nil
+}
 
+// GetWorkerHistory returns a page of the worker's submissions, the scores are the values the worker submitted and
+// name models by the aliases the worker saw
+func (t *TaskService) GetWorkerHistory(ctx context.Context, workerId string, params WorkerHistoryParams) (*WorkerHistoryResponse, error) {
+	offset := (params.Page - 1) * params.Limit
+	taskResults, total, err := t.taskResultORM.GetWorkerHistory(ctx, workerId, params.Types, params.From, params.To, offset, params.Limit)
 	if err != nil {
-		if errors.Is(err, db.ErrNotFound) {
-			return completedTaskMap, nil // No existing task result found
-		}
+		log.Error().Err(err).Str("workerId", workerId).Msg("Error getting worker history")
 		return nil, err
 	}
 
-	if len(completedtResult) > 0 {
-		for _, ts := range completedtResult {
-			completedTaskMap[ts.TaskID] = true
+	history := make([]WorkerHistoryItem, 0, len(taskResults))
+	for _, taskResult := range taskResults {
+		var resultData []Result
+		if err := json.Unmarshal(taskResult.ResultData, &resultData); err != nil {
+			log.Error().Err(err).Str("taskResultId", taskResult.ID).Msg("Error parsing result data")
+			return nil, err
+		}
+
+		taskModel := taskResult.Task()
+		item := WorkerHistoryItem{
+			TaskResultId: taskResult.ID,
+			TaskId:       taskModel.ID,
+			TaskTitle:    taskModel.Title,
+			TaskType:     taskModel.Type,
+			Status:       taskResult.Status,
+			ResultData:   BlindResults(UnscaleScores(resultData), taskModel.ID),
+			CreatedAt:    taskResult.CreatedAt,
+			UpdatedAt:    taskResult.UpdatedAt,
+		}
+		if finalisedReward, ok := taskResult.FinalisedReward(); ok {
+			item.FinalisedReward = &finalisedReward
 		}
+		history = append(history, item)
 	}
-	return completedTaskMap, nil // Task result exists
+
+	return &WorkerHistoryResponse{
+		History: history,
+		Pagination: Pagination{
+			Page:       params.Page,
+			Limit:      params.Limit,
+			TotalPages: int(math.Ceil(float64(total) / float64(params.Limit))),
+			TotalItems: total,
+		},
+	}, nil
 }
 
 func ProcessRequestBody(c *gin.Context) (CreateTaskRequest, error) {
@@ -663,6 +1278,17 @@ func ProcessRequestBody(c *gin.Context) (CreateTaskRequest, error) {
 	expireAt := c.PostForm("expireAt")
 	maxResults, _ := strconv.Atoi(c.PostForm("maxResults"))
 	totalRewards, _ := strconv.ParseFloat(c.PostForm("totalRewards"), 64)
+	isGold, _ := strconv.ParseBool(c.PostForm("isGold"))
+
+	var minReputation *float64
+	if rawMinReputation := c.PostForm("minReputation"); rawMinReputation != "" {
+		value, err := strconv.ParseFloat(rawMinReputation, 64)
+		if err != nil {
+			log.Error().Err(err).Msg("Invalid minReputation")
+			return reqbody, err
+		}
+		minReputation = &value
+	}
 
 	var taskData []TaskData
 	if err := json.Unmarshal([]byte(c.PostForm("taskData")), &taskData); err != nil {
@@ -670,13 +1296,24 @@ func ProcessRequestBody(c *gin.Context) (CreateTaskRequest, error) {
 		return reqbody, err
 	}
 
+	var goldAnswers []GoldAnswer
+	if rawGoldAnswers := c.PostForm("goldAnswers"); rawGoldAnswers != "" {
+		if err := json.Unmarshal([]byte(rawGoldAnswers), &goldAnswers); err != nil {
+			log.Error().Err(err).Msg("Invalid goldAnswers")
+			return reqbody, err
+		}
+	}
+
 	reqbody = CreateTaskRequest{
-		Title:        title,
-		Body:         body,
-		ExpireAt:     expireAt,
-		TaskData:     taskData,
-		MaxResults:   maxResults,
-		TotalRewards: totalRewards,
+		Title:         title,
+		Body:          body,
+		ExpireAt:      expireAt,
+		TaskData:      taskData,
+		MaxResults:    maxResults,
+		TotalRewards:  totalRewards,
+		IsGold:        isGold,
+		GoldAnswers:   goldAnswers,
+		MinReputation: minReputation,
 	}
 
 	return reqbody, nil